@@ -0,0 +1,122 @@
+// Package blob provides storages of file contents addressed by their SHA-256.
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrInvalidKey is returned when the key is not a hex encoded SHA-256.
+var ErrInvalidKey = errors.New("blob: invalid key")
+
+// A LocalStore stores contents as files under a directory on the local filesystem.
+// A content is stored once at the path derived from its SHA-256, so the same
+// content put many times shares one file.
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore returns LocalStore storing contents under dir. The directory is
+// created if it does not exist.
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{
+		dir: dir,
+	}, nil
+}
+
+// Put stores the content read from r and returns its key and size.
+// Nothing is stored if reading r fails.
+func (s *LocalStore) Put(ctx context.Context, r io.Reader) (string, int64, error) {
+	// 一時ファイルに書き込みながらハッシュを計算し、書き込み終わってからキーのパスに移す
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), &contextReader{ctx: ctx, r: r})
+	if err != nil {
+		return "", 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, err
+	}
+
+	key := hex.EncodeToString(hash.Sum(nil))
+	path := s.path(key)
+
+	// 同じ内容がすでに保存されている場合は一時ファイルを捨てる
+	if _, err := os.Stat(path); err == nil {
+		return key, size, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, err
+	}
+	return key, size, nil
+}
+
+// Open opens the content of key. The returned error wraps fs.ErrNotExist if
+// the content is not stored.
+func (s *LocalStore) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	if !validKey(key) {
+		return nil, ErrInvalidKey
+	}
+	return os.Open(s.path(key))
+}
+
+// Delete deletes the content of key. Deleting a content not stored is not an error.
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("blob: failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// path はキーのファイルのパスを返す。1つのディレクトリにファイルが集中しないよう先頭2文字で分ける
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key)
+}
+
+// validKey はキーが16進数の SHA-256 であるかを返す。パスに使うので "../" などを含むキーは受け付けない
+func validKey(key string) bool {
+	if len(key) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range key {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// contextReader はコンテキストがキャンセルされたら読み取りをやめる io.Reader
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,122 @@
+package blob_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/blob"
+)
+
+func TestLocalStore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := blob.NewLocalStore(dir)
+	if err != nil {
+		t.Fatal("failed to create store, err =", err)
+	}
+
+	ctx := context.Background()
+	const content = "screenshot"
+	sum := sha256.Sum256([]byte(content))
+	want := hex.EncodeToString(sum[:])
+
+	// 同じ内容を2回保存しても同じキーの1ファイルになること
+	for i := 0; i < 2; i++ {
+		key, size, err := store.Put(ctx, strings.NewReader(content))
+		if err != nil {
+			t.Fatal("failed to put, err =", err)
+		}
+		if key != want || size != int64(len(content)) {
+			t.Errorf("unexpected key or size, given = %s, %d, expected = %s, %d", key, size, want, len(content))
+		}
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("unexpected stored files, given = %v", files)
+	}
+
+	f, err := store.Open(ctx, want)
+	if err != nil {
+		t.Fatal("failed to open, err =", err)
+	}
+	if _, err := f.Seek(6, io.SeekStart); err != nil {
+		t.Fatal("failed to seek, err =", err)
+	}
+	got, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatal("failed to read, err =", err)
+	}
+	if string(got) != "shot" {
+		t.Errorf("unexpected content, given = %q, expected = %q", got, "shot")
+	}
+
+	if err := store.Delete(ctx, want); err != nil {
+		t.Fatal("failed to delete, err =", err)
+	}
+	if err := store.Delete(ctx, want); err != nil {
+		t.Error("deleting twice must not fail, err =", err)
+	}
+	if _, err := store.Open(ctx, want); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected error, given = %v, expected = %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestLocalStorePutError(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := blob.NewLocalStore(dir)
+	if err != nil {
+		t.Fatal("failed to create store, err =", err)
+	}
+
+	// 読み取りに失敗した場合は何も残さないこと
+	failure := errors.New("connection reset")
+	_, _, err = store.Put(context.Background(), io.MultiReader(strings.NewReader("partial"), &errReader{err: failure}))
+	if !errors.Is(err, failure) {
+		t.Fatalf("unexpected error, given = %v, expected = %v", err, failure)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("files are left, given = %v", entries)
+	}
+}
+
+func TestLocalStoreInvalidKey(t *testing.T) {
+	t.Parallel()
+
+	store, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal("failed to create store, err =", err)
+	}
+
+	for _, key := range []string{"", "../../etc/passwd", strings.Repeat("G", 64), strings.Repeat("A", 64)} {
+		if _, err := store.Open(context.Background(), key); err != blob.ErrInvalidKey {
+			t.Errorf("unexpected error, key = %q, given = %v, expected = %v", key, err, blob.ErrInvalidKey)
+		}
+	}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,236 @@
+// Package client is a Go client for the TODO REST API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// 既定の設定
+const (
+	DefaultTimeout    = 10 * time.Second
+	DefaultMaxRetries = 2
+	DefaultBackoff    = 100 * time.Millisecond
+)
+
+// maxBackoff は Retry-After を含めて、再試行の前に待つ最大の時間
+const maxBackoff = 30 * time.Second
+
+// An Option configures Client on NewClient.
+type Option func(*Client)
+
+// WithHTTPClient sends the requests by hc instead of http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.hc = hc
+	}
+}
+
+// WithToken sends token as the bearer token in the Authorization header.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithTimeout limits the time of each attempt of a request. The default is DefaultTimeout,
+// and zero means no limit other than the context.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithRetry retries idempotent requests up to maxRetries times on network errors
+// and on 429, 502, 503 and 504 responses, waiting backoff doubled on each retry
+// or Retry-After of the response. The defaults are DefaultMaxRetries and DefaultBackoff.
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// A Client calls the TODO API at the base URL. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	hc         *http.Client
+	token      string
+	timeout    time.Duration
+	maxRetries int
+	backoff    time.Duration
+}
+
+// NewClient returns Client calling the API served at baseURL, such as "http://localhost:8080".
+func NewClient(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("client: invalid base URL: scheme must be http or https, given = %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		hc:         http.DefaultClient,
+		timeout:    DefaultTimeout,
+		maxRetries: DefaultMaxRetries,
+		backoff:    DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// An Error is returned for a response with an error status. It wraps *model.ErrNotFound
+// for 404 and *model.ErrInvalidArgument for 400, so that errors.As can check them like
+// the errors of the service.
+type Error struct {
+	StatusCode int
+	Message    string // レスポンスの本文
+	RequestID  string // サーバーのログを探すためのリクエストID（X-Request-ID ヘッダー）
+	err        error
+}
+
+// Error implements error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("client: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Unwrap returns the model error corresponding to the status.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// do は method のリクエストを送り、成功したレスポンスの本文を out に読み取る。
+// notFound は 404 のときに返す見つからなかったリソース
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}, notFound *model.ErrNotFound) error {
+	u := *c.baseURL
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawQuery = query.Encode()
+
+	// 再試行のたびに本文を読み直せるよう、先にエンコードしておく
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("client: failed to encode request: %w", err)
+		}
+	}
+
+	// POST は重複して作成しないよう再試行しない
+	retries := c.maxRetries
+	if method == http.MethodPost {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		retryable, retryAfter, err := c.attempt(ctx, method, u.String(), body, out, notFound)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= retries || ctx.Err() != nil {
+			return err
+		}
+
+		wait := c.backoff << attempt
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		if wait > maxBackoff {
+			wait = maxBackoff
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// attempt はリクエストを1回送る。失敗した場合は、再試行できるかと Retry-After で指定された待つ時間も返す
+func (c *Client) attempt(ctx context.Context, method, u string, body []byte, out interface{}, notFound *model.ErrNotFound) (bool, time.Duration, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return false, 0, fmt.Errorf("client: failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		// 接続の失敗やタイムアウトは、次の試行で成功するかもしれない
+		return true, 0, fmt.Errorf("client: failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// 本文は http.Error で書かれたテキストなので、そのままメッセージにする
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		e := &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(b)), RequestID: resp.Header.Get("X-Request-ID")}
+		// 本文の末尾に付いたリクエストIDは RequestID に持つので、メッセージからは除く
+		if e.RequestID != "" {
+			e.Message = strings.TrimSuffix(e.Message, " (request_id: "+e.RequestID+")")
+		}
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			if notFound != nil {
+				e.err = notFound
+			}
+		case http.StatusBadRequest:
+			e.err = &model.ErrInvalidArgument{Field: "request", Reason: strings.TrimPrefix(e.Message, "Bad Request: ")}
+		}
+		return isRetryableStatus(resp.StatusCode), parseRetryAfter(resp.Header.Get("Retry-After")), e
+	}
+
+	if out == nil {
+		return false, 0, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, 0, fmt.Errorf("client: failed to decode response: %w", err)
+	}
+	return false, 0, nil
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter は秒数の Retry-After を返す。日時の形式や不正な値は 0 にする
+func parseRetryAfter(v string) time.Duration {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,220 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/client"
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// newServer は空の DB を使う router.NewRouter のサーバーを返す
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "client_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	srv := httptest.NewServer(router.NewRouter(todoDB))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newClient(t *testing.T, url string, opts ...client.Option) *client.Client {
+	t.Helper()
+
+	c, err := client.NewClient(url, opts...)
+	if err != nil {
+		t.Fatal("failed to create client, err =", err)
+	}
+	return c
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	c := newClient(t, newServer(t).URL)
+	ctx := context.Background()
+
+	created, err := c.CreateTODO(ctx, &model.CreateTODORequest{Subject: "subject", Description: "description", Tags: []string{"a"}})
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	if created.ID == 0 || created.Subject != "subject" || len(created.Tags) != 1 {
+		t.Errorf("unexpected todo, given = %+v", created)
+	}
+
+	completed := true
+	updated, err := c.UpdateTODO(ctx, &model.UpdateTODORequest{ID: created.ID, Subject: "updated", Description: "description", Completed: &completed})
+	if err != nil {
+		t.Fatal("failed to update todo, err =", err)
+	}
+	if updated.Subject != "updated" || updated.CompletedAt == nil || len(updated.Tags) != 1 {
+		t.Errorf("unexpected todo, given = %+v", updated)
+	}
+
+	// PATCH で省略した件名と説明は変更されない
+	completed = false
+	patched, err := c.PatchTODO(ctx, &model.PatchTODORequest{ID: created.ID, Completed: &completed})
+	if err != nil {
+		t.Fatal("failed to patch todo, err =", err)
+	}
+	if patched.Subject != "updated" || patched.Description != "description" || patched.CompletedAt != nil {
+		t.Errorf("unexpected todo, given = %+v", patched)
+	}
+
+	todos, err := c.ReadTODO(ctx, &model.ReadTODORequest{Tags: []string{"a"}})
+	if err != nil {
+		t.Fatal("failed to read todos, err =", err)
+	}
+	if len(todos) != 1 || todos[0].ID != created.ID {
+		t.Errorf("unexpected todos, given = %+v", todos)
+	}
+
+	read, err := c.ReadTODOByID(ctx, created.ID)
+	if err != nil {
+		t.Fatal("failed to read todo, err =", err)
+	}
+	if read.ID != created.ID || read.Subject != "updated" {
+		t.Errorf("unexpected todo, given = %+v", read)
+	}
+
+	if err := c.DeleteTODO(ctx, []int64{created.ID}); err != nil {
+		t.Fatal("failed to delete todo, err =", err)
+	}
+
+	// サーバーのエラーは model のエラーとして確認できる
+	var notFound *model.ErrNotFound
+	if err := c.DeleteTODO(ctx, []int64{created.ID}); !errors.As(err, &notFound) || notFound.ID != created.ID {
+		t.Errorf("unexpected error, given = %v", err)
+	}
+	if _, err := c.ReadTODOByID(ctx, created.ID); !errors.As(err, &notFound) || notFound.ID != created.ID {
+		t.Errorf("unexpected error, given = %v", err)
+	}
+	if _, err := c.UpdateTODO(ctx, &model.UpdateTODORequest{ID: created.ID, Subject: "subject"}); !errors.As(err, &notFound) {
+		t.Errorf("unexpected error, given = %v", err)
+	}
+	if _, err := c.PatchTODO(ctx, &model.PatchTODORequest{ID: created.ID}); !errors.As(err, &notFound) || notFound.ID != created.ID {
+		t.Errorf("unexpected error, given = %v", err)
+	}
+	var invalid *model.ErrInvalidArgument
+	if _, err := c.CreateTODO(ctx, &model.CreateTODORequest{}); !errors.As(err, &invalid) {
+		t.Errorf("unexpected error, given = %v", err)
+	}
+	var statusErr *client.Error
+	if _, err := c.ReadTODO(ctx, &model.ReadTODORequest{TagMatch: "none"}); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("unexpected error, given = %v", err)
+	}
+	if statusErr.RequestID == "" || strings.Contains(statusErr.Message, statusErr.RequestID) {
+		t.Errorf("unexpected request ID, given = %+v", statusErr)
+	}
+}
+
+func TestTODOIterator(t *testing.T) {
+	t.Parallel()
+
+	c := newClient(t, newServer(t).URL)
+	ctx := context.Background()
+
+	for _, n := range []int{0, 3, 4} {
+		// n 件になるまで作成する
+		for i := 0; ; i++ {
+			todos, err := c.ReadTODO(ctx, &model.ReadTODORequest{Size: 100})
+			if err != nil {
+				t.Fatal("failed to read todos, err =", err)
+			}
+			if len(todos) >= n {
+				break
+			}
+			if _, err := c.CreateTODO(ctx, &model.CreateTODORequest{Subject: "subject" + strconv.Itoa(i)}); err != nil {
+				t.Fatal("failed to create todo, err =", err)
+			}
+		}
+
+		seen := map[int64]bool{}
+		it := c.IterateTODO(&model.ReadTODORequest{Size: 2})
+		for it.Next(ctx) {
+			seen[it.TODO().ID] = true
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal("failed to iterate todos, err =", err)
+		}
+		if len(seen) != n {
+			t.Errorf("%d todos must be iterated, given = %d", n, len(seen))
+		}
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer(t)
+
+	// 最初の2回は 503 を返す
+	var calls int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		http.Redirect(w, r, srv.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	t.Cleanup(flaky.Close)
+
+	ctx := context.Background()
+
+	c := newClient(t, flaky.URL, client.WithRetry(2, time.Millisecond))
+	if _, err := c.ReadTODO(ctx, &model.ReadTODORequest{}); err != nil {
+		t.Errorf("read must succeed by retries, err = %v", err)
+	}
+
+	// POST は再試行しない
+	atomic.StoreInt32(&calls, 0)
+	var statusErr *client.Error
+	if _, err := c.CreateTODO(ctx, &model.CreateTODORequest{Subject: "subject"}); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("unexpected error, given = %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("create must not be retried, given = %d calls", n)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	c = newClient(t, flaky.URL, client.WithRetry(1, time.Millisecond))
+	if _, err := c.ReadTODO(ctx, &model.ReadTODORequest{}); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("unexpected error, given = %v", err)
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	t.Parallel()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(slow.Close)
+
+	c := newClient(t, slow.URL, client.WithTimeout(10*time.Millisecond), client.WithRetry(1, time.Millisecond))
+	start := time.Now()
+	if _, err := c.ReadTODO(context.Background(), &model.ReadTODORequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error, given = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("request must time out, elapsed = %s", elapsed)
+	}
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// defaultPageSize は size を省略した場合にサーバーが返すTODOの数
+const defaultPageSize = 10
+
+// CreateTODO creates a TODO by req and returns the created TODO.
+// It is not retried so that the TODO is not created twice.
+func (c *Client) CreateTODO(ctx context.Context, req *model.CreateTODORequest) (*model.TODO, error) {
+	var resp model.CreateTODOResponse
+	if err := c.do(ctx, http.MethodPost, "/todos", nil, req, &resp, nil); err != nil {
+		return nil, err
+	}
+	return &resp.TODO, nil
+}
+
+// ReadTODO reads a page of TODOs after req.PrevID in the list order.
+// Zero req.Size reads the default number of TODOs of the server.
+func (c *Client) ReadTODO(ctx context.Context, req *model.ReadTODORequest) ([]*model.TODO, error) {
+	query := url.Values{}
+	if req.PrevID != 0 {
+		query.Set("prev_id", strconv.FormatInt(req.PrevID, 10))
+	}
+	if req.Size != 0 {
+		query.Set("size", strconv.FormatInt(req.Size, 10))
+	}
+	for _, tag := range req.Tags {
+		query.Add("tag", tag)
+	}
+	if req.TagMatch != "" {
+		query.Set("tag_match", req.TagMatch)
+	}
+	for _, id := range req.IDs {
+		query.Add("id", strconv.FormatInt(id, 10))
+	}
+
+	var resp model.ReadTODOResponse
+	if err := c.do(ctx, http.MethodGet, "/todos", query, nil, &resp, nil); err != nil {
+		return nil, err
+	}
+	return resp.TODOs, nil
+}
+
+// ReadTODOByID reads the TODO of id. It returns *model.ErrNotFound if the TODO does not exist.
+func (c *Client) ReadTODOByID(ctx context.Context, id int64) (*model.TODO, error) {
+	todos, err := c.ReadTODO(ctx, &model.ReadTODORequest{IDs: []int64{id}, Size: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(todos) == 0 {
+		return nil, &model.ErrNotFound{Resource: "TODO", ID: id}
+	}
+	return todos[0], nil
+}
+
+// UpdateTODO updates the TODO of req.ID and returns the updated TODO.
+func (c *Client) UpdateTODO(ctx context.Context, req *model.UpdateTODORequest) (*model.TODO, error) {
+	var resp model.UpdateTODOResponse
+	if err := c.do(ctx, http.MethodPut, "/todos", nil, req, &resp, &model.ErrNotFound{Resource: "TODO", ID: req.ID}); err != nil {
+		return nil, err
+	}
+	return &resp.TODO, nil
+}
+
+// PatchTODO updates only the given fields of the TODO of req.ID and returns the updated TODO.
+// Unlike UpdateTODO, the omitted subject and description are also kept.
+func (c *Client) PatchTODO(ctx context.Context, req *model.PatchTODORequest) (*model.TODO, error) {
+	var resp model.PatchTODOResponse
+	if err := c.do(ctx, http.MethodPatch, "/todos", nil, req, &resp, &model.ErrNotFound{Resource: "TODO", ID: req.ID}); err != nil {
+		return nil, err
+	}
+	return &resp.TODO, nil
+}
+
+// DeleteTODO deletes the TODOs of ids with their subtasks. If any of them does not
+// exist, nothing is deleted and the error wraps *model.ErrNotFound.
+// A retry after the deletion succeeded but its response was lost also results in *model.ErrNotFound.
+func (c *Client) DeleteTODO(ctx context.Context, ids []int64) error {
+	// サーバーはどのIDが見つからなかったかを返さないので、1件の場合だけIDを設定する
+	notFound := &model.ErrNotFound{Resource: "TODO"}
+	if len(ids) == 1 {
+		notFound.ID = ids[0]
+	}
+	return c.do(ctx, http.MethodDelete, "/todos", nil, &model.DeleteTODORequest{IDs: ids}, &model.DeleteTODOResponse{}, notFound)
+}
+
+// A TODOIterator iterates over all TODOs reading them page by page, like bufio.Scanner:
+//
+//	it := c.IterateTODO(&model.ReadTODORequest{Size: 100})
+//	for it.Next(ctx) {
+//		todo := it.TODO()
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type TODOIterator struct {
+	c    *Client
+	req  model.ReadTODORequest
+	page []*model.TODO
+	todo *model.TODO
+	done bool
+	err  error
+}
+
+// IterateTODO returns TODOIterator starting after req.PrevID with pages of req.Size.
+func (c *Client) IterateTODO(req *model.ReadTODORequest) *TODOIterator {
+	it := &TODOIterator{c: c, req: *req}
+	if it.req.Size == 0 {
+		it.req.Size = defaultPageSize
+	}
+	return it
+}
+
+// Next advances to the next TODO, reading the next page if needed. It returns false
+// at the end of TODOs or on an error.
+func (it *TODOIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if len(it.page) == 0 {
+		if it.done {
+			it.todo = nil
+			return false
+		}
+		page, err := it.c.ReadTODO(ctx, &it.req)
+		if err != nil {
+			it.todo, it.err = nil, err
+			return false
+		}
+		// 1ページに満たない場合は、これが最後のページ
+		it.page, it.done = page, int64(len(page)) < it.req.Size
+		if len(page) == 0 {
+			it.todo = nil
+			return false
+		}
+		it.req.PrevID = page[len(page)-1].ID
+	}
+	it.todo, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// TODO returns the current TODO advanced by Next.
+func (it *TODOIterator) TODO() *model.TODO {
+	return it.todo
+}
+
+// Err returns the error stopped the iteration, if any.
+func (it *TODOIterator) Err() error {
+	return it.err
+}
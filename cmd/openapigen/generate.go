@@ -0,0 +1,520 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+// header は生成したファイルであることを示すコメント（linter などはこの形式で生成したファイルを判定する）
+const header = "// Code generated by openapigen. DO NOT EDIT.\n\n"
+
+// initialisms は Go の名前ですべて大文字にする単語
+var initialisms = map[string]string{
+	"api":   "API",
+	"html":  "HTML",
+	"http":  "HTTP",
+	"id":    "ID",
+	"ids":   "IDs",
+	"json":  "JSON",
+	"todo":  "TODO",
+	"todos": "TODOs",
+	"uri":   "URI",
+	"url":   "URL",
+	"xml":   "XML",
+}
+
+// goName は snake_case の名前を Go の名前にする。override が空でない場合はそれを使う
+func goName(name, override string) string {
+	if override != "" {
+		return override
+	}
+	var b strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		if word == "" {
+			continue
+		}
+		if initialism, ok := initialisms[word]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// comment は説明を1行のコメントにする
+func comment(description string) string {
+	return strings.Join(strings.Fields(description), " ")
+}
+
+// generator は Go のソースを組み立てる
+type generator struct {
+	doc       *document
+	pkg       string
+	qualifier string // 型を参照するときのパッケージ名（同じパッケージの場合は空）
+	imports   map[string]bool
+	buf       bytes.Buffer
+}
+
+func newGenerator(doc *document, pkg string) *generator {
+	return &generator{doc: doc, pkg: pkg, imports: map[string]bool{}}
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// source は import を付けて gofmt で整形したソースを返す
+func (g *generator) source() ([]byte, error) {
+	var src bytes.Buffer
+	src.WriteString(header)
+	fmt.Fprintf(&src, "package %s\n\n", g.pkg)
+	switch len(g.imports) {
+	case 0:
+	case 1:
+		for path := range g.imports {
+			fmt.Fprintf(&src, "import %q\n\n", path)
+		}
+	default:
+		paths := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			paths = append(paths, path)
+		}
+		// 標準ライブラリを先に並べる
+		sort.Slice(paths, func(i, j int) bool {
+			iStd, jStd := !strings.Contains(paths[i], "."), !strings.Contains(paths[j], ".")
+			if iStd != jStd {
+				return iStd
+			}
+			return paths[i] < paths[j]
+		})
+		src.WriteString("import (\n")
+		std := true
+		for _, path := range paths {
+			if std && strings.Contains(path, ".") {
+				std = false
+				src.WriteString("\n")
+			}
+			fmt.Fprintf(&src, "%q\n", path)
+		}
+		src.WriteString(")\n\n")
+	}
+	src.Write(g.buf.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated source: %w\n%s", err, src.Bytes())
+	}
+	return formatted, nil
+}
+
+// goType は s の Go の型を返す
+func (g *generator) goType(s *schema) (string, error) {
+	resolved, component, err := g.doc.resolve(s)
+	if err != nil {
+		return "", err
+	}
+	if component != "" && resolved.Type == "object" {
+		t := g.qualifier + goName(component, resolved.GoName)
+		if s.Nullable || resolved.Nullable {
+			t = "*" + t
+		}
+		return t, nil
+	}
+
+	var t string
+	switch resolved.Type {
+	case "string":
+		t = "string"
+		if resolved.Format == "date-time" {
+			g.imports["time"] = true
+			t = "time.Time"
+		}
+	case "integer":
+		t = "int64"
+		if resolved.Format == "int32" {
+			t = "int32"
+		}
+	case "number":
+		t = "float64"
+		if resolved.Format == "float" {
+			t = "float32"
+		}
+	case "boolean":
+		t = "bool"
+	case "array":
+		if resolved.Items == nil {
+			return "", fmt.Errorf("items of array is required")
+		}
+		item, err := g.goType(resolved.Items)
+		if err != nil {
+			return "", err
+		}
+		// 構造体のスライスはポインタのスライスにする
+		if items, _, err := g.doc.resolve(resolved.Items); err == nil && items.Type == "object" && !strings.HasPrefix(item, "*") {
+			item = "*" + item
+		}
+		return "[]" + item, nil
+	case "object":
+		return "", fmt.Errorf("inline object is not supported, define it in components")
+	default:
+		return "", fmt.Errorf("unsupported type %q", resolved.Type)
+	}
+	if s.Nullable || resolved.Nullable {
+		t = "*" + t
+	}
+	return t, nil
+}
+
+// writeStruct は s のプロパティをフィールドとする構造体の型を書き込む
+func (g *generator) writeStruct(name, doc string, s *schema) error {
+	if s.Type != "object" {
+		return fmt.Errorf("%s: schema must be an object", name)
+	}
+	g.printf("// %s\n", doc)
+	if len(s.Properties) == 0 {
+		g.printf("type %s struct{}\n\n", name)
+		return nil
+	}
+	g.printf("type %s struct {\n", name)
+	if err := g.writeFields(name, s.Properties); err != nil {
+		return err
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// writeFields はプロパティを JSON のタグを付けたフィールドとして書き込む
+func (g *generator) writeFields(name string, props properties) error {
+	for _, prop := range props {
+		t, err := g.goType(prop.Schema)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", name, prop.Name, err)
+		}
+		jsonTag := prop.Name
+		if prop.Schema.OmitEmpty {
+			jsonTag += ",omitempty"
+		}
+		g.printf("%s %s `json:\"%s\"`", goName(prop.Name, prop.Schema.GoName), t, jsonTag)
+		if c := comment(prop.Schema.Description); c != "" {
+			g.printf(" // %s", c)
+		}
+		g.printf("\n")
+	}
+	return nil
+}
+
+// queryParameters は操作のクエリパラメーターを返す。対応していないパラメーターはエラーにする
+func queryParameters(op *operation) ([]*parameter, error) {
+	var params []*parameter
+	for _, param := range op.Parameters {
+		if param.In != "query" {
+			return nil, fmt.Errorf("%s %s: %s parameter %q is not supported", op.Method, op.Path, param.In, param.Name)
+		}
+		if param.Schema == nil {
+			return nil, fmt.Errorf("%s %s: schema of parameter %q is required", op.Method, op.Path, param.Name)
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+// generateTypes は tag が付いた操作のリクエストとレスポンス、それらが参照するスキーマの型を生成する
+func generateTypes(doc *document, tag, pkg string) ([]byte, error) {
+	ops, err := doc.operationsOf(tag)
+	if err != nil {
+		return nil, err
+	}
+	g := newGenerator(doc, pkg)
+
+	// 参照しているコンポーネントをドキュメントの順序で先に定義する
+	refs := map[string]bool{}
+	var collect func(s *schema) error
+	collect = func(s *schema) error {
+		if s == nil {
+			return nil
+		}
+		resolved, component, err := doc.resolve(s)
+		if err != nil {
+			return err
+		}
+		if component != "" {
+			if refs[component] {
+				return nil
+			}
+			refs[component] = true
+		}
+		for _, prop := range resolved.Properties {
+			if err := collect(prop.Schema); err != nil {
+				return err
+			}
+		}
+		return collect(resolved.Items)
+	}
+	for _, op := range ops {
+		if err := collect(op.requestSchema()); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.Method, op.Path, err)
+		}
+		if err := collect(op.responseSchema()); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.Method, op.Path, err)
+		}
+	}
+	for _, prop := range doc.Components.Schemas {
+		if !refs[prop.Name] || prop.Schema.Type != "object" {
+			continue
+		}
+		name := goName(prop.Name, prop.Schema.GoName)
+		desc := fmt.Sprintf("%s is the %s schema.", name, prop.Name)
+		if c := comment(prop.Schema.Description); c != "" {
+			desc = c
+		}
+		if err := g.writeStruct(name, desc, prop.Schema); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, op := range ops {
+		name := op.name()
+
+		// リクエストはクエリパラメーターと本文のプロパティをまとめた構造体にする
+		params, err := queryParameters(op)
+		if err != nil {
+			return nil, err
+		}
+		body := op.requestSchema()
+		if body != nil {
+			if body.Ref != "" || body.Type != "object" {
+				return nil, fmt.Errorf("%s %s: request body must be an inline object", op.Method, op.Path)
+			}
+		}
+		g.printf("// %sRequest is the request of %s %s: %s.\n", name, op.Method, op.Path, op.Summary)
+		if len(params) == 0 && (body == nil || len(body.Properties) == 0) {
+			g.printf("type %sRequest struct{}\n\n", name)
+		} else {
+			g.printf("type %sRequest struct {\n", name)
+			for _, param := range params {
+				t, err := g.goType(param.Schema)
+				if err != nil {
+					return nil, fmt.Errorf("%s %s: parameter %q: %w", op.Method, op.Path, param.Name, err)
+				}
+				g.printf("%s %s `query:\"%s\"`", goName(param.Name, param.GoName), t, param.Name)
+				if c := comment(param.Description); c != "" {
+					g.printf(" // %s", c)
+				}
+				g.printf("\n")
+			}
+			if body != nil {
+				if err := g.writeFields(name+"Request", body.Properties); err != nil {
+					return nil, err
+				}
+			}
+			g.printf("}\n\n")
+		}
+
+		resp := op.responseSchema()
+		if resp == nil || resp.Ref != "" {
+			return nil, fmt.Errorf("%s %s: 200 response must be an inline object of application/json", op.Method, op.Path)
+		}
+		if err := g.writeStruct(name+"Response", fmt.Sprintf("%sResponse is the response of %s %s.", name, op.Method, op.Path), resp); err != nil {
+			return nil, err
+		}
+	}
+	return g.source()
+}
+
+// generateServer は tag が付いた操作を処理するサーバーのインターフェースと、
+// それに HTTP のリクエストを渡す関数を生成する。生成したコードは、出力先のパッケージにある
+// negotiate、decodeRequest と writeServiceError を使う
+func generateServer(doc *document, tag, pkg, modelPath, server string) ([]byte, error) {
+	ops, err := doc.operationsOf(tag)
+	if err != nil {
+		return nil, err
+	}
+	// メソッドだけで操作を選ぶので、すべての操作が同じパスにある必要がある
+	for _, op := range ops[1:] {
+		if op.Path != ops[0].Path {
+			return nil, fmt.Errorf("operations tagged %q must have the same path, given = %s, %s", tag, ops[0].Path, op.Path)
+		}
+	}
+
+	g := newGenerator(doc, pkg)
+	g.qualifier = modelPackage(modelPath) + "."
+	g.imports["context"] = true
+	g.imports["net/http"] = true
+	g.imports[modelPath] = true
+
+	g.printf("// %s is the server of the operations tagged %s in the OpenAPI document.\n", server, tag)
+	g.printf("// Errors returned by the methods are written as the responses by writeServiceError.\n")
+	g.printf("type %s interface {\n", server)
+	for _, op := range ops {
+		name := op.name()
+		g.printf("// %s handles %s %s: %s.\n", name, op.Method, op.Path, op.Summary)
+		g.printf("%s(ctx context.Context, req *%s%sRequest) (*%s%sResponse, error)\n", name, g.qualifier, name, g.qualifier, name)
+	}
+	g.printf("}\n\n")
+
+	g.printf("// serve%s calls the method of s for the operation of r.Method, decoding the request\n", server)
+	g.printf("// and encoding the response in the media types negotiated by the headers.\n")
+	g.printf("func serve%s(s %s, w http.ResponseWriter, r *http.Request) {\n", server, server)
+	g.printf("rw, ok := negotiate(w, r)\nif !ok {\nreturn\n}\n\n")
+	g.printf("switch r.Method {\n")
+	for _, op := range ops {
+		name := op.name()
+		params, err := queryParameters(op)
+		if err != nil {
+			return nil, err
+		}
+		g.printf("case http.Method%s:\n", strings.ToUpper(op.Method[:1])+strings.ToLower(op.Method[1:]))
+		g.printf("var req %s%sRequest\n", g.qualifier, name)
+		if len(params) != 0 {
+			g.printf("if err := bind%sRequest(r, &req); err != nil {\nwriteServiceError(w, err)\nreturn\n}\n", name)
+		}
+		if op.requestSchema() != nil {
+			g.printf("if !decodeRequest(w, r, &req) {\nreturn\n}\n")
+		}
+		g.printf("resp, err := s.%s(r.Context(), &req)\n", name)
+		g.printf("if err != nil {\nwriteServiceError(w, err)\nreturn\n}\n")
+		g.printf("rw.write(http.StatusOK, resp)\n")
+	}
+	g.printf("default:\nhttp.Error(w, \"Method Not Allowed\", http.StatusMethodNotAllowed)\n}\n}\n\n")
+
+	for _, op := range ops {
+		params, err := queryParameters(op)
+		if err != nil {
+			return nil, err
+		}
+		if len(params) != 0 {
+			if err := g.writeBind(op, params); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return g.source()
+}
+
+// modelPackage は import パスのパッケージ名を返す
+func modelPackage(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// writeBind はクエリパラメーターをリクエストに読み取る関数を書き込む。
+// 省略されたパラメーターにはドキュメントの既定値を設定する
+func (g *generator) writeBind(op *operation, params []*parameter) error {
+	name := op.name()
+	g.printf("// bind%sRequest reads the query parameters of r into req.\n", name)
+	g.printf("func bind%sRequest(r *http.Request, req *%s%sRequest) error {\n", name, g.qualifier, name)
+	g.printf("query := r.URL.Query()\n")
+	for _, param := range params {
+		field := "req." + goName(param.Name, param.GoName)
+		s := param.Schema
+		if s.Ref != "" || s.Nullable {
+			return fmt.Errorf("%s %s: parameter %q must be an inline schema that is not nullable", op.Method, op.Path, param.Name)
+		}
+
+		g.printf("\n")
+		if param.Required {
+			g.printf("if _, ok := query[%q]; !ok {\n", param.Name)
+			g.writeInvalid(param, "is required")
+			g.printf("}\n")
+		}
+		if s.Type == "array" {
+			if s.Items == nil || s.Items.Ref != "" || (s.Items.Type != "string" && s.Items.Type != "integer") {
+				return fmt.Errorf("%s %s: parameter %q must be an array of strings or integers", op.Method, op.Path, param.Name)
+			}
+			if s.Items.Type == "string" {
+				g.printf("%s = query[%q]\n", field, param.Name)
+				continue
+			}
+			// 整数の配列は、繰り返し指定されたパラメーターをそれぞれ読み取る
+			bits, conv := 64, "n"
+			if s.Items.Format == "int32" {
+				bits, conv = 32, "int32(n)"
+			}
+			g.imports["strconv"] = true
+			g.printf("for _, v := range query[%q] {\n", param.Name)
+			g.printf("n, err := strconv.ParseInt(v, 10, %d)\nif err != nil {\n", bits)
+			g.writeInvalid(param, "must be integers")
+			g.printf("}\n%s = append(%s, %s)\n}\n", field, field, conv)
+			continue
+		}
+
+		switch {
+		case s.Type == "string" && s.Default == nil:
+			g.printf("%s = query.Get(%q)\n", field, param.Name)
+		case s.Type == "string":
+			g.printf("%s = %q\n", field, fmt.Sprint(s.Default))
+			g.printf("if v := query.Get(%q); v != \"\" {\n%s = v\n}\n", param.Name, field)
+		default:
+			if s.Default != nil {
+				g.printf("%s = %v\n", field, s.Default)
+			}
+			if err := g.writeParse(op, param, field); err != nil {
+				return err
+			}
+		}
+
+		if len(s.Enum) != 0 {
+			if s.Type != "string" {
+				return fmt.Errorf("%s %s: enum of parameter %q must be strings", op.Method, op.Path, param.Name)
+			}
+			cases := make([]string, 0, len(s.Enum)+1)
+			if s.Default == nil && !param.Required {
+				cases = append(cases, `""`)
+			}
+			for _, v := range s.Enum {
+				cases = append(cases, fmt.Sprintf("%q", v))
+			}
+			reason := "must be " + s.Enum[0]
+			if len(s.Enum) > 1 {
+				reason = "must be one of " + strings.Join(s.Enum, ", ")
+			}
+			g.printf("switch %s {\ncase %s:\ndefault:\n", field, strings.Join(cases, ", "))
+			g.writeInvalid(param, reason)
+			g.printf("}\n")
+		}
+	}
+	g.printf("return nil\n}\n\n")
+	return nil
+}
+
+// writeParse は文字列以外のクエリパラメーターを読み取る処理を書き込む
+func (g *generator) writeParse(op *operation, param *parameter, field string) error {
+	g.printf("if v := query.Get(%q); v != \"\" {\n", param.Name)
+	switch param.Schema.Type {
+	case "integer":
+		bits := 64
+		if param.Schema.Format == "int32" {
+			bits = 32
+		}
+		g.imports["strconv"] = true
+		g.printf("n, err := strconv.ParseInt(v, 10, %d)\nif err != nil {\n", bits)
+		g.writeInvalid(param, "must be an integer")
+		if bits == 32 {
+			g.printf("}\n%s = int32(n)\n", field)
+		} else {
+			g.printf("}\n%s = n\n", field)
+		}
+	case "number":
+		g.imports["strconv"] = true
+		g.printf("n, err := strconv.ParseFloat(v, 64)\nif err != nil {\n")
+		g.writeInvalid(param, "must be a number")
+		g.printf("}\n%s = n\n", field)
+	case "boolean":
+		g.imports["strconv"] = true
+		g.printf("b, err := strconv.ParseBool(v)\nif err != nil {\n")
+		g.writeInvalid(param, "must be a boolean")
+		g.printf("}\n%s = b\n", field)
+	default:
+		return fmt.Errorf("%s %s: parameter %q has unsupported type %q", op.Method, op.Path, param.Name, param.Schema.Type)
+	}
+	g.printf("}\n")
+	return nil
+}
+
+// writeInvalid はパラメーターが不正な場合に返すエラーを書き込む
+func (g *generator) writeInvalid(param *parameter, reason string) {
+	g.printf("return &%sErrInvalidArgument{Field: %q, Reason: %q}\n", g.qualifier, param.Name, reason)
+}
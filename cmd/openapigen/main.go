@@ -0,0 +1,83 @@
+// Command openapigen generates Go code from the operations of an OpenAPI 3.0 document
+// so that the document, the models and the handlers do not drift apart.
+//
+// Usage:
+//
+//	openapigen -spec openapi.yaml -tag todos -kind types -package model -o todo.gen.go
+//	openapigen -spec openapi.yaml -tag todos -kind server -package handler -model path/to/model -server TODOServer -o todo.gen.go
+//
+// The types kind generates the request and response structs of the operations with the
+// tag, named after their operationId, and the component schemas they refer to.
+// The server kind generates the interface of the operations and the function serving
+// http.Handler by it, which uses negotiate, decodeRequest and writeServiceError of the
+// output package.
+//
+// Besides the standard keywords, properties accept x-go-name to override the field name
+// and x-omitempty to add omitempty to the tags. Nullable properties become pointers.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "openapigen:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("openapigen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	specPath := fs.String("spec", "", "OpenAPI document in YAML")
+	tag := fs.String("tag", "", "tag of the operations to generate")
+	kind := fs.String("kind", "types", "what to generate: types or server")
+	pkg := fs.String("package", "", "package name of the generated file")
+	modelPath := fs.String("model", "", "import path of the package of the types (server)")
+	server := fs.String("server", "", "name of the server interface (server)")
+	out := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *specPath == "" || *tag == "" || *pkg == "" {
+		return errors.New("-spec, -tag and -package are required")
+	}
+
+	data, err := os.ReadFile(*specPath)
+	if err != nil {
+		return err
+	}
+	doc, err := loadDocument(data)
+	if err != nil {
+		return err
+	}
+
+	var src []byte
+	switch *kind {
+	case "types":
+		src, err = generateTypes(doc, *tag, *pkg)
+	case "server":
+		if *modelPath == "" || *server == "" {
+			return errors.New("-model and -server are required for server")
+		}
+		src, err = generateServer(doc, *tag, *pkg, *modelPath, *server)
+	default:
+		return fmt.Errorf("invalid kind %q: must be types or server", *kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err := stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*out, src, 0o644)
+}
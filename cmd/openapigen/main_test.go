@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRun_UpToDate は生成済みのファイルがドキュメントと一致しているかを確認する
+func TestRun_UpToDate(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		args []string
+		file string
+	}{
+		"Types": {
+			args: []string{"-kind", "types", "-package", "model"},
+			file: "../../model/todo.gen.go",
+		},
+		"Server": {
+			args: []string{"-kind", "server", "-package", "handler", "-model", "github.com/TechBowl-japan/go-stations/model", "-server", "TODOServer"},
+			file: "../../handler/todo.gen.go",
+		},
+	}
+
+	for name, tc := range testcases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"-spec", "../../docs/openapi.yaml", "-tag", "todos"}, tc.args...)
+			if err := run(args, &stdout, &stderr); err != nil {
+				t.Fatalf("failed to generate, err = %v, stderr = %s", err, stderr.String())
+			}
+			want, err := os.ReadFile(tc.file)
+			if err != nil {
+				t.Fatal("failed to read generated file, err =", err)
+			}
+			if !bytes.Equal(stdout.Bytes(), want) {
+				t.Errorf("%s is out of date, run go generate", tc.file)
+			}
+		})
+	}
+}
+
+func TestRun_Generate(t *testing.T) {
+	t.Parallel()
+
+	const spec = `
+paths:
+  /items:
+    get:
+      operationId: readItem
+      tags: [items]
+      parameters:
+        - name: size
+          in: query
+          required: true
+          schema:
+            type: integer
+            format: int32
+        - name: order
+          in: query
+          schema:
+            type: string
+            enum: [asc, desc]
+            default: asc
+        - name: id
+          in: query
+          x-go-name: IDs
+          schema:
+            type: array
+            items:
+              type: integer
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  items:
+                    type: array
+                    items:
+                      $ref: '#/components/schemas/item'
+components:
+  schemas:
+    item:
+      type: object
+      properties:
+        item_url:
+          type: string
+          nullable: true
+          x-omitempty: true
+`
+	path := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(path, []byte(spec), 0o600); err != nil {
+		t.Fatal("failed to write document, err =", err)
+	}
+
+	testcases := map[string]struct {
+		args []string
+		want []string
+	}{
+		"Types": {
+			args: []string{"-kind", "types", "-package", "model"},
+			want: []string{
+				"type Item struct",
+				"ItemURL *string `json:\"item_url,omitempty\"`",
+				"type ReadItemRequest struct",
+				"Size int32 `query:\"size\"`",
+				"IDs []int64 `query:\"id\"`",
+				"Items []*Item `json:\"items\"`",
+			},
+		},
+		"Server": {
+			args: []string{"-kind", "server", "-package", "handler", "-model", "example.com/model", "-server", "ItemServer"},
+			want: []string{
+				"ReadItem(ctx context.Context, req *model.ReadItemRequest) (*model.ReadItemResponse, error)",
+				`if _, ok := query["size"]; !ok {`,
+				"req.Size = int32(n)",
+				`req.Order = "asc"`,
+				`case "asc", "desc":`,
+				`for _, v := range query["id"] {`,
+				"req.IDs = append(req.IDs, n)",
+			},
+		},
+	}
+
+	for name, tc := range testcases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			if err := run(append([]string{"-spec", path, "-tag", "items"}, tc.args...), &stdout, &stderr); err != nil {
+				t.Fatalf("failed to generate, err = %v, stderr = %s", err, stderr.String())
+			}
+			// gofmt で揃えられた空白を1つにしてから比べる
+			src := strings.Join(strings.Fields(stdout.String()), " ")
+			for _, want := range tc.want {
+				if !strings.Contains(src, want) {
+					t.Errorf("generated source must contain %q, given = %s", want, stdout.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRun_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]string{
+		"PathParameter": `
+paths:
+  /items/{id}:
+    get:
+      operationId: readItem
+      tags: [items]
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+`,
+		"InlineObject": `
+paths:
+  /items:
+    get:
+      operationId: readItem
+      tags: [items]
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  item:
+                    type: object
+`,
+		"NoOperationID": `
+paths:
+  /items:
+    get:
+      tags: [items]
+`,
+		"NoOperation": `
+paths:
+  /items:
+    get:
+      operationId: readItem
+`,
+	}
+
+	for name, spec := range testcases {
+		name, spec := name, spec
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "openapi.yaml")
+			if err := os.WriteFile(path, []byte(spec), 0o600); err != nil {
+				t.Fatal("failed to write document, err =", err)
+			}
+			var stdout, stderr bytes.Buffer
+			if err := run([]string{"-spec", path, "-tag", "items", "-package", "model"}, &stdout, &stderr); err == nil {
+				t.Errorf("unsupported document must be an error, given = %s", stdout.String())
+			}
+		})
+	}
+}
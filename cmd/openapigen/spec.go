@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// document は生成に使う部分だけを読み取った OpenAPI 3.0 のドキュメント
+type document struct {
+	Paths      paths `yaml:"paths"`
+	Components struct {
+		Schemas properties `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+// paths はドキュメントの順序を保ったパスの一覧
+type paths []*pathItem
+
+func (p *paths) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: paths must be a mapping", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		item := &pathItem{Path: node.Content[i].Value}
+		if err := node.Content[i+1].Decode(item); err != nil {
+			return err
+		}
+		*p = append(*p, item)
+	}
+	return nil
+}
+
+type pathItem struct {
+	Path       string                `yaml:"-"`
+	Operations map[string]*operation `yaml:",inline"`
+	order      []string              // ドキュメントでのメソッドの順序
+}
+
+// methods は操作として扱うキー
+var methods = map[string]string{
+	"get":    http.MethodGet,
+	"put":    http.MethodPut,
+	"post":   http.MethodPost,
+	"delete": http.MethodDelete,
+	"patch":  http.MethodPatch,
+}
+
+func (p *pathItem) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: path item must be a mapping", node.Line)
+	}
+	p.Operations = map[string]*operation{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		// parameters などの操作以外のキーは読み飛ばす
+		method, ok := methods[node.Content[i].Value]
+		if !ok {
+			continue
+		}
+		op := &operation{Method: method, Path: p.Path}
+		if err := node.Content[i+1].Decode(op); err != nil {
+			return err
+		}
+		p.Operations[method] = op
+		p.order = append(p.order, method)
+	}
+	return nil
+}
+
+// operations は操作をドキュメントの順序で返す
+func (p *pathItem) operations() []*operation {
+	ops := make([]*operation, 0, len(p.order))
+	for _, method := range p.order {
+		ops = append(ops, p.Operations[method])
+	}
+	return ops
+}
+
+type operation struct {
+	Method      string       `yaml:"-"`
+	Path        string       `yaml:"-"`
+	OperationID string       `yaml:"operationId"`
+	Summary     string       `yaml:"summary"`
+	Tags        []string     `yaml:"tags"`
+	Parameters  []*parameter `yaml:"parameters"`
+	RequestBody *struct {
+		Content map[string]*mediaType `yaml:"content"`
+	} `yaml:"requestBody"`
+	Responses map[string]*struct {
+		Content map[string]*mediaType `yaml:"content"`
+	} `yaml:"responses"`
+}
+
+// hasTag は操作に tag が付いているかを返す
+func (o *operation) hasTag(tag string) bool {
+	for _, t := range o.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// name は operationId から Go の名前を作る
+func (o *operation) name() string {
+	return strings.ToUpper(o.OperationID[:1]) + o.OperationID[1:]
+}
+
+// requestSchema は JSON の本文のスキーマを返す。本文がない場合は nil を返す
+func (o *operation) requestSchema() *schema {
+	if o.RequestBody == nil {
+		return nil
+	}
+	if mt := o.RequestBody.Content["application/json"]; mt != nil {
+		return mt.Schema
+	}
+	return nil
+}
+
+// responseSchema は 200 の JSON のスキーマを返す。定義されていない場合は nil を返す
+func (o *operation) responseSchema() *schema {
+	resp := o.Responses["200"]
+	if resp == nil {
+		return nil
+	}
+	if mt := resp.Content["application/json"]; mt != nil {
+		return mt.Schema
+	}
+	return nil
+}
+
+type mediaType struct {
+	Schema *schema `yaml:"schema"`
+}
+
+type parameter struct {
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Description string  `yaml:"description"`
+	Required    bool    `yaml:"required"`
+	Schema      *schema `yaml:"schema"`
+	GoName      string  `yaml:"x-go-name"`
+}
+
+type schema struct {
+	Ref         string      `yaml:"$ref"`
+	Type        string      `yaml:"type"`
+	Format      string      `yaml:"format"`
+	Description string      `yaml:"description"`
+	Nullable    bool        `yaml:"nullable"`
+	Items       *schema     `yaml:"items"`
+	Properties  properties  `yaml:"properties"`
+	Required    []string    `yaml:"required"`
+	Enum        []string    `yaml:"enum"`
+	Default     interface{} `yaml:"default"`
+	GoName    string `yaml:"x-go-name"`
+	OmitEmpty bool   `yaml:"x-omitempty"`
+}
+
+// properties はドキュメントの順序を保ったスキーマの一覧。フィールドはこの順序で並べる
+type properties []*property
+
+type property struct {
+	Name   string
+	Schema *schema
+}
+
+func (p *properties) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: properties must be a mapping", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		prop := &property{Name: node.Content[i].Value, Schema: &schema{}}
+		if err := node.Content[i+1].Decode(prop.Schema); err != nil {
+			return err
+		}
+		*p = append(*p, prop)
+	}
+	return nil
+}
+
+// lookup は name のスキーマを返す。見つからない場合は nil を返す
+func (p properties) lookup(name string) *schema {
+	for _, prop := range p {
+		if prop.Name == name {
+			return prop.Schema
+		}
+	}
+	return nil
+}
+
+const componentsPrefix = "#/components/schemas/"
+
+// resolve は $ref をたどったスキーマと、コンポーネントの場合はその名前を返す
+func (d *document) resolve(s *schema) (*schema, string, error) {
+	if s.Ref == "" {
+		return s, "", nil
+	}
+	if !strings.HasPrefix(s.Ref, componentsPrefix) {
+		return nil, "", fmt.Errorf("unsupported $ref %q", s.Ref)
+	}
+	name := strings.TrimPrefix(s.Ref, componentsPrefix)
+	resolved := d.Components.Schemas.lookup(name)
+	if resolved == nil {
+		return nil, "", fmt.Errorf("schema %q is not found", name)
+	}
+	return resolved, name, nil
+}
+
+// loadDocument は YAML の OpenAPI ドキュメントを読み取る
+func loadDocument(data []byte) (*document, error) {
+	var doc document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+	return &doc, nil
+}
+
+// operationsOf は tag が付いた操作をドキュメントの順序で返す
+func (d *document) operationsOf(tag string) ([]*operation, error) {
+	var ops []*operation
+	for _, item := range d.Paths {
+		for _, op := range item.operations() {
+			if !op.hasTag(tag) {
+				continue
+			}
+			if op.OperationID == "" {
+				return nil, fmt.Errorf("%s %s: operationId is required", op.Method, op.Path)
+			}
+			ops = append(ops, op)
+		}
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("no operation is tagged %q", tag)
+	}
+	return ops, nil
+}
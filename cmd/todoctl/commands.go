@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// stringsFlag は繰り返し指定できる文字列のフラグ
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+// timeFlag は RFC 3339 の日時のフラグ
+type timeFlag struct{ t *time.Time }
+
+func (f *timeFlag) String() string {
+	if f.t == nil {
+		return ""
+	}
+	return f.t.Format(time.RFC3339)
+}
+
+func (f *timeFlag) Set(v string) error {
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return fmt.Errorf("must be RFC 3339 like 2006-01-02T15:04:05+09:00")
+	}
+	f.t = &t
+	return nil
+}
+
+// newFlagSet はサブコマンドのフラグを作成する。output が nil でない場合は -o を追加する
+func newFlagSet(e *env, name, usage string, output *string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: todoctl %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	if output != nil {
+		fs.StringVar(output, "o", outputTable, "output format: table or json")
+	}
+	return fs
+}
+
+// parseArgs はフラグと引数が混在していても、すべてのフラグを読み取って残りの引数を返す
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return rest, nil
+		}
+		rest, args = append(rest, args[0]), args[1:]
+	}
+}
+
+func parseIDs(args []string) ([]int64, error) {
+	ids := make([]int64, len(args))
+	for i, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid TODO id %q", arg)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+func runList(ctx context.Context, e *env, args []string) error {
+	var (
+		output   string
+		tags     stringsFlag
+		tagMatch string
+		limit    int
+	)
+	fs := newFlagSet(e, "list", "[flags]", &output)
+	fs.Var(&tags, "tag", "show TODOs with the tag (repeatable)")
+	fs.StringVar(&tagMatch, "tag-match", model.TagMatchAny, "match any or all of the tags")
+	fs.IntVar(&limit, "limit", 0, "maximum number of TODOs to show (0 for all)")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		fs.Usage()
+		return fmt.Errorf("list takes no arguments")
+	}
+	w, err := newWriter(e.stdout, output)
+	if err != nil {
+		return err
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	var todos []*model.TODO
+	it := c.IterateTODO(&model.ReadTODORequest{Size: 100, Tags: tags, TagMatch: tagMatch})
+	for (limit == 0 || len(todos) < limit) && it.Next(ctx) {
+		todos = append(todos, it.TODO())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return w.writeTODOs(todos)
+}
+
+func runCreate(ctx context.Context, e *env, args []string) error {
+	var (
+		output      string
+		description string
+		tags        stringsFlag
+		parentID    int64
+		due         timeFlag
+	)
+	fs := newFlagSet(e, "create", "[flags] <subject>", &output)
+	fs.StringVar(&description, "description", "", "description in CommonMark")
+	fs.Var(&tags, "tag", "tag to add (repeatable)")
+	fs.Int64Var(&parentID, "parent", 0, "ID of the parent TODO")
+	fs.Var(&due, "due", "due date in RFC 3339")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 || rest[0] == "" {
+		fs.Usage()
+		return fmt.Errorf("create takes a subject")
+	}
+	w, err := newWriter(e.stdout, output)
+	if err != nil {
+		return err
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	todo, err := c.CreateTODO(ctx, &model.CreateTODORequest{
+		Subject:     rest[0],
+		Description: description,
+		Tags:        tags,
+		ParentID:    parentID,
+		DueAt:       due.t,
+	})
+	if err != nil {
+		return err
+	}
+	return w.writeTODOs([]*model.TODO{todo})
+}
+
+func runUpdate(ctx context.Context, e *env, args []string) error {
+	var (
+		output      string
+		subject     string
+		description string
+		tags        stringsFlag
+		clearTags   bool
+		due         timeFlag
+	)
+	fs := newFlagSet(e, "update", "[flags] <id>", &output)
+	fs.StringVar(&subject, "subject", "", "new subject")
+	fs.StringVar(&description, "description", "", "new description in CommonMark")
+	fs.Var(&tags, "tag", "replace the tags with the tag (repeatable)")
+	fs.BoolVar(&clearTags, "clear-tags", false, "remove all the tags")
+	fs.Var(&due, "due", "new due date in RFC 3339")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	ids, err := parseIDs(rest)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 {
+		fs.Usage()
+		return fmt.Errorf("update takes an id")
+	}
+	w, err := newWriter(e.stdout, output)
+	if err != nil {
+		return err
+	}
+
+	// 指定されなかった件名と説明は省略し、サーバーで今の値のままにする
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	req := &model.PatchTODORequest{ID: ids[0], DueAt: due.t}
+	if set["subject"] {
+		req.Subject = &subject
+	}
+	if set["description"] {
+		req.Description = &description
+	}
+	switch {
+	case clearTags:
+		req.Tags = []string{}
+	case len(tags) > 0:
+		req.Tags = tags
+	}
+
+	todo, err := c.PatchTODO(ctx, req)
+	if err != nil {
+		return err
+	}
+	return w.writeTODOs([]*model.TODO{todo})
+}
+
+func runComplete(ctx context.Context, e *env, args []string) error {
+	var output string
+	fs := newFlagSet(e, "complete", "[flags] <id>...", &output)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	ids, err := parseIDs(rest)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		fs.Usage()
+		return fmt.Errorf("complete takes ids")
+	}
+	w, err := newWriter(e.stdout, output)
+	if err != nil {
+		return err
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	completed := true
+	todos := make([]*model.TODO, 0, len(ids))
+	for _, id := range ids {
+		todo, err := c.PatchTODO(ctx, &model.PatchTODORequest{ID: id, Completed: &completed})
+		if err != nil {
+			return err
+		}
+		todos = append(todos, todo)
+	}
+	return w.writeTODOs(todos)
+}
+
+func runDelete(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "delete", "<id>...", nil)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	ids, err := parseIDs(rest)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		fs.Usage()
+		return fmt.Errorf("delete takes ids")
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	if err := c.DeleteTODO(ctx, ids); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "deleted %d TODO(s)\n", len(ids))
+	return nil
+}
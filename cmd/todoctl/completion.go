@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// 補完のスクリプト。コマンドやフラグを追加したときはここも更新する
+const bashCompletion = `# bash completion for todoctl. Load it by: source <(todoctl completion bash)
+_todoctl() {
+	local cur prev cmd i
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case "${COMP_WORDS[i]}" in
+		--config | --server | --token) ((i++)) ;;
+		-*) ;;
+		*) cmd="${COMP_WORDS[i]}"; break ;;
+		esac
+	done
+
+	case "$prev" in
+	--config) COMPREPLY=($(compgen -f -- "$cur")); return ;;
+	-o) COMPREPLY=($(compgen -W "table json" -- "$cur")); return ;;
+	--tag-match) COMPREPLY=($(compgen -W "any all" -- "$cur")); return ;;
+	esac
+
+	case "$cmd" in
+	"") COMPREPLY=($(compgen -W "list create update complete delete completion --config --server --token" -- "$cur")) ;;
+	list) COMPREPLY=($(compgen -W "-o --tag --tag-match --limit" -- "$cur")) ;;
+	create) COMPREPLY=($(compgen -W "-o --description --tag --parent --due" -- "$cur")) ;;
+	update) COMPREPLY=($(compgen -W "-o --subject --description --tag --clear-tags --due" -- "$cur")) ;;
+	complete) COMPREPLY=($(compgen -W "-o" -- "$cur")) ;;
+	completion) COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+	esac
+}
+complete -F _todoctl todoctl
+`
+
+const zshCompletion = `#compdef todoctl
+# zsh completion for todoctl. Load it by: source <(todoctl completion zsh)
+autoload -U +X bashcompinit && bashcompinit
+` + bashCompletion
+
+const fishCompletion = `# fish completion for todoctl. Load it by: todoctl completion fish | source
+set -l commands list create update complete delete completion
+complete -c todoctl -f
+complete -c todoctl -n "not __fish_seen_subcommand_from $commands" -l config -r -F -d "config file"
+complete -c todoctl -n "not __fish_seen_subcommand_from $commands" -l server -x -d "server URL"
+complete -c todoctl -n "not __fish_seen_subcommand_from $commands" -l token -x -d "bearer token"
+complete -c todoctl -n "not __fish_seen_subcommand_from $commands" -a list -d "List TODOs"
+complete -c todoctl -n "not __fish_seen_subcommand_from $commands" -a create -d "Create a TODO"
+complete -c todoctl -n "not __fish_seen_subcommand_from $commands" -a update -d "Update a TODO"
+complete -c todoctl -n "not __fish_seen_subcommand_from $commands" -a complete -d "Mark TODOs as completed"
+complete -c todoctl -n "not __fish_seen_subcommand_from $commands" -a delete -d "Delete TODOs"
+complete -c todoctl -n "not __fish_seen_subcommand_from $commands" -a completion -d "Print the shell completion script"
+complete -c todoctl -n "__fish_seen_subcommand_from list create update complete" -s o -x -a "table json" -d "output format"
+complete -c todoctl -n "__fish_seen_subcommand_from list create update" -l tag -x -d "tag"
+complete -c todoctl -n "__fish_seen_subcommand_from list" -l tag-match -x -a "any all" -d "match any or all of the tags"
+complete -c todoctl -n "__fish_seen_subcommand_from list" -l limit -x -d "maximum number of TODOs"
+complete -c todoctl -n "__fish_seen_subcommand_from create update" -l description -x -d "description"
+complete -c todoctl -n "__fish_seen_subcommand_from create update" -l due -x -d "due date in RFC 3339"
+complete -c todoctl -n "__fish_seen_subcommand_from create" -l parent -x -d "ID of the parent TODO"
+complete -c todoctl -n "__fish_seen_subcommand_from update" -l subject -x -d "new subject"
+complete -c todoctl -n "__fish_seen_subcommand_from update" -l clear-tags -d "remove all the tags"
+complete -c todoctl -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+`
+
+var completions = map[string]string{
+	"bash": bashCompletion,
+	"zsh":  zshCompletion,
+	"fish": fishCompletion,
+}
+
+func runCompletion(_ context.Context, e *env, args []string) error {
+	if len(args) != 1 || completions[args[0]] == "" {
+		fmt.Fprintln(e.stderr, "Usage: todoctl completion bash|zsh|fish")
+		return fmt.Errorf("completion takes bash, zsh or fish")
+	}
+	_, err := io.WriteString(e.stdout, completions[args[0]])
+	return err
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// defaultServer は設定がない場合に接続するサーバー
+const defaultServer = "http://localhost:8080"
+
+// config は設定ファイルの内容
+type config struct {
+	Server string `json:"server"`
+	Token  string `json:"token"`
+}
+
+// defaultConfigPath はユーザーの設定ディレクトリにある設定ファイルのパスを返す
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "todoctl", "config.json")
+}
+
+// loadConfig は設定ファイルを読み込む。path を省略した場合の既定のファイルはなくてもよい
+func loadConfig(path string) (*config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath()
+	}
+
+	cfg := &config{}
+	if path != "" {
+		b, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist) && !explicit:
+		case err != nil:
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		default:
+			if err := json.Unmarshal(b, cfg); err != nil {
+				return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+			}
+		}
+	}
+
+	if cfg.Server == "" {
+		cfg.Server = defaultServer
+	}
+	return cfg, nil
+}
+
+// override は空でない値で設定を上書きする
+func (c *config) override(server, token string) {
+	if server != "" {
+		c.Server = server
+	}
+	if token != "" {
+		c.Token = token
+	}
+}
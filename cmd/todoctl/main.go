@@ -0,0 +1,116 @@
+// Command todoctl manages TODOs on a running server.
+//
+// Usage:
+//
+//	todoctl [--config path] [--server url] [--token token] <command> [flags] [args]
+//
+// The commands are list, create, update, complete, delete and completion. The server
+// URL and the token are read from the flags, the environment variables TODOCTL_SERVER
+// and TODOCTL_TOKEN, and the config file in this order.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+
+	"github.com/TechBowl-japan/go-stations/client"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "todoctl:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+// command はサブコマンドの定義
+type command struct {
+	summary string
+	run     func(ctx context.Context, env *env, args []string) error
+}
+
+var commands = map[string]*command{
+	"list":       {summary: "List TODOs", run: runList},
+	"create":     {summary: "Create a TODO", run: runCreate},
+	"update":     {summary: "Update a TODO", run: runUpdate},
+	"complete":   {summary: "Mark TODOs as completed", run: runComplete},
+	"delete":     {summary: "Delete TODOs with their subtasks", run: runDelete},
+	"completion": {summary: "Print the shell completion script for bash, zsh or fish", run: runCompletion},
+}
+
+// env はサブコマンドが使う出力先とクライアント
+type env struct {
+	stdout io.Writer
+	stderr io.Writer
+
+	cfg    *config
+	client func() (*client.Client, error) // completion はサーバーを使わないので、必要になってから作る
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("todoctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "", "config file (default "+defaultConfigPath()+")")
+	server := fs.String("server", "", "server URL (default "+defaultServer+")")
+	token := fs.String("token", "", "bearer token sent to the server")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: todoctl [flags] <command> [command flags] [args]")
+		fmt.Fprintln(stderr, "\nCommands:")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(stderr, "  %-10s  %s\n", name, commands[name].summary)
+		}
+		fmt.Fprintln(stderr, "\nFlags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fs.Usage()
+		return fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	// フラグ、環境変数、設定ファイルの順に優先する
+	cfg.override(os.Getenv("TODOCTL_SERVER"), os.Getenv("TODOCTL_TOKEN"))
+	cfg.override(*server, *token)
+
+	e := &env{
+		stdout: stdout,
+		stderr: stderr,
+		cfg:    cfg,
+		client: func() (*client.Client, error) {
+			var opts []client.Option
+			if cfg.Token != "" {
+				opts = append(opts, client.WithToken(cfg.Token))
+			}
+			return client.NewClient(cfg.Server, opts...)
+		},
+	}
+	return cmd.run(ctx, e, fs.Args()[1:])
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestRun(t *testing.T) {
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "todoctl_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+	srv := httptest.NewServer(router.NewRouter(todoDB))
+	t.Cleanup(srv.Close)
+
+	// サーバーの URL は設定ファイルから読み込む
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"server": "`+srv.URL+`"}`), 0o600); err != nil {
+		t.Fatal("failed to write config, err =", err)
+	}
+
+	todoctl := func(args ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if err := run(context.Background(), append([]string{"--config", configPath}, args...), &stdout, &stderr); err != nil {
+			t.Fatalf("todoctl %s failed, err = %v, stderr = %s", strings.Join(args, " "), err, stderr.String())
+		}
+		return stdout.String()
+	}
+	decode := func(out string) []*model.TODO {
+		t.Helper()
+		var todos []*model.TODO
+		if err := json.Unmarshal([]byte(out), &todos); err != nil {
+			t.Fatalf("failed to decode output, err = %v, output = %s", err, out)
+		}
+		return todos
+	}
+
+	created := decode(todoctl("create", "-o", "json", "subject", "--tag", "a", "--description", "description"))
+	if len(created) != 1 || created[0].Subject != "subject" || len(created[0].Tags) != 1 {
+		t.Fatalf("unexpected todo, given = %+v", created)
+	}
+	id := strconv.FormatInt(created[0].ID, 10)
+
+	// 指定しなかった説明とタグは変更しない
+	updated := decode(todoctl("update", id, "--subject", "updated", "-o", "json"))
+	if updated[0].Subject != "updated" || updated[0].Description != "description" || len(updated[0].Tags) != 1 {
+		t.Errorf("unexpected todo, given = %+v", updated[0])
+	}
+
+	completed := decode(todoctl("complete", "-o", "json", id))
+	if completed[0].CompletedAt == nil || completed[0].Subject != "updated" {
+		t.Errorf("unexpected todo, given = %+v", completed[0])
+	}
+
+	table := todoctl("list", "--tag", "a")
+	if lines := strings.Split(strings.TrimSpace(table), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], id+" ") || !strings.Contains(lines[1], "updated") {
+		t.Errorf("unexpected table, given = %q", table)
+	}
+
+	todoctl("delete", id)
+	if todos := decode(todoctl("list", "-o", "json")); len(todos) != 0 {
+		t.Errorf("todos must be deleted, given = %+v", todos)
+	}
+
+	// サーバーのフラグは設定ファイルより優先する
+	var stdout, stderr bytes.Buffer
+	if err := run(context.Background(), []string{"--config", configPath, "--server", "http://127.0.0.1:1", "list"}, &stdout, &stderr); err == nil {
+		t.Error("the server of the flag must be used")
+	}
+	if err := run(context.Background(), []string{"--config", configPath, "update", id}, &stdout, &stderr); err == nil {
+		t.Error("deleted todo must not be found")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// 出力の形式
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// writer はTODOを指定された形式で出力する
+type writer struct {
+	w      io.Writer
+	format string
+}
+
+func newWriter(w io.Writer, format string) (*writer, error) {
+	switch format {
+	case outputTable, outputJSON:
+		return &writer{w: w, format: format}, nil
+	}
+	return nil, fmt.Errorf("invalid output format %q: must be table or json", format)
+}
+
+func (w *writer) writeTODOs(todos []*model.TODO) error {
+	if w.format == outputJSON {
+		// 件数によらず配列で出力し、jq などで扱いやすくする
+		if todos == nil {
+			todos = []*model.TODO{}
+		}
+		enc := json.NewEncoder(w.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(todos)
+	}
+
+	tw := tabwriter.NewWriter(w.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSUBJECT\tTAGS\tDUE\tDONE")
+	for _, todo := range todos {
+		due, done := "-", ""
+		if todo.DueAt != nil {
+			due = todo.DueAt.Local().Format(time.RFC3339)
+		}
+		if todo.CompletedAt != nil {
+			done = "✓"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", strconv.FormatInt(todo.ID, 10), todo.Subject, strings.Join(todo.Tags, ","), due, done)
+	}
+	return tw.Flush()
+}
@@ -1,18 +1,44 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	_ "embed"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/mattn/go-sqlite3"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
 )
 
 //go:embed schema.sql
 var schema string
 
-// NewDB returns go-sqlite3 driver based *sql.DB.
+// migrations は schema.sql 作成後に適用するスキーマ変更。
+// ファイル名の先頭の番号がバージョンとなり、PRAGMA user_version に適用済みのバージョンを記録する。
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// NewDB returns go-sqlite3 driver based *sql.DB, tracing the queries with the tracer
+// provider set by otel.SetTracerProvider.
 func NewDB(path string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", path)
+	// todo_tags などの ON DELETE CASCADE を有効にするため、接続ごとに外部キー制約をオンにする。
+	// また、読み取りの後に書き込むトランザクション同士がロックの昇格で衝突しないよう、
+	// トランザクションは開始時に書き込みロックを取得する。
+	// さらに、カレンダーのフィードなどの時間のかかる読み取りが書き込みを止めないよう、WAL モードにする。
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	// クエリごとに OpenTelemetry のスパンを作る。リクエストなどの親のスパンがないクエリは記録しない
+	db, err := otelsql.Open("sqlite3", path+sep+"_foreign_keys=on&_txlock=immediate&_journal_mode=WAL",
+		otelsql.WithAttributes(semconv.DBSystemSqlite),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{DisableErrSkip: true}))
 	if err != nil {
 		return nil, err
 	}
@@ -21,5 +47,116 @@ func NewDB(path string) (*sql.DB, error) {
 		return nil, err
 	}
 
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+
 	return db, nil
 }
+
+// migration は埋め込んだマイグレーションの1ファイル
+type migration struct {
+	version int
+	name    string
+}
+
+// readMigrations は埋め込んだマイグレーションをバージョン順に返す
+func readMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	// fs.ReadDir はファイル名順に返すので、そのままバージョン順になる
+	ms := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		version, err := strconv.Atoi(strings.SplitN(entry.Name(), "_", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q: %w", entry.Name(), err)
+		}
+		ms = append(ms, migration{version: version, name: entry.Name()})
+	}
+	return ms, nil
+}
+
+// SchemaVersion returns the version of the last migration applied to db.
+func SchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// LatestSchemaVersion returns the version of the last migration embedded in the binary,
+// which NewDB applies.
+func LatestSchemaVersion() (int, error) {
+	ms, err := readMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	return ms[len(ms)-1].version, nil
+}
+
+// CheckSchemaVersion returns an error if the schema of db is not LatestSchemaVersion, such
+// as when another process of an older or newer binary migrated the same file.
+func CheckSchemaVersion(ctx context.Context, db *sql.DB) error {
+	current, err := SchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	latest, err := LatestSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if current != latest {
+		return fmt.Errorf("schema version is %d, expected %d", current, latest)
+	}
+	return nil
+}
+
+// migrate は未適用のマイグレーションをバージョン順に1つずつトランザクション内で適用する
+func migrate(db *sql.DB) error {
+	current, err := SchemaVersion(context.Background(), db)
+	if err != nil {
+		return err
+	}
+
+	ms, err := readMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range ms {
+		if m.version <= current {
+			continue
+		}
+
+		body, err := migrations.ReadFile(path.Join("migrations", m.name))
+		if err != nil {
+			return err
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(string(body)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %s: %w", m.name, err)
+		}
+		// PRAGMA ではプレースホルダーが使えないため値を埋め込む
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, m.version)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,7 +1,9 @@
 package db_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
 	"testing"
 
@@ -44,3 +46,37 @@ func TestNewDB(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckSchemaVersion(t *testing.T) {
+	t.Parallel()
+
+	const path = "../.sqlite3/db_test_schema_version.db"
+	t.Cleanup(func() {
+		if err := os.Remove(path); err != nil {
+			t.Error("failed to cleanup testdata, err =", err)
+		}
+	})
+
+	d, err := db.NewDB(path)
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	defer d.Close()
+
+	ctx := context.Background()
+	if err := db.CheckSchemaVersion(ctx, d); err != nil {
+		t.Error("unexpected error after migration, err =", err)
+	}
+
+	latest, err := db.LatestSchemaVersion()
+	if err != nil {
+		t.Fatal("failed to read latest schema version, err =", err)
+	}
+	// 新しいバイナリがマイグレーションした状態を再現する
+	if _, err := d.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, latest+1)); err != nil {
+		t.Fatal("failed to set schema version, err =", err)
+	}
+	if err := db.CheckSchemaVersion(ctx, d); err == nil {
+		t.Error("expected error for schema version mismatch")
+	}
+}
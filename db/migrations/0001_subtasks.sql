@@ -0,0 +1,5 @@
+ALTER TABLE todos ADD COLUMN parent_id INTEGER REFERENCES todos(id) ON DELETE CASCADE;
+ALTER TABLE todos ADD COLUMN sort_order INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE todos ADD COLUMN completed_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS index_todos_parent_id ON todos(parent_id, sort_order);
@@ -0,0 +1,12 @@
+-- 一覧の並び順を表すキー。空文字列のものは RebalancePositions でキーが振られるまで ID の降順で先頭に並ぶ
+ALTER TABLE todos ADD COLUMN position TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS index_todos_position ON todos(position, id);
+
+-- 並び替えでは updated_at を更新しないよう、内容に関わるカラムの更新時だけ updated_at を更新する
+DROP TRIGGER IF EXISTS trigger_todos_updated_at;
+
+CREATE TRIGGER trigger_todos_updated_at AFTER UPDATE OF subject, description, parent_id, completed_at ON todos
+BEGIN
+  UPDATE todos SET updated_at = DATETIME('now') WHERE id == NEW.id;
+END;
@@ -0,0 +1,12 @@
+-- 期限と繰り返しの設定。rrule_start は繰り返しの起点（RRULE の DTSTART）で、次の回を作成しても変わらない
+ALTER TABLE todos ADD COLUMN due_at DATETIME;
+ALTER TABLE todos ADD COLUMN rrule TEXT NOT NULL DEFAULT '';
+ALTER TABLE todos ADD COLUMN rrule_start DATETIME;
+ALTER TABLE todos ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
+
+DROP TRIGGER IF EXISTS trigger_todos_updated_at;
+
+CREATE TRIGGER trigger_todos_updated_at AFTER UPDATE OF subject, description, parent_id, completed_at, due_at, rrule, timezone ON todos
+BEGIN
+  UPDATE todos SET updated_at = DATETIME('now') WHERE id == NEW.id;
+END;
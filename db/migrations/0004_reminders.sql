@@ -0,0 +1,17 @@
+-- TODOの期限の before_seconds 秒前に通知するリマインダー
+CREATE TABLE IF NOT EXISTS reminders (
+  id              INTEGER  NOT NULL PRIMARY KEY AUTOINCREMENT,
+  todo_id         INTEGER  NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+  before_seconds  INTEGER  NOT NULL DEFAULT 0,
+  status          TEXT     NOT NULL DEFAULT 'pending',
+  attempts        INTEGER  NOT NULL DEFAULT 0,
+  last_error      TEXT     NOT NULL DEFAULT '',
+  next_attempt_at DATETIME,
+  sent_at         DATETIME,
+  created_at      DATETIME NOT NULL DEFAULT (DATETIME('now')),
+  CHECK(before_seconds >= 0),
+  CHECK(status IN ('pending', 'sent', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS index_reminders_todo_id ON reminders(todo_id);
+CREATE INDEX IF NOT EXISTS index_reminders_status ON reminders(status);
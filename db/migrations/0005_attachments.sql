@@ -0,0 +1,14 @@
+-- TODOに添付したファイル。内容は BlobStore に SHA-256 をキーとして保存する
+CREATE TABLE IF NOT EXISTS attachments (
+  id           INTEGER  NOT NULL PRIMARY KEY AUTOINCREMENT,
+  todo_id      INTEGER  NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+  filename     TEXT     NOT NULL,
+  content_type TEXT     NOT NULL,
+  size         INTEGER  NOT NULL,
+  sha256       TEXT     NOT NULL,
+  created_at   DATETIME NOT NULL DEFAULT (DATETIME('now')),
+  CHECK(size >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS index_attachments_todo_id ON attachments(todo_id);
+CREATE INDEX IF NOT EXISTS index_attachments_sha256 ON attachments(sha256);
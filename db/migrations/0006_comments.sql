@@ -0,0 +1,18 @@
+-- TODOについてのコメント
+CREATE TABLE IF NOT EXISTS comments (
+  id          INTEGER  NOT NULL PRIMARY KEY AUTOINCREMENT,
+  todo_id     INTEGER  NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+  author      TEXT     NOT NULL,
+  body        TEXT     NOT NULL,
+  created_at  DATETIME NOT NULL DEFAULT (DATETIME('now')),
+  updated_at  DATETIME NOT NULL DEFAULT (DATETIME('now')),
+  CHECK(author <> ''),
+  CHECK(body <> '')
+);
+
+CREATE INDEX IF NOT EXISTS index_comments_todo_id ON comments(todo_id, id);
+
+CREATE TRIGGER IF NOT EXISTS trigger_comments_updated_at AFTER UPDATE OF body ON comments
+BEGIN
+  UPDATE comments SET updated_at = DATETIME('now') WHERE id == NEW.id;
+END;
@@ -0,0 +1,8 @@
+-- iCalendar フィードの秘密のURLに使うトークン。トークンそのものではなく SHA-256 を保存する
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+  id          INTEGER  NOT NULL PRIMARY KEY AUTOINCREMENT,
+  name        TEXT     NOT NULL,
+  token_hash  TEXT     NOT NULL UNIQUE,
+  created_at  DATETIME NOT NULL DEFAULT (DATETIME('now')),
+  CHECK(name <> '')
+);
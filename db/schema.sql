@@ -11,3 +11,18 @@ CREATE TRIGGER IF NOT EXISTS trigger_todos_updated_at AFTER UPDATE ON todos
 BEGIN
   UPDATE todos SET updated_at = DATETIME('now') WHERE id == NEW.id;
 END;
+
+CREATE TABLE IF NOT EXISTS tags (
+  id          INTEGER  NOT NULL PRIMARY KEY AUTOINCREMENT,
+  name        TEXT     NOT NULL UNIQUE,
+  created_at  DATETIME NOT NULL DEFAULT (DATETIME('now')),
+  CHECK(name <> '')
+);
+
+CREATE TABLE IF NOT EXISTS todo_tags (
+  todo_id     INTEGER  NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+  tag_id      INTEGER  NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+  PRIMARY KEY(todo_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS index_todo_tags_tag_id ON todo_tags(tag_id);
@@ -0,0 +1,135 @@
+// Package docs embeds the API documents.
+package docs
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPI is the OpenAPI document of the REST API, openapi.yaml.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+// UI is the page at /docs rendering the OpenAPI document served at openapi.json
+// next to it with Swagger UI and sending requests to the operations. It loads nothing
+// but the files of UIFiles served at /docs/.
+//
+//go:embed ui.html
+var UI []byte
+
+//go:embed swagger-ui/swagger-ui-bundle.js swagger-ui/swagger-ui.css
+var swaggerUI embed.FS
+
+// UIFiles are the files of Swagger UI loaded by UI, vendored in swagger-ui.
+var UIFiles, _ = fs.Sub(swaggerUI, "swagger-ui")
+
+// Prune returns the OpenAPI document spec in YAML keeping only the paths for which keep
+// returns true. The order and the comments of spec are kept.
+func Prune(spec []byte, keep func(path string) bool) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(spec, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) != 1 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("document must be a mapping")
+	}
+
+	doc := root.Content[0]
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value != "paths" {
+			continue
+		}
+		paths := doc.Content[i+1]
+		kept := paths.Content[:0]
+		for j := 0; j+1 < len(paths.Content); j += 2 {
+			if keep(paths.Content[j].Value) {
+				kept = append(kept, paths.Content[j], paths.Content[j+1])
+			}
+		}
+		paths.Content = kept
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// JSON converts the OpenAPI document spec in YAML to JSON, keeping the order of the keys.
+func JSON(spec []byte) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(spec, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, &root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSON は YAML のノードを JSON として書き込む。
+// encoding/json の map はキーを並べ替えるので、マッピングは自分で書き込む
+func writeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) != 1 {
+			return errors.New("document must have a node")
+		}
+		return writeJSON(buf, node.Content[0])
+	case yaml.AliasNode:
+		return writeJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(node.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		var v interface{}
+		if err := node.Decode(&v); err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		buf.Write(b)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package docs_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/TechBowl-japan/go-stations/docs"
+)
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+
+	spec, err := docs.Prune(docs.OpenAPI, func(path string) bool {
+		return !strings.HasPrefix(path, "/todos/{id}/attachments")
+	})
+	if err != nil {
+		t.Fatal("failed to prune document, err =", err)
+	}
+
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		t.Fatal("failed to load pruned document, err =", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Error("pruned document must be valid, err =", err)
+	}
+	if doc.Paths.Find("/todos/{id}/attachments") != nil {
+		t.Error("attachments must be pruned")
+	}
+	if doc.Paths.Find("/todos") == nil {
+		t.Error("todos must be kept")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	b, err := docs.JSON([]byte("b: 1\na:\n  - x\n  - true\n  - null\n'200': {c: 1.5}\n"))
+	if err != nil {
+		t.Fatal("failed to convert document, err =", err)
+	}
+	// キーの順序を保つ
+	if want := `{"b":1,"a":["x",true,null],"200":{"c":1.5}}`; string(b) != want {
+		t.Errorf("unexpected JSON, want = %s, given = %s", want, b)
+	}
+
+	b, err = docs.JSON(docs.OpenAPI)
+	if err != nil {
+		t.Fatal("failed to convert document, err =", err)
+	}
+	if !json.Valid(b) {
+		t.Error("document must be converted to valid JSON")
+	}
+}
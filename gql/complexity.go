@@ -0,0 +1,97 @@
+package gql
+
+import (
+	"strconv"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// listMultiplier は件数の引数を持たないリストのフィールドが返すと見積もる要素の数
+const listMultiplier = 10
+
+// listFields は件数の引数を持たないリストのフィールド。スカラーのリストは数えない
+var listFields = map[string]bool{
+	"children": true,
+	"comments": true,
+}
+
+// complexity は操作で解決されるフィールドの数を見積もる。
+// first を持つフィールドとリストのフィールドは、子のフィールドの数を要素の数だけ掛ける
+func complexity(doc *ast.QueryDocument, op *ast.OperationDefinition, variables map[string]interface{}) int {
+	c := &complexityCounter{doc: doc, op: op, variables: variables, visiting: map[string]bool{}}
+	return c.selectionSet(op.SelectionSet)
+}
+
+type complexityCounter struct {
+	doc       *ast.QueryDocument
+	op        *ast.OperationDefinition
+	variables map[string]interface{}
+	visiting  map[string]bool // 循環するフラグメントを無限に展開しないよう、展開中のフラグメント
+}
+
+func (c *complexityCounter) selectionSet(set ast.SelectionSet) int {
+	total := 0
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			children := c.selectionSet(sel.SelectionSet)
+			if n, ok := c.first(sel); ok {
+				// 範囲外の値は実行時にエラーになるので、範囲内に収めて見積もる
+				if n < 0 {
+					n = 0
+				} else if n > maxPageSize {
+					n = maxPageSize
+				}
+				children *= n
+			} else if listFields[sel.Name] {
+				children *= listMultiplier
+			}
+			total += 1 + children
+		case *ast.InlineFragment:
+			total += c.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			fragment := c.doc.Fragments.ForName(sel.Name)
+			if fragment == nil || c.visiting[sel.Name] {
+				continue
+			}
+			c.visiting[sel.Name] = true
+			total += c.selectionSet(fragment.SelectionSet)
+			c.visiting[sel.Name] = false
+		}
+	}
+	return total
+}
+
+// first はフィールドの first の値を返す。省略された場合はスキーマの既定値を使う
+func (c *complexityCounter) first(field *ast.Field) (int, bool) {
+	if field.Name != "todos" {
+		return 0, false
+	}
+
+	arg := field.Arguments.ForName("first")
+	if arg == nil {
+		return defaultPageSize, true
+	}
+	switch arg.Value.Kind {
+	case ast.IntValue:
+		n, err := strconv.Atoi(arg.Value.Raw)
+		if err != nil {
+			return maxPageSize, true
+		}
+		return n, true
+	case ast.Variable:
+		switch v := c.variables[arg.Value.Raw].(type) {
+		case float64: // JSON の数値
+			return int(v), true
+		case nil:
+			if def := c.op.VariableDefinitions.ForName(arg.Value.Raw); def != nil && def.DefaultValue != nil {
+				if n, err := strconv.Atoi(def.DefaultValue.Raw); err == nil {
+					return n, true
+				}
+			}
+			return defaultPageSize, true
+		}
+	}
+	// 不正な値は実行時にエラーになるので、最大の数で見積もる
+	return maxPageSize, true
+}
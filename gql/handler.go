@@ -0,0 +1,213 @@
+// Package gql serves the TODO API over GraphQL with the same services as the REST endpoints.
+package gql
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+//go:embed schema.graphql
+var schemaSource string
+
+// 既定の制限
+const (
+	DefaultMaxDepth      = 10
+	DefaultMaxComplexity = 1000
+)
+
+// maxParallelism はリクエストごとに並行して実行する resolver の数。
+// loader でまとめられるよう、1ページの TODO の数より多くする
+const maxParallelism = maxPageSize * 2
+
+// An Option configures Handler on NewHandler.
+type Option func(*options)
+
+type options struct {
+	maxDepth      int
+	maxComplexity int
+	introspection bool
+}
+
+// WithMaxDepth limits the nesting depth of fields in a query. The default is DefaultMaxDepth.
+func WithMaxDepth(n int) Option {
+	return func(o *options) {
+		o.maxDepth = n
+	}
+}
+
+// WithMaxComplexity limits the estimated number of fields resolved by a query,
+// where the fields under a list count for each of the elements. The default is
+// DefaultMaxComplexity.
+func WithMaxComplexity(n int) Option {
+	return func(o *options) {
+		o.maxComplexity = n
+	}
+}
+
+// WithIntrospection enables or disables introspection queries. They are disabled by default.
+func WithIntrospection(enabled bool) Option {
+	return func(o *options) {
+		o.introspection = enabled
+	}
+}
+
+// A Handler implements handling the GraphQL endpoint.
+type Handler struct {
+	schema        *graphql.Schema
+	todos         *service.TODOService
+	comments      *service.CommentService
+	maxComplexity int
+	introspection bool
+}
+
+// NewHandler returns Handler resolving the queries by todos and comments.
+func NewHandler(todos *service.TODOService, comments *service.CommentService, opts ...Option) (*Handler, error) {
+	o := options{maxDepth: DefaultMaxDepth, maxComplexity: DefaultMaxComplexity}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	schemaOpts := []graphql.SchemaOpt{graphql.MaxDepth(o.maxDepth), graphql.MaxParallelism(maxParallelism)}
+	if !o.introspection {
+		schemaOpts = append(schemaOpts, graphql.DisableIntrospection())
+	}
+	schema, err := graphql.ParseSchema(schemaSource, &resolver{todos: todos}, schemaOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	return &Handler{
+		schema:        schema,
+		todos:         todos,
+		comments:      comments,
+		maxComplexity: o.maxComplexity,
+		introspection: o.introspection,
+	}, nil
+}
+
+// request は GraphQL over HTTP のリクエスト
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, "variables must be a JSON object")
+				return
+			}
+		}
+	case http.MethodPost:
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			http.Error(w, "Unsupported Media Type", http.StatusUnsupportedMediaType)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "body must be a JSON object")
+			return
+		}
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// 実行する前に、操作の種類と見積もった複雑さを確認する
+	doc, err := parser.ParseQuery(&ast.Source{Input: req.Query})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	op := operation(doc, req.OperationName)
+	if op == nil {
+		writeError(w, http.StatusBadRequest, "operation is not found")
+		return
+	}
+	// GET は安全なメソッドなので、データを変更する操作は受け付けない
+	if r.Method == http.MethodGet && op.Operation != ast.Query {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "mutation must be sent by POST")
+		return
+	}
+	// 無効にしたイントロスペクションは、黙って null を返さずにエラーにする
+	if !h.introspection && introspects(doc, op.SelectionSet, map[string]bool{}) {
+		writeError(w, http.StatusBadRequest, "introspection is disabled")
+		return
+	}
+	if c := complexity(doc, op, req.Variables); c > h.maxComplexity {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("query is too complex: %d exceeds the limit %d", c, h.maxComplexity))
+		return
+	}
+
+	ctx := withLoaders(r.Context(), h.todos, h.comments)
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.Error(r.Context(), "failed to encode response", "err", err)
+	}
+}
+
+// operation は実行する操作を返す。名前の指定がない場合は、操作が1つだけのときにそれを返す
+func operation(doc *ast.QueryDocument, name string) *ast.OperationDefinition {
+	if name != "" {
+		return doc.Operations.ForName(name)
+	}
+	if len(doc.Operations) == 1 {
+		return doc.Operations[0]
+	}
+	return nil
+}
+
+// introspects は操作のルートで __schema か __type を選択しているかを返す。__typename は型の名前だけなので許可する
+func introspects(doc *ast.QueryDocument, set ast.SelectionSet, visiting map[string]bool) bool {
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if sel.Name == "__schema" || sel.Name == "__type" {
+				return true
+			}
+		case *ast.InlineFragment:
+			if introspects(doc, sel.SelectionSet, visiting) {
+				return true
+			}
+		case *ast.FragmentSpread:
+			// 循環するフラグメントは実行時に拒否されるので、展開中のものは見ない
+			fragment := doc.Fragments.ForName(sel.Name)
+			if fragment == nil || visiting[sel.Name] {
+				continue
+			}
+			visiting[sel.Name] = true
+			if introspects(doc, fragment.SelectionSet, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeError は実行する前に拒否したリクエストのエラーを GraphQL のレスポンスの形式で返す
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]string{{"message": message}},
+	})
+}
@@ -0,0 +1,317 @@
+package gql_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/gql"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// newHandler は空の DB を使う Handler と、同じ DB のサービスを返す
+func newHandler(t *testing.T, opts ...gql.Option) (*gql.Handler, *service.TODOService, *service.CommentService) {
+	t.Helper()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "gql_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	todos, comments := service.NewTODOService(todoDB), service.NewCommentService(todoDB)
+	h, err := gql.NewHandler(todos, comments, opts...)
+	if err != nil {
+		t.Fatal("failed to create handler, err =", err)
+	}
+	return h, todos, comments
+}
+
+// post は query を POST で送り、ステータスコードとレスポンスを返す
+func post(t *testing.T, h http.Handler, query string, variables map[string]interface{}) (int, *response) {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		t.Fatal("failed to marshal request, err =", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return serve(t, h, req)
+}
+
+func serve(t *testing.T, h http.Handler, req *http.Request) (int, *response) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response, err = %v, body = %s", err, rec.Body)
+	}
+	return rec.Code, &resp
+}
+
+func TestHandler_Query(t *testing.T) {
+	t.Parallel()
+
+	h, todos, comments := newHandler(t)
+	ctx := context.Background()
+
+	parent, err := todos.CreateTODO(ctx, "parent", "**bold**")
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	for i := 0; i < 2; i++ {
+		child, err := todos.CreateTODO(ctx, "child"+strconv.Itoa(i), "", service.WithParent(parent.ID))
+		if err != nil {
+			t.Fatal("failed to create todo, err =", err)
+		}
+		if _, err := comments.CreateComment(ctx, child.ID, "author", "body"); err != nil {
+			t.Fatal("failed to create comment, err =", err)
+		}
+	}
+
+	status, resp := post(t, h, `query($id: ID!) {
+		todo(id: $id) {
+			subject
+			descriptionHTML
+			children { subject parent { id } comments { body } }
+		}
+	}`, map[string]interface{}{"id": strconv.FormatInt(parent.ID, 10)})
+	if status != http.StatusOK || len(resp.Errors) != 0 {
+		t.Fatalf("unexpected response, status = %d, errors = %v", status, resp.Errors)
+	}
+
+	var data struct {
+		TODO struct {
+			Subject         string `json:"subject"`
+			DescriptionHTML string `json:"descriptionHTML"`
+			Children        []struct {
+				Subject string `json:"subject"`
+				Parent  struct {
+					ID string `json:"id"`
+				} `json:"parent"`
+				Comments []struct {
+					Body string `json:"body"`
+				} `json:"comments"`
+			} `json:"children"`
+		} `json:"todo"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatal("failed to unmarshal data, err =", err)
+	}
+	if data.TODO.Subject != "parent" || data.TODO.DescriptionHTML != "<p><strong>bold</strong></p>\n" {
+		t.Errorf("unexpected todo, given = %s", resp.Data)
+	}
+	if len(data.TODO.Children) != 2 {
+		t.Fatalf("unexpected children, given = %s", resp.Data)
+	}
+	for _, child := range data.TODO.Children {
+		if child.Parent.ID != strconv.FormatInt(parent.ID, 10) || len(child.Comments) != 1 || child.Comments[0].Body != "body" {
+			t.Errorf("unexpected child, given = %+v", child)
+		}
+	}
+
+	// 存在しないTODOは null になる
+	_, resp = post(t, h, `{ todo(id: "999") { id } }`, nil)
+	if len(resp.Errors) != 0 || string(resp.Data) != `{"todo":null}` {
+		t.Errorf("unexpected response, data = %s, errors = %v", resp.Data, resp.Errors)
+	}
+}
+
+func TestHandler_Todos(t *testing.T) {
+	t.Parallel()
+
+	h, todos, _ := newHandler(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := todos.CreateTODO(ctx, "subject"+strconv.Itoa(i), ""); err != nil {
+			t.Fatal("failed to create todo, err =", err)
+		}
+	}
+
+	type page struct {
+		TODOs struct {
+			Edges []struct {
+				Node struct {
+					Subject string `json:"subject"`
+				} `json:"node"`
+			} `json:"edges"`
+			PageInfo struct {
+				HasNextPage bool    `json:"hasNextPage"`
+				EndCursor   *string `json:"endCursor"`
+			} `json:"pageInfo"`
+		} `json:"todos"`
+	}
+	const query = `query($after: ID) {
+		todos(first: 2, after: $after) { edges { node { subject } } pageInfo { hasNextPage endCursor } }
+	}`
+
+	var subjects []string
+	var after interface{}
+	for i := 0; ; i++ {
+		if i > 3 {
+			t.Fatal("pages must end")
+		}
+		_, resp := post(t, h, query, map[string]interface{}{"after": after})
+		if len(resp.Errors) != 0 {
+			t.Fatal("unexpected errors, given =", resp.Errors)
+		}
+		var p page
+		if err := json.Unmarshal(resp.Data, &p); err != nil {
+			t.Fatal("failed to unmarshal data, err =", err)
+		}
+		for _, edge := range p.TODOs.Edges {
+			subjects = append(subjects, edge.Node.Subject)
+		}
+		if !p.TODOs.PageInfo.HasNextPage {
+			break
+		}
+		after = *p.TODOs.PageInfo.EndCursor
+	}
+	if len(subjects) != 3 {
+		t.Errorf("all todos must be read, given = %v", subjects)
+	}
+
+	_, resp := post(t, h, `{ todos(first: 101) { edges { cursor } } }`, nil)
+	if len(resp.Errors) != 1 {
+		t.Errorf("first out of range must be an error, given = %v", resp.Errors)
+	}
+}
+
+func TestHandler_Mutation(t *testing.T) {
+	t.Parallel()
+
+	h, todos, _ := newHandler(t)
+
+	_, resp := post(t, h, `mutation { createTODO(input: {subject: "subject", description: "description", tags: ["a"]}) { id } }`, nil)
+	var created struct {
+		CreateTODO struct {
+			ID string `json:"id"`
+		} `json:"createTODO"`
+	}
+	if err := json.Unmarshal(resp.Data, &created); err != nil || created.CreateTODO.ID == "" {
+		t.Fatalf("failed to create todo, data = %s, errors = %v", resp.Data, resp.Errors)
+	}
+
+	// 省略した説明とタグは変更しない
+	_, resp = post(t, h, `mutation($id: ID!) { updateTODO(input: {id: $id, subject: "updated", completed: true}) { id } }`,
+		map[string]interface{}{"id": created.CreateTODO.ID})
+	if len(resp.Errors) != 0 {
+		t.Fatal("failed to update todo, errors =", resp.Errors)
+	}
+	id, _ := strconv.ParseInt(created.CreateTODO.ID, 10, 64)
+	read, err := todos.ReadTODOsByIDs(context.Background(), []int64{id})
+	if err != nil || len(read) != 1 {
+		t.Fatal("failed to read todo, err =", err)
+	}
+	if read[0].Subject != "updated" || read[0].Description != "description" || len(read[0].Tags) != 1 || read[0].CompletedAt == nil {
+		t.Errorf("unexpected todo, given = %+v", read[0])
+	}
+
+	_, resp = post(t, h, `mutation($id: ID!) { deleteTODOs(ids: [$id]) }`, map[string]interface{}{"id": created.CreateTODO.ID})
+	if len(resp.Errors) != 0 {
+		t.Fatal("failed to delete todo, errors =", resp.Errors)
+	}
+	_, resp = post(t, h, `mutation($id: ID!) { deleteTODOs(ids: [$id]) }`, map[string]interface{}{"id": created.CreateTODO.ID})
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != "not found" {
+		t.Errorf("unexpected errors, given = %v", resp.Errors)
+	}
+
+	// GET ではデータを変更できない
+	req := httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(`mutation { deleteTODOs(ids: ["1"]) }`), nil)
+	if status, _ := serve(t, h, req); status != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected status, want = %d, given = %d", http.StatusMethodNotAllowed, status)
+	}
+}
+
+func TestHandler_Limits(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		opts       []gql.Option
+		query      string
+		variables  map[string]interface{}
+		wantStatus int
+		wantError  bool
+	}{
+		"Simple": {
+			query:      `{ todos { edges { node { id } } } }`,
+			wantStatus: http.StatusOK,
+		},
+		"TooDeep": {
+			opts:       []gql.Option{gql.WithMaxDepth(3)},
+			query:      `{ todo(id: "1") { parent { parent { parent { id } } } } }`,
+			wantStatus: http.StatusOK,
+			wantError:  true,
+		},
+		"TooComplex": {
+			query:      `{ todos(first: 100) { edges { node { children { children { id } } } } } }`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  true,
+		},
+		"TooComplexByVariable": {
+			opts:       []gql.Option{gql.WithMaxComplexity(100)},
+			query:      `query($n: Int) { todos(first: $n) { edges { node { id subject } } } }`,
+			variables:  map[string]interface{}{"n": 50},
+			wantStatus: http.StatusBadRequest,
+			wantError:  true,
+		},
+		"IntrospectionDisabled": {
+			query:      `{ ...schema } fragment schema on Query { __schema { types { name } } }`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  true,
+		},
+		"TypenameWithoutIntrospection": {
+			query:      `{ __typename }`,
+			wantStatus: http.StatusOK,
+		},
+		"CyclicFragment": {
+			query:      `{ ...a } fragment a on Query { ...a }`,
+			wantStatus: http.StatusOK,
+			wantError:  true,
+		},
+		"IntrospectionEnabled": {
+			opts:       []gql.Option{gql.WithIntrospection(true)},
+			query:      `{ __schema { queryType { name } } }`,
+			wantStatus: http.StatusOK,
+		},
+		"SyntaxError": {
+			query:      `{ todos {`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  true,
+		},
+	}
+
+	for name, tc := range testcases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h, _, _ := newHandler(t, tc.opts...)
+			status, resp := post(t, h, tc.query, tc.variables)
+			if status != tc.wantStatus {
+				t.Errorf("unexpected status, want = %d, given = %d", tc.wantStatus, status)
+			}
+			if hasError := len(resp.Errors) != 0; hasError != tc.wantError {
+				t.Errorf("unexpected errors, given = %v", resp.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package gql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+const (
+	// loaderWait は同時に解決されるフィールドのキーをまとめるために待つ時間
+	loaderWait = time.Millisecond
+	// loaderMaxBatch を超えるキーは次のクエリで読み込む
+	loaderMaxBatch = 500
+)
+
+// A loader batches the loads of keys requested within loaderWait into one fetch,
+// and caches the results during the request like DataLoader.
+type loader struct {
+	fetch func(ctx context.Context, keys []int64) (map[int64]interface{}, error)
+
+	mu      sync.Mutex
+	pending *batch
+	batches map[int64]*batch // キーを読み込んだ（読み込み中の）バッチ
+}
+
+type batch struct {
+	keys   []int64
+	once   sync.Once
+	done   chan struct{}
+	values map[int64]interface{}
+	err    error
+}
+
+func newLoader(fetch func(ctx context.Context, keys []int64) (map[int64]interface{}, error)) *loader {
+	return &loader{
+		fetch:   fetch,
+		batches: map[int64]*batch{},
+	}
+}
+
+// load はキーの値を返す。値がない場合は nil を返す
+func (l *loader) load(ctx context.Context, key int64) (interface{}, error) {
+	l.mu.Lock()
+	b, ok := l.batches[key]
+	if !ok {
+		if l.pending == nil {
+			l.pending = &batch{done: make(chan struct{})}
+			pending := l.pending
+			time.AfterFunc(loaderWait, func() { l.dispatch(ctx, pending) })
+		}
+		b = l.pending
+		b.keys = append(b.keys, key)
+		l.batches[key] = b
+		if len(b.keys) == loaderMaxBatch {
+			go l.dispatch(ctx, b)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return b.values[key], b.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// dispatch はバッチのキーをまとめて読み込む。時間切れと上限のどちらで呼ばれても1回だけ読み込む
+func (l *loader) dispatch(ctx context.Context, b *batch) {
+	b.once.Do(func() {
+		l.mu.Lock()
+		if l.pending == b {
+			l.pending = nil
+		}
+		l.mu.Unlock()
+
+		b.values, b.err = l.fetch(ctx, b.keys)
+		close(b.done)
+	})
+}
+
+// loaders はリクエストごとの loader
+type loaders struct {
+	svc *service.TODOService
+
+	todos    *loader // TODOのIDから *model.TODO
+	children *loader // 親のIDから []*model.TODO
+	comments *loader // TODOのIDから []*model.Comment
+}
+
+type contextKeyLoaders struct{}
+
+func withLoaders(ctx context.Context, todos *service.TODOService, comments *service.CommentService) context.Context {
+	l := &loaders{
+		svc: todos,
+		todos: newLoader(func(ctx context.Context, ids []int64) (map[int64]interface{}, error) {
+			read, err := todos.ReadTODOsByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			values := make(map[int64]interface{}, len(read))
+			for _, todo := range read {
+				values[todo.ID] = todo
+			}
+			return values, nil
+		}),
+		children: newLoader(func(ctx context.Context, parentIDs []int64) (map[int64]interface{}, error) {
+			read, err := todos.ReadChildrenOf(ctx, parentIDs)
+			if err != nil {
+				return nil, err
+			}
+			grouped := map[int64][]*model.TODO{}
+			for _, todo := range read {
+				grouped[*todo.ParentID] = append(grouped[*todo.ParentID], todo)
+			}
+			values := make(map[int64]interface{}, len(grouped))
+			for id, children := range grouped {
+				values[id] = children
+			}
+			return values, nil
+		}),
+		comments: newLoader(func(ctx context.Context, todoIDs []int64) (map[int64]interface{}, error) {
+			read, err := comments.ReadCommentsOf(ctx, todoIDs)
+			if err != nil {
+				return nil, err
+			}
+			grouped := map[int64][]*model.Comment{}
+			for _, comment := range read {
+				grouped[comment.TODOID] = append(grouped[comment.TODOID], comment)
+			}
+			values := make(map[int64]interface{}, len(grouped))
+			for id, comments := range grouped {
+				values[id] = comments
+			}
+			return values, nil
+		}),
+	}
+	return context.WithValue(ctx, contextKeyLoaders{}, l)
+}
+
+func loadersFromContext(ctx context.Context) *loaders {
+	return ctx.Value(contextKeyLoaders{}).(*loaders)
+}
@@ -0,0 +1,371 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// todos の first の既定値と、指定できる最大の数
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// resolver は Query と Mutation のルートの resolver
+type resolver struct {
+	todos *service.TODOService
+}
+
+func (r *resolver) Todo(ctx context.Context, args struct{ ID graphql.ID }) (*todoResolver, error) {
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, err
+	}
+	return loadTODO(ctx, id)
+}
+
+func (r *resolver) Todos(ctx context.Context, args struct {
+	First    int32
+	After    *graphql.ID
+	Tags     *[]string
+	TagMatch string
+}) (*connectionResolver, error) {
+	// first の既定値はスキーマで defaultPageSize にしている
+	first := int64(args.First)
+	if first < 0 || first > maxPageSize {
+		return nil, toError(ctx, &model.ErrInvalidArgument{Field: "first", Reason: "must be between 0 and " + strconv.Itoa(maxPageSize)})
+	}
+
+	var prevID int64
+	if args.After != nil {
+		var err error
+		if prevID, err = parseID("after", *args.After); err != nil {
+			return nil, err
+		}
+	}
+
+	var tags []string
+	if args.Tags != nil {
+		tags = *args.Tags
+	}
+
+	// 次のページがあるかを知るため、1件多く読み取る
+	todos, err := r.todos.ReadTODO(ctx, prevID, first+1, service.WithTagFilter(tags, strings.ToLower(args.TagMatch)))
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+
+	c := &connectionResolver{}
+	if int64(len(todos)) > first {
+		todos, c.hasNextPage = todos[:first], true
+	}
+	for _, todo := range todos {
+		c.edges = append(c.edges, &todoResolver{todo: todo})
+	}
+	return c, nil
+}
+
+type createTODOInput struct {
+	Subject     string
+	Description *string
+	Tags        *[]string
+	ParentID    *graphql.ID
+	DueAt       *graphql.Time
+	RRule       *string
+	Timezone    *string
+}
+
+func (r *resolver) CreateTODO(ctx context.Context, args struct{ Input createTODOInput }) (*todoResolver, error) {
+	in := args.Input
+	if in.Subject == "" {
+		return nil, toError(ctx, &model.ErrInvalidArgument{Field: "subject", Reason: "must not be empty"})
+	}
+
+	var opts []service.TODOOption
+	if in.Tags != nil {
+		opts = append(opts, service.WithTags(*in.Tags))
+	}
+	if in.ParentID != nil {
+		parentID, err := parseID("parentId", *in.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, service.WithParent(parentID))
+	}
+	if in.DueAt != nil {
+		opts = append(opts, service.WithDueAt(in.DueAt.Time))
+	}
+	if in.RRule != nil || in.Timezone != nil {
+		opts = append(opts, service.WithRRule(stringValue(in.RRule)), service.WithTimezone(stringValue(in.Timezone)))
+	}
+
+	todo, err := r.todos.CreateTODO(ctx, in.Subject, stringValue(in.Description), opts...)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	return &todoResolver{todo: todo}, nil
+}
+
+type updateTODOInput struct {
+	ID          graphql.ID
+	Subject     string
+	Description *string
+	Tags        *[]string
+	ParentID    *graphql.ID
+	Completed   *bool
+	DueAt       *graphql.Time
+	RRule       *string
+	Timezone    *string
+}
+
+func (r *resolver) UpdateTODO(ctx context.Context, args struct{ Input updateTODOInput }) (*todoResolver, error) {
+	in := args.Input
+	id, err := parseID("id", in.ID)
+	if err != nil {
+		return nil, err
+	}
+	if in.Subject == "" {
+		return nil, toError(ctx, &model.ErrInvalidArgument{Field: "subject", Reason: "must not be empty"})
+	}
+
+	// 説明が省略された場合は今の説明のままにする
+	var (
+		description string
+		opts        []service.TODOOption
+	)
+	if in.Description != nil {
+		description = *in.Description
+	} else {
+		opts = append(opts, service.KeepDescription())
+	}
+	if in.Tags != nil {
+		opts = append(opts, service.WithTags(append([]string{}, *in.Tags...)))
+	}
+	if in.ParentID != nil {
+		parentID, err := parseID("parentId", *in.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, service.WithParent(parentID))
+	}
+	if in.Completed != nil {
+		opts = append(opts, service.WithCompleted(*in.Completed))
+	}
+	if in.DueAt != nil {
+		opts = append(opts, service.WithDueAt(in.DueAt.Time))
+	}
+	if in.RRule != nil {
+		opts = append(opts, service.WithRRule(*in.RRule))
+	}
+	if in.Timezone != nil {
+		opts = append(opts, service.WithTimezone(*in.Timezone))
+	}
+
+	todo, err := r.todos.UpdateTODO(ctx, id, in.Subject, description, opts...)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	return &todoResolver{todo: todo}, nil
+}
+
+func (r *resolver) DeleteTODOs(ctx context.Context, args struct{ IDs []graphql.ID }) ([]graphql.ID, error) {
+	ids := make([]int64, len(args.IDs))
+	for i, id := range args.IDs {
+		var err error
+		if ids[i], err = parseID("ids", id); err != nil {
+			return nil, err
+		}
+	}
+	if len(ids) == 0 {
+		return nil, toError(ctx, &model.ErrInvalidArgument{Field: "ids", Reason: "must not be empty"})
+	}
+
+	if err := r.todos.DeleteTODO(ctx, ids); err != nil {
+		return nil, toError(ctx, err)
+	}
+	return args.IDs, nil
+}
+
+// todoResolver は TODO 型の resolver
+type todoResolver struct {
+	todo *model.TODO
+}
+
+func (r *todoResolver) ID() graphql.ID      { return formatID(r.todo.ID) }
+func (r *todoResolver) Subject() string     { return r.todo.Subject }
+func (r *todoResolver) Description() string { return r.todo.Description }
+func (r *todoResolver) Rrule() string       { return r.todo.RRule }
+func (r *todoResolver) Timezone() string    { return r.todo.Timezone }
+func (r *todoResolver) CommentCount() int32 { return int32(r.todo.CommentCount) }
+
+func (r *todoResolver) DescriptionHTML(ctx context.Context) (string, error) {
+	// 同じTODOが並行して解決されることがあるので、コピーに設定する
+	todo := *r.todo
+	if err := loadersFromContext(ctx).svc.RenderDescriptionHTML([]*model.TODO{&todo}); err != nil {
+		return "", toError(ctx, err)
+	}
+	return todo.DescriptionHTML, nil
+}
+
+func (r *todoResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.todo.CreatedAt} }
+func (r *todoResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: r.todo.UpdatedAt} }
+
+func (r *todoResolver) Tags() []string {
+	if r.todo.Tags == nil {
+		return []string{}
+	}
+	return r.todo.Tags
+}
+
+func (r *todoResolver) CompletedAt() *graphql.Time { return timeValue(r.todo.CompletedAt) }
+func (r *todoResolver) DueAt() *graphql.Time       { return timeValue(r.todo.DueAt) }
+
+func (r *todoResolver) Parent(ctx context.Context) (*todoResolver, error) {
+	if r.todo.ParentID == nil {
+		return nil, nil
+	}
+	return loadTODO(ctx, *r.todo.ParentID)
+}
+
+func (r *todoResolver) Children(ctx context.Context) ([]*todoResolver, error) {
+	v, err := loadersFromContext(ctx).children.load(ctx, r.todo.ID)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	children, _ := v.([]*model.TODO)
+	resolvers := make([]*todoResolver, len(children))
+	for i, child := range children {
+		resolvers[i] = &todoResolver{todo: child}
+	}
+	return resolvers, nil
+}
+
+func (r *todoResolver) Comments(ctx context.Context) ([]*commentResolver, error) {
+	v, err := loadersFromContext(ctx).comments.load(ctx, r.todo.ID)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	comments, _ := v.([]*model.Comment)
+	resolvers := make([]*commentResolver, len(comments))
+	for i, comment := range comments {
+		resolvers[i] = &commentResolver{comment: comment}
+	}
+	return resolvers, nil
+}
+
+// commentResolver は Comment 型の resolver
+type commentResolver struct {
+	comment *model.Comment
+}
+
+func (r *commentResolver) ID() graphql.ID          { return formatID(r.comment.ID) }
+func (r *commentResolver) Author() string          { return r.comment.Author }
+func (r *commentResolver) Body() string            { return r.comment.Body }
+func (r *commentResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.comment.CreatedAt} }
+func (r *commentResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: r.comment.UpdatedAt} }
+
+// connectionResolver は TODOConnection 型の resolver
+type connectionResolver struct {
+	edges       []*todoResolver
+	hasNextPage bool
+}
+
+func (r *connectionResolver) Edges() []*edgeResolver {
+	edges := make([]*edgeResolver, len(r.edges))
+	for i, node := range r.edges {
+		edges[i] = &edgeResolver{node: node}
+	}
+	return edges
+}
+
+func (r *connectionResolver) PageInfo() *pageInfoResolver {
+	p := &pageInfoResolver{hasNextPage: r.hasNextPage}
+	if len(r.edges) > 0 {
+		cursor := r.edges[len(r.edges)-1].ID()
+		p.endCursor = &cursor
+	}
+	return p
+}
+
+// edgeResolver は TODOEdge 型の resolver。カーソルは ReadTODO の prevID に使うTODOのID
+type edgeResolver struct {
+	node *todoResolver
+}
+
+func (r *edgeResolver) Cursor() graphql.ID  { return r.node.ID() }
+func (r *edgeResolver) Node() *todoResolver { return r.node }
+
+type pageInfoResolver struct {
+	hasNextPage bool
+	endCursor   *graphql.ID
+}
+
+func (r *pageInfoResolver) HasNextPage() bool      { return r.hasNextPage }
+func (r *pageInfoResolver) EndCursor() *graphql.ID { return r.endCursor }
+
+// loadTODO は loader でTODOを読み込む。TODOがない場合は nil を返す
+func loadTODO(ctx context.Context, id int64) (*todoResolver, error) {
+	v, err := loadersFromContext(ctx).todos.load(ctx, id)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	todo, ok := v.(*model.TODO)
+	if !ok {
+		return nil, nil
+	}
+	return &todoResolver{todo: todo}, nil
+}
+
+// toError はサービス層のエラーを利用者に返すエラーにする。内部のエラーの詳細は返さない
+func toError(ctx context.Context, err error) error {
+	var (
+		notFound *model.ErrNotFound
+		invalid  *model.ErrInvalidArgument
+	)
+	switch {
+	case errors.As(err, &notFound):
+		return errors.New("not found")
+	case errors.As(err, &invalid):
+		return invalid
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	default:
+		logging.Error(ctx, "internal error", "err", err)
+		return errors.New("internal error")
+	}
+}
+
+func parseID(field string, id graphql.ID) (int64, error) {
+	n, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil {
+		return 0, &model.ErrInvalidArgument{Field: field, Reason: "must be an integer ID"}
+	}
+	return n, nil
+}
+
+func formatID(id int64) graphql.ID {
+	return graphql.ID(strconv.FormatInt(id, 10))
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func timeValue(t *time.Time) *graphql.Time {
+	if t == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t}
+}
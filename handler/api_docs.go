@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/docs"
+	"github.com/TechBowl-japan/go-stations/logging"
+)
+
+// An APIDocsHandler implements serving the OpenAPI document at /openapi.yaml and
+// /openapi.json, the page rendering it at /docs, and the files loaded by the page at /docs/.
+type APIDocsHandler struct {
+	spec       []byte
+	ui         []byte
+	files      fs.FS
+	registered func(path string) bool
+
+	once sync.Once
+	yaml []byte
+	json []byte
+	err  error
+}
+
+// NewAPIDocsHandler returns APIDocsHandler based http.Handler serving spec with only
+// the paths for which registered returns true, the files of files at /docs/<name>, and
+// ui at the other paths. registered is called on the first request so that the routes
+// registered after NewAPIDocsHandler are also documented.
+func NewAPIDocsHandler(spec, ui []byte, files fs.FS, registered func(path string) bool) *APIDocsHandler {
+	return &APIDocsHandler{
+		spec:       spec,
+		ui:         ui,
+		files:      files,
+		registered: registered,
+	}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *APIDocsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// ページが読み込むファイルは埋め込んだものだけを返し、ディレクトリーの一覧は返さない
+	if name := strings.TrimPrefix(r.URL.Path, "/docs/"); name != r.URL.Path {
+		if name == "" {
+			http.NotFound(w, r)
+			return
+		}
+		b, err := fs.ReadFile(h.files, name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		// 埋め込んだファイルには更新日時がないので、Content-Type だけを拡張子から決める
+		http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(b))
+		return
+	}
+
+	var contentType string
+	var body []byte
+	switch path.Ext(r.URL.Path) {
+	case ".yaml", ".json":
+		// 登録されているエンドポイントだけのドキュメントを最初のリクエストで作る
+		h.once.Do(func() {
+			h.yaml, h.err = docs.Prune(h.spec, h.registered)
+			if h.err == nil {
+				h.json, h.err = docs.JSON(h.yaml)
+			}
+		})
+		if h.err != nil {
+			logging.Error(r.Context(), "failed to build OpenAPI document", "err", h.err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		contentType, body = "application/yaml", h.yaml
+		if path.Ext(r.URL.Path) == ".json" {
+			contentType, body = "application/json", h.json
+		}
+	default:
+		contentType, body = "text/html; charset=utf-8", h.ui
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
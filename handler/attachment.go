@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// multipartMemory は multipart のファイルをメモリに保持する上限。超えた分は一時ファイルに書き出される
+const multipartMemory = 1 << 20
+
+// An AttachmentHandler implements handling REST endpoints of attachments of a TODO.
+type AttachmentHandler struct {
+	svc *service.AttachmentService
+}
+
+// NewAttachmentHandler returns AttachmentHandler based http.Handler.
+func NewAttachmentHandler(svc *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *AttachmentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	todoID := todoIDFromContext(r.Context())
+
+	// /todos/{id}/attachments/{attachment_id} はファイルのダウンロード
+	if subPath := subPathFromContext(r.Context()); subPath != "" {
+		id, err := strconv.ParseInt(subPath, 10, 64)
+		if err != nil || id <= 0 {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.download(w, r, todoID, id)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		// ファイルの上限に multipart の境界などの分の余裕を足した大きさまで受け付ける
+		limit := h.svc.MaxSize + multipartMemory
+		if r.ContentLength > limit {
+			http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		if err := r.ParseMultipartForm(multipartMemory); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		// multipart/form-data の file フィールドのファイルをすべて添付する
+		files := r.MultipartForm.File["file"]
+		if len(files) == 0 {
+			http.Error(w, "Bad Request: file is required", http.StatusBadRequest)
+			return
+		}
+
+		attachments := make([]*model.Attachment, 0, len(files))
+		for _, file := range files {
+			attachment, err := h.create(r, todoID, file)
+			if err != nil {
+				writeServiceError(w, err)
+				return
+			}
+			attachments = append(attachments, attachment)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.CreateAttachmentResponse{Attachments: attachments})
+	case http.MethodGet:
+		attachments, err := h.svc.ReadAttachments(r.Context(), todoID)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.ReadAttachmentResponse{Attachments: attachments})
+	case http.MethodDelete:
+		// DeleteAttachmentRequest に JSON Decode
+		var req model.DeleteAttachmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		// idのリストが空の場合を判定
+		if len(req.IDs) == 0 {
+			http.Error(w, "Bad Request: ids are required", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.svc.DeleteAttachments(r.Context(), todoID, req.IDs); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.DeleteAttachmentResponse{})
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// create は multipart のファイル1つを添付する
+func (h *AttachmentHandler) create(r *http.Request, todoID int64, file *multipart.FileHeader) (*model.Attachment, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return h.svc.CreateAttachment(r.Context(), todoID, file.Filename, f)
+}
+
+// download は添付ファイルの内容を返す。Range リクエストには http.ServeContent が対応する
+func (h *AttachmentHandler) download(w http.ResponseWriter, r *http.Request, todoID, id int64) {
+	attachment, content, err := h.svc.OpenAttachment(r.Context(), todoID, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	defer content.Close()
+
+	// ブラウザで開かれても実行されないよう、ダウンロードとして返し内容の推測もさせない
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+attachment.SHA256+`"`)
+	http.ServeContent(w, r, attachment.Filename, attachment.CreatedAt, content)
+}
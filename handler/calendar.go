@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// A CalendarFeedHandler implements handling REST endpoints of the secret iCalendar feed URLs.
+type CalendarFeedHandler struct {
+	svc *service.CalendarFeedService
+}
+
+// NewCalendarFeedHandler returns CalendarFeedHandler based http.Handler.
+func NewCalendarFeedHandler(svc *service.CalendarFeedService) *CalendarFeedHandler {
+	return &CalendarFeedHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *CalendarFeedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		// CreateCalendarFeedRequest に JSON Decode
+		var req model.CreateCalendarFeedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		// 認証している場合は、本文の name ではなく認証した利用者にフィードを発行する
+		name := req.Name
+		if user, ok := middleware.UserFromContext(r.Context()); ok {
+			name = user
+		}
+
+		feed, token, err := h.svc.CreateFeed(r.Context(), name)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.CreateCalendarFeedResponse{
+			Feed:  *feed,
+			Token: token,
+			URL:   "/todos.ics?" + url.Values{"token": {token}}.Encode(),
+		})
+	case http.MethodGet:
+		feeds, err := h.svc.ReadFeeds(r.Context(), feedOptions(r.Context())...)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.ReadCalendarFeedResponse{Feeds: feeds})
+	case http.MethodDelete:
+		// DeleteCalendarFeedRequest に JSON Decode
+		var req model.DeleteCalendarFeedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		// idのリストが空の場合を判定
+		if len(req.IDs) == 0 {
+			http.Error(w, "Bad Request: ids are required", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.svc.DeleteFeeds(r.Context(), req.IDs, feedOptions(r.Context())...); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.DeleteCalendarFeedResponse{})
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// feedOptions は認証している場合に、認証した利用者のフィードだけを読み取り、取り消せるようにする
+func feedOptions(ctx context.Context) []service.FeedOption {
+	if user, ok := middleware.UserFromContext(ctx); ok {
+		return []service.FeedOption{service.FeedsOf(user)}
+	}
+	return nil
+}
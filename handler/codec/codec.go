@@ -0,0 +1,179 @@
+// Package codec encodes and decodes HTTP bodies in the media types negotiated
+// by the Accept and Content-Type headers.
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var (
+	// ErrNotAcceptable is returned by Negotiate when no codec matches the Accept header.
+	ErrNotAcceptable = errors.New("codec: not acceptable")
+	// ErrUnsupportedMediaType is returned by ForContentType when no codec matches the Content-Type header.
+	ErrUnsupportedMediaType = errors.New("codec: unsupported media type")
+)
+
+// A Codec encodes and decodes values in a media type.
+// Struct fields are named by their json tags in every media type.
+type Codec interface {
+	// ContentType returns the value of the Content-Type header of the encoded body.
+	ContentType() string
+	Encode(w io.Writer, v interface{}) error
+	Decode(r io.Reader, v interface{}) error
+}
+
+// JSON is the Codec of application/json.
+var JSON Codec = jsonCodec{}
+
+// MessagePack is the Codec of application/msgpack.
+var MessagePack Codec = msgpackCodec{}
+
+// CBOR is the Codec of application/cbor.
+var CBOR Codec = cborCodec{}
+
+// codecs はサーバーが優先する順の対応する形式。q が同じ場合は先にあるものを選ぶ
+var codecs = []struct {
+	codec      Codec
+	mediaTypes []string // 最初のものが正式な名前で、残りは別名
+}{
+	{JSON, []string{"application/json"}},
+	{MessagePack, []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}},
+	{CBOR, []string{"application/cbor"}},
+}
+
+// Negotiate returns the Codec preferred by the Accept header.
+// JSON is returned when accept is empty.
+func Negotiate(accept string) (Codec, error) {
+	if strings.TrimSpace(accept) == "" {
+		return JSON, nil
+	}
+	ranges := parseAccept(accept)
+
+	var (
+		best  Codec
+		bestQ float64
+	)
+	for _, c := range codecs {
+		if q := quality(ranges, c.mediaTypes); q > bestQ {
+			best, bestQ = c.codec, q
+		}
+	}
+	if best == nil {
+		return nil, ErrNotAcceptable
+	}
+	return best, nil
+}
+
+// ForContentType returns the Codec of the Content-Type header.
+// JSON is returned when contentType is empty.
+func ForContentType(contentType string) (Codec, error) {
+	if contentType == "" {
+		return JSON, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ErrUnsupportedMediaType
+	}
+	for _, c := range codecs {
+		for _, t := range c.mediaTypes {
+			if mediaType == t {
+				return c.codec, nil
+			}
+		}
+	}
+	return nil, ErrUnsupportedMediaType
+}
+
+// mediaRange は Accept に列挙された1つの形式
+type mediaRange struct {
+	mediaType string // "type/subtype"、"type/*" または "*/*"
+	q         float64
+}
+
+// parseAccept は Accept の値を読み取る。読み取れない形式は無視する
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+// quality はいずれかの mediaTypes に最も具体的に一致する形式の q を返す。一致しない場合は0を返す
+func quality(ranges []mediaRange, mediaTypes []string) float64 {
+	best, specificity := 0.0, -1
+	for _, t := range mediaTypes {
+		major := t[:strings.IndexByte(t, '/')]
+		for _, r := range ranges {
+			var s int
+			switch r.mediaType {
+			case t:
+				s = 2
+			case major + "/*":
+				s = 1
+			case "*/*":
+				s = 0
+			default:
+				continue
+			}
+			if s > specificity || (s == specificity && r.q > best) {
+				best, specificity = r.q, s
+			}
+		}
+	}
+	return best
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return "application/json" }
+
+func (jsonCodec) Encode(w io.Writer, v interface{}) error { return json.NewEncoder(w).Encode(v) }
+
+func (jsonCodec) Decode(r io.Reader, v interface{}) error { return json.NewDecoder(r).Decode(v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string { return "application/msgpack" }
+
+func (msgpackCodec) Encode(w io.Writer, v interface{}) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
+
+func (msgpackCodec) Decode(r io.Reader, v interface{}) error {
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// cborEncMode は日時を JSON と同じく RFC 3339 の文字列に tag 0 を付けて書き込む
+var cborEncMode, _ = cbor.EncOptions{Time: cbor.TimeRFC3339Nano, TimeTag: cbor.EncTagRequired}.EncMode()
+
+type cborCodec struct{}
+
+func (cborCodec) ContentType() string { return "application/cbor" }
+
+// CBOR の struct は cbor タグがなければ json タグを使う
+func (cborCodec) Encode(w io.Writer, v interface{}) error { return cborEncMode.NewEncoder(w).Encode(v) }
+
+func (cborCodec) Decode(r io.Reader, v interface{}) error { return cbor.NewDecoder(r).Decode(v) }
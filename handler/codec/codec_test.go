@@ -0,0 +1,88 @@
+package codec_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/handler/codec"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		accept string
+		want   codec.Codec
+		err    error
+	}{
+		"Empty":         {accept: "", want: codec.JSON},
+		"Any":           {accept: "*/*", want: codec.JSON},
+		"XML":           {accept: "application/xml", err: codec.ErrNotAcceptable},
+		"Alias":         {accept: "application/x-msgpack", want: codec.MessagePack},
+		"Quality":       {accept: "application/json;q=0.5, application/cbor", want: codec.CBOR},
+		"Specific":      {accept: "*/*;q=0.1, application/json;q=0, application/msgpack;q=0.2", want: codec.MessagePack},
+		"Wildcard":      {accept: "application/*, application/json;q=0", want: codec.MessagePack},
+		"Browser":       {accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", want: codec.JSON},
+		"NotAcceptable": {accept: "text/html", err: codec.ErrNotAcceptable},
+		"Rejected":      {accept: "*/*;q=0", err: codec.ErrNotAcceptable},
+	}
+
+	for name, tc := range testcases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := codec.Negotiate(tc.accept)
+			if err != tc.err {
+				t.Fatalf("unexpected error, want = %v, given = %v", tc.err, err)
+			}
+			if got != tc.want {
+				t.Errorf("unexpected codec, want = %T, given = %T", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestForContentType(t *testing.T) {
+	t.Parallel()
+
+	if c, err := codec.ForContentType("application/cbor; charset=binary"); err != nil || c != codec.CBOR {
+		t.Errorf("unexpected codec, given = %T, err = %v", c, err)
+	}
+	if _, err := codec.ForContentType("text/plain"); err != codec.ErrUnsupportedMediaType {
+		t.Errorf("unexpected error, given = %v", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	parentID := int64(1)
+	want := model.ReadTODOResponse{TODOs: []*model.TODO{
+		{ID: 2, Subject: "subject", CreatedAt: now, UpdatedAt: now, Tags: []string{"a", "b"}, ParentID: &parentID, DueAt: &now},
+		{ID: 1, Subject: "parent", Description: "<desc>", CreatedAt: now, UpdatedAt: now},
+	}}
+
+	for _, c := range []codec.Codec{codec.JSON, codec.MessagePack, codec.CBOR} {
+		c := c
+		t.Run(c.ContentType(), func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			if err := c.Encode(&buf, want); err != nil {
+				t.Fatal("failed to encode, err =", err)
+			}
+			var got model.ReadTODOResponse
+			if err := c.Decode(&buf, &got); err != nil {
+				t.Fatal("failed to decode, err =", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
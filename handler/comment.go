@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// A CommentHandler implements handling REST endpoints of comments of a TODO.
+type CommentHandler struct {
+	svc *service.CommentService
+}
+
+// NewCommentHandler returns CommentHandler based http.Handler.
+func NewCommentHandler(svc *service.CommentService) *CommentHandler {
+	return &CommentHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *CommentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	todoID := todoIDFromContext(r.Context())
+
+	switch r.Method {
+	case http.MethodPost:
+		// CreateCommentRequest に JSON Decode
+		var req model.CreateCommentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		// 認証している場合は、本文の author ではなく認証した利用者を投稿者にする
+		author := req.Author
+		if user, ok := middleware.UserFromContext(r.Context()); ok {
+			author = user
+		}
+
+		comment, err := h.svc.CreateComment(r.Context(), todoID, author, req.Body)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.CreateCommentResponse{Comment: *comment})
+	case http.MethodGet:
+		// TODOの一覧と同じく prev_id と size でページングする
+		var prevID, size int64 = 0, 10
+		if v := r.URL.Query().Get("prev_id"); v != "" {
+			var err error
+			if prevID, err = strconv.ParseInt(v, 10, 64); err != nil {
+				http.Error(w, "Invalid prev_id parameter", http.StatusBadRequest)
+				return
+			}
+		}
+		if v := r.URL.Query().Get("size"); v != "" {
+			var err error
+			if size, err = strconv.ParseInt(v, 10, 64); err != nil {
+				http.Error(w, "Invalid size parameter", http.StatusBadRequest)
+				return
+			}
+		}
+
+		comments, err := h.svc.ReadComments(r.Context(), todoID, prevID, size)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.ReadCommentResponse{Comments: comments})
+	case http.MethodPut:
+		// UpdateCommentRequest に JSON Decode
+		var req model.UpdateCommentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		// id が 0 の場合を判定
+		if req.ID == 0 {
+			http.Error(w, "Bad Request: id is required", http.StatusBadRequest)
+			return
+		}
+
+		comment, err := h.svc.UpdateComment(r.Context(), todoID, req.ID, req.Body, authorOptions(r.Context())...)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.UpdateCommentResponse{Comment: *comment})
+	case http.MethodDelete:
+		// DeleteCommentRequest に JSON Decode
+		var req model.DeleteCommentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		// idのリストが空の場合を判定
+		if len(req.IDs) == 0 {
+			http.Error(w, "Bad Request: ids are required", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.svc.DeleteComments(r.Context(), todoID, req.IDs, authorOptions(r.Context())...); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.DeleteCommentResponse{})
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// authorOptions は認証している場合に、認証した利用者のコメントだけを変更できるようにする
+func authorOptions(ctx context.Context) []service.CommentOption {
+	if user, ok := middleware.UserFromContext(ctx); ok {
+		return []service.CommentOption{service.ByAuthor(user)}
+	}
+	return nil
+}
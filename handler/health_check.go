@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// A HealthCheckHandler implements the liveness and readiness check endpoints.
+type HealthCheckHandler struct {
+	check func(ctx context.Context) *model.HealthCheckResponse
+}
+
+// NewHealthCheckHandler returns HealthCheckHandler based http.Handler responding the
+// results of check, such as health.Registry.Ready, with 503 if any of them failed.
+func NewHealthCheckHandler(check func(ctx context.Context) *model.HealthCheckResponse) *HealthCheckHandler {
+	return &HealthCheckHandler{check: check}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *HealthCheckHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rw, ok := negotiate(w, r)
+	if !ok {
+		return
+	}
+
+	res := h.check(r.Context())
+
+	// 古い結果をプロキシなどが返さないようにする
+	w.Header().Set("Cache-Control", "no-store")
+	status := http.StatusOK
+	if res.Status != model.HealthStatusOK {
+		// 失敗したチェックは、どの依存先に問題があるかをログにも残す
+		for _, c := range res.Checks {
+			if c.Status != model.HealthStatusOK {
+				logging.Warn(r.Context(), "health check failed", "check", c.Name, "err", c.Error)
+			}
+		}
+		status = http.StatusServiceUnavailable
+	}
+	rw.write(status, res)
+}
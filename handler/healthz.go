@@ -1,8 +1,6 @@
 package handler
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
 
 	"github.com/TechBowl-japan/go-stations/model"
@@ -20,16 +18,15 @@ func NewHealthzHandler() *HealthzHandler {
 
 // ServeHTTP implements http.Handler interface.
 func (h *HealthzHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// 1. HealthzResponse を変数に代入
-	res := &model.HealthzResponse{Message: "OK"} // 1. Message の Field に OK という文字を入れる
+	// 1. Accept に応じたレスポンスの形式を選ぶ
+	rw, ok := negotiate(w, r)
+	if !ok {
+		return
+	}
 
-	// 2. JSONにシリアライズして書き込み
-	w.Header().Set("Content-Type", "application/json")
-	encoder := json.NewEncoder(w)
+	// 2. HealthzResponse を変数に代入
+	res := &model.HealthzResponse{Message: "OK"} // Message の Field に OK という文字を入れる
 
-	err := encoder.Encode(res) // 3. Encodeメソッドを呼び出し
-	if err != nil {
-		// 4. シリアライズ失敗時のエラーログ出力
-		log.Println("Failed to encode HealthzResponse:", err)
-	}
+	// 3. 選んだ形式にシリアライズして書き込み（失敗時はエラーログを出力）
+	rw.write(http.StatusOK, res)
 }
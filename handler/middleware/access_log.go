@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formats of AccessLog.
+const (
+	// AccessLogCombined is the Apache combined log format followed by the duration in
+	// microseconds and the quoted OS name of the client.
+	AccessLogCombined = "combined"
+	// AccessLogJSON writes a JSON object per line.
+	AccessLogJSON = "json"
+)
+
+// AccessLog returns Middleware writing a line per request to w in format,
+// AccessLogCombined or AccessLogJSON, after the response is written. It must be placed
+// outside RequestID to log the request ID and the bytes of the error bodies with the
+// request ID appended, outside Recovery to log the requests recovered from panics, and
+// outside UserAgentMiddleware to log the OS name derived by it.
+func AccessLog(w io.Writer, format string) (Middleware, error) {
+	var write func(io.Writer, *accessLogEntry) error
+	switch format {
+	case AccessLogCombined:
+		write = writeCombined
+	case AccessLogJSON:
+		write = writeJSON
+	default:
+		return nil, fmt.Errorf("middleware: unknown access log format %q", format)
+	}
+
+	// 並行するリクエストの行が混ざらないよう、1行ずつ書き込む
+	var mu sync.Mutex
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusResponseWriter{ResponseWriter: rw}
+			// 内側の UserAgentMiddleware が導いた OS 名を受け取る
+			fields := &accessLogFields{}
+			next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), contextKeyAccessLog, fields)))
+
+			entry := &accessLogEntry{
+				Time:      start,
+				RequestID: sw.Header().Get(RequestIDHeader), // 内側の RequestID がレスポンスのヘッダーに設定する
+				ClientIP:  clientIP(r),
+				Method:    r.Method,
+				Path:      r.URL.RequestURI(),
+				Proto:     r.Proto,
+				Status:    sw.Status(),
+				Bytes:     sw.bytes,
+				Duration:  time.Since(start),
+				Referer:   r.Referer(),
+				UserAgent: r.UserAgent(),
+				OS:        fields.os,
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			write(w, entry) // ログを書き込めなくてもレスポンスは返し終えている
+		})
+	}, nil
+}
+
+// contextKeyAccessLog は内側のミドルウェアがアクセスログの項目を記録する accessLogFields のキー
+var contextKeyAccessLog = contextKey("accessLog")
+
+// accessLogFields は内側のミドルウェアが記録するアクセスログの項目
+type accessLogFields struct {
+	os string
+}
+
+// accessLogEntry はアクセスログの1行の内容
+type accessLogEntry struct {
+	Time      time.Time
+	RequestID string
+	ClientIP  string
+	Method    string
+	Path      string
+	Proto     string
+	Status    int
+	Bytes     int64
+	Duration  time.Duration
+	Referer   string
+	UserAgent string
+	OS        string
+}
+
+// writeCombined は Apache の combined 形式に、処理時間（マイクロ秒）と OS 名を加えて書き込む
+func writeCombined(w io.Writer, e *accessLogEntry) error {
+	size := "-"
+	if e.Bytes > 0 {
+		size = strconv.FormatInt(e.Bytes, 10)
+	}
+	_, err := fmt.Fprintf(w, "%s - - [%s] %s %d %s %s %s %d %s\n",
+		orDash(e.ClientIP),
+		e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		quote(e.Method+" "+e.Path+" "+e.Proto),
+		e.Status,
+		size,
+		quote(e.Referer),
+		quote(e.UserAgent),
+		e.Duration.Microseconds(),
+		quote(e.OS),
+	)
+	return err
+}
+
+// writeJSON は1行に1つの JSON のオブジェクトを書き込む
+func writeJSON(w io.Writer, e *accessLogEntry) error {
+	b, err := json.Marshal(struct {
+		Time       time.Time `json:"time"`
+		RequestID  string    `json:"request_id,omitempty"`
+		ClientIP   string    `json:"client_ip"`
+		Method     string    `json:"method"`
+		Path       string    `json:"path"`
+		Proto      string    `json:"proto"`
+		Status     int       `json:"status"`
+		Bytes      int64     `json:"bytes"`
+		DurationMS float64   `json:"duration_ms"`
+		Referer    string    `json:"referer,omitempty"`
+		UserAgent  string    `json:"user_agent,omitempty"`
+		OS         string    `json:"os,omitempty"`
+	}{
+		Time:       e.Time,
+		RequestID:  e.RequestID,
+		ClientIP:   e.ClientIP,
+		Method:     e.Method,
+		Path:       e.Path,
+		Proto:      e.Proto,
+		Status:     e.Status,
+		Bytes:      e.Bytes,
+		DurationMS: float64(e.Duration.Microseconds()) / 1000,
+		Referer:    e.Referer,
+		UserAgent:  e.UserAgent,
+		OS:         e.OS,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// clientIP は接続元の IP アドレスを返す。ヘッダーは偽装できるので X-Forwarded-For は使わない
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// orDash は空の値を combined 形式で値がないことを表す "-" にする
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// quote は combined 形式の引用符で囲んだ値を返す。行が壊れないよう引用符と制御文字はエスケープする
+func quote(s string) string {
+	if s == "" {
+		return `"-"`
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range []byte(s) {
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// statusResponseWriter は書き込んだステータスコードと本文のバイト数を記録する
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// Status returns the status code written, 200 if only the body is written.
+func (w *statusResponseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *statusResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher interface if the underlying ResponseWriter does.
+func (w *statusResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker interface if the underlying ResponseWriter does.
+func (w *statusResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("middleware: %T does not implement http.Hijacker", w.ResponseWriter)
+	}
+	// 接続を引き渡した後のやり取りはこのサーバーからは見えないので、101 として記録する
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
@@ -0,0 +1,151 @@
+package middleware_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+)
+
+const macUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15"
+
+func TestAccessLog(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		format string
+		check  func(t *testing.T, line []byte)
+	}{
+		"Combined": {
+			format: middleware.AccessLogCombined,
+			check: func(t *testing.T, line []byte) {
+				want := regexp.MustCompile(`^192\.0\.2\.1 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "POST /todos\?size=1 HTTP/1\.1" 201 5 "https://example\.com/" "Mozilla/5\.0 [^"]*" \d+ "macOS"\n$`)
+				if !want.Match(line) {
+					t.Errorf("unexpected line, given = %q", line)
+				}
+			},
+		},
+		"JSON": {
+			format: middleware.AccessLogJSON,
+			check: func(t *testing.T, line []byte) {
+				var entry struct {
+					RequestID string  `json:"request_id"`
+					ClientIP  string  `json:"client_ip"`
+					Method    string  `json:"method"`
+					Path      string  `json:"path"`
+					Status    int     `json:"status"`
+					Bytes     int64   `json:"bytes"`
+					Duration  float64 `json:"duration_ms"`
+					OS        string  `json:"os"`
+				}
+				if err := json.Unmarshal(line, &entry); err != nil {
+					t.Fatal("failed to parse line, err =", err)
+				}
+				if entry.RequestID != "id" || entry.ClientIP != "192.0.2.1" || entry.Method != http.MethodPost || entry.Path != "/todos?size=1" ||
+					entry.Status != http.StatusCreated || entry.Bytes != 5 || entry.Duration < 0 || entry.OS != "macOS" {
+					t.Errorf("unexpected entry, given = %+v", entry)
+				}
+			},
+		},
+	}
+
+	for name, tc := range testcases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			accessLog, err := middleware.AccessLog(&buf, tc.format)
+			if err != nil {
+				t.Fatal("failed to create middleware, err =", err)
+			}
+			h := middleware.NewChain(accessLog, middleware.RequestID, middleware.UserAgentMiddleware).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("hello"))
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/todos?size=1", nil)
+			req.Header.Set(middleware.RequestIDHeader, "id")
+			req.Header.Set("Referer", "https://example.com/")
+			req.Header.Set("User-Agent", macUserAgent)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			tc.check(t, buf.Bytes())
+		})
+	}
+
+	if _, err := middleware.AccessLog(&bytes.Buffer{}, "common"); err == nil {
+		t.Error("unknown format must be rejected")
+	}
+}
+
+func TestAccessLog_Recovery(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	accessLog, err := middleware.AccessLog(&buf, middleware.AccessLogCombined)
+	if err != nil {
+		t.Fatal("failed to create middleware, err =", err)
+	}
+	h := middleware.NewChain(accessLog, middleware.RequestID, middleware.Recovery).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("intentional panic")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/do-panic", nil)
+	req.Header.Set(middleware.RequestIDHeader, "panic-id")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	// 本文を書かずに返したリクエストもステータスコードを残し、バイト数はリクエストIDを付け加えた本文のものにする
+	if rec.Body.String() != "Internal Server Error (request_id: panic-id)\n" {
+		t.Fatalf("unexpected body, given = %q", rec.Body)
+	}
+	if want := regexp.MustCompile(`"GET /do-panic HTTP/1\.1" 500 ` + strconv.Itoa(rec.Body.Len()) + ` "-" "-" \d+ "-"\n$`); !want.Match(buf.Bytes()) {
+		t.Errorf("unexpected line, given = %q", buf.String())
+	}
+}
+
+func TestAccessLog_Interfaces(t *testing.T) {
+	t.Parallel()
+
+	accessLog, err := middleware.AccessLog(&bytes.Buffer{}, middleware.AccessLogJSON)
+	if err != nil {
+		t.Fatal("failed to create middleware, err =", err)
+	}
+	// ストリーミングや WebSocket のハンドラーが使えるよう、元の ResponseWriter の機能を保つ
+	h := middleware.NewChain(accessLog, middleware.RequestID).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("ResponseWriter must implement http.Flusher")
+		}
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("ResponseWriter must implement http.Hijacker")
+			return
+		}
+		conn, rw, err := hj.Hijack()
+		if err != nil {
+			t.Error("failed to hijack, err =", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 2\r\nConnection: close\r\n\r\nok")
+		rw.Flush()
+	})
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal("failed to send request, err =", err)
+	}
+	defer resp.Body.Close()
+	b, _ := bufio.NewReader(resp.Body).ReadString('\n')
+	if resp.StatusCode != http.StatusOK || b != "ok" {
+		t.Errorf("unexpected response, status = %d, body = %q", resp.StatusCode, b)
+	}
+}
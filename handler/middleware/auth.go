@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+)
+
+// DefaultUser is the user authenticated by the token given to Auth.
+const DefaultUser = "default"
+
+var contextKeyUser = contextKey("User")
+
+// Auth returns Middleware accepting only the requests with token as the bearer token in
+// the Authorization header, except for the requests to publicPaths. The requests are
+// authenticated as DefaultUser.
+func Auth(token string, publicPaths ...string) Middleware {
+	return AuthUsers(map[string]string{token: DefaultUser}, publicPaths...)
+}
+
+// AuthUsers is like Auth, but accepts any token of users, a map from the bearer tokens to
+// the names of the users, and authenticates the requests as the user of the token.
+// The user is available with UserFromContext and is logged as the "user" attribute.
+func AuthUsers(users map[string]string, publicPaths ...string) Middleware {
+	public := make(map[string]bool, len(publicPaths))
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if public[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			given, ok := bearerToken(r)
+			user, found := "", false
+			if ok {
+				user, found = lookupUser(users, given)
+			}
+			if !found {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="todo"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			// ログにも認証した利用者を残す
+			ctx := logging.ContextWithAttrs(context.WithValue(r.Context(), contextKeyUser, user), slog.String("user", user))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// UserFromContext returns the user authenticated by Auth or AuthUsers, or false if the
+// request was not authenticated.
+func UserFromContext(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(contextKeyUser).(string)
+	return user, ok
+}
+
+// lookupUser は given をトークンとする利用者を探す
+func lookupUser(users map[string]string, given string) (string, bool) {
+	// トークンの比較にかかる時間から推測されないよう、すべてのトークンと一定時間で比較する
+	user, found := "", false
+	for token, name := range users {
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+			user, found = name, true
+		}
+	}
+	return user, found
+}
+
+// bearerToken は Authorization ヘッダーの bearer トークンを返す
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	// スキームは大文字と小文字を区別しない
+	if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return "", false
+	}
+	return auth[len(prefix):], true
+}
@@ -0,0 +1,116 @@
+package middleware_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/logging"
+)
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	h := middleware.Auth("secret", "/public")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	testcases := map[string]struct {
+		target        string
+		authorization string
+		want          int
+	}{
+		"Valid":        {target: "/todos", authorization: "Bearer secret", want: http.StatusOK},
+		"InvalidToken": {target: "/todos", authorization: "Bearer other", want: http.StatusUnauthorized},
+		"NoScheme":     {target: "/todos", authorization: "secret", want: http.StatusUnauthorized},
+		"Missing":      {target: "/todos", want: http.StatusUnauthorized},
+		"Public":       {target: "/public", want: http.StatusOK},
+	}
+
+	for name, tc := range testcases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("unexpected status, want = %d, given = %d", tc.want, rec.Code)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("WWW-Authenticate must be set")
+			}
+		})
+	}
+}
+
+func TestAuthUsers(t *testing.T) {
+	t.Parallel()
+
+	h := middleware.AuthUsers(map[string]string{"alice-token": "alice", "bob-token": "bob"}, "/public")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			user = "(none)"
+		}
+		w.Write([]byte(user))
+	}))
+
+	testcases := map[string]struct {
+		target        string
+		authorization string
+		want          int
+		user          string
+	}{
+		"Alice":   {target: "/todos", authorization: "Bearer alice-token", want: http.StatusOK, user: "alice"},
+		"Bob":     {target: "/todos", authorization: "Bearer bob-token", want: http.StatusOK, user: "bob"},
+		"Unknown": {target: "/todos", authorization: "Bearer carol-token", want: http.StatusUnauthorized},
+		"Public":  {target: "/public", want: http.StatusOK, user: "(none)"},
+	}
+
+	for name, tc := range testcases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("unexpected status, want = %d, given = %d", tc.want, rec.Code)
+			}
+			if tc.want == http.StatusOK && rec.Body.String() != tc.user {
+				t.Errorf("unexpected user, want = %q, given = %q", tc.user, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthUsers_Logging(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatText, slog.LevelInfo)
+	if err != nil {
+		t.Fatal("failed to create logger, err =", err)
+	}
+
+	h := middleware.NewChain(middleware.Logger(logger), middleware.AuthUsers(map[string]string{"alice-token": "alice"})).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		logging.Info(r.Context(), "handled")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+	req.Header.Set("Authorization", "Bearer alice-token")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.Contains(buf.String(), "user=alice") {
+		t.Errorf("log must have the user, given = %q", buf.String())
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import "net/http"
+
+// A Middleware wraps http.Handler to process requests before or after it.
+type Middleware func(next http.Handler) http.Handler
+
+// A Chain is an ordered list of middlewares. The first middleware is the outermost one,
+// so it sees a request first and its response last.
+type Chain []Middleware
+
+// NewChain returns Chain of mws in the order.
+func NewChain(mws ...Middleware) Chain {
+	return append(Chain(nil), mws...)
+}
+
+// Append returns Chain of mws added after the middlewares of c, that is inside of them.
+// c is not modified, so that a shared chain can be extended per route.
+func (c Chain) Append(mws ...Middleware) Chain {
+	chain := make(Chain, 0, len(c)+len(mws))
+	chain = append(chain, c...)
+	return append(chain, mws...)
+}
+
+// Then returns h wrapped by the middlewares of c.
+func (c Chain) Then(h http.Handler) http.Handler {
+	// 最初のミドルウェアが最も外側になるよう、後ろから包む
+	for i := len(c) - 1; i >= 0; i-- {
+		h = c[i](h)
+	}
+	return h
+}
+
+// ThenFunc returns fn wrapped by the middlewares of c.
+func (c Chain) ThenFunc(fn http.HandlerFunc) http.Handler {
+	return c.Then(fn)
+}
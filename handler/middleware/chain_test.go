@@ -0,0 +1,51 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+)
+
+// record は呼び出された順序を calls に記録するミドルウェアを返す
+func record(calls *[]string, name string) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name+" before")
+			next.ServeHTTP(w, r)
+			*calls = append(*calls, name+" after")
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	base := middleware.NewChain(record(&calls, "a"), record(&calls, "b"))
+	extended := base.Append(record(&calls, "c"))
+	// 同じチェーンから別のエンドポイント向けに伸ばしても、互いに影響しない
+	other := base.Append(record(&calls, "d"))
+
+	extended.ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	}).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := []string{"a before", "b before", "c before", "handler", "c after", "b after", "a after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("unexpected order, want = %v, given = %v", want, calls)
+	}
+	if len(base) != 2 || len(other) != 3 {
+		t.Errorf("Append must not modify the chain, given = %d, %d", len(base), len(other))
+	}
+
+	calls = nil
+	middleware.NewChain().ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	}).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if !reflect.DeepEqual(calls, []string{"handler"}) {
+		t.Errorf("empty chain must call the handler only, given = %v", calls)
+	}
+}
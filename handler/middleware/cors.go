@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CORS の preflight に返す値
+var (
+	corsAllowedMethods = strings.Join([]string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}, ", ")
+	corsAllowedHeaders = "Accept, Authorization, Content-Type, " + RequestIDHeader + ", traceparent, tracestate"
+	corsMaxAge         = strconv.Itoa(10 * 60)
+)
+
+// CORS returns Middleware allowing the browsers on allowedOrigins, or any origin with "*",
+// to call the API. It answers the preflight requests by itself.
+func CORS(allowedOrigins ...string) Middleware {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[origin] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Origin によってヘッダーが変わるのでキャッシュに伝える
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if origin == "" || !(allowed[origin] || allowed["*"]) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			// ブラウザーのスクリプトからもリクエストIDを読めるようにする
+			w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
+
+			// preflight はハンドラーに渡さず、認証も求めない
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+				w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+				w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
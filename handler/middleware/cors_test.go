@@ -0,0 +1,71 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+)
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		origins     []string
+		method      string
+		origin      string
+		preflight   bool
+		want        int
+		allowOrigin string
+	}{
+		"Allowed": {
+			origins: []string{"https://example.com"}, method: http.MethodGet, origin: "https://example.com",
+			want: http.StatusTeapot, allowOrigin: "https://example.com",
+		},
+		"AnyOrigin": {
+			origins: []string{"*"}, method: http.MethodGet, origin: "https://example.com",
+			want: http.StatusTeapot, allowOrigin: "https://example.com",
+		},
+		"NotAllowed": {
+			origins: []string{"https://example.com"}, method: http.MethodGet, origin: "https://example.org",
+			want: http.StatusTeapot,
+		},
+		"Preflight": {
+			origins: []string{"https://example.com"}, method: http.MethodOptions, origin: "https://example.com", preflight: true,
+			want: http.StatusNoContent, allowOrigin: "https://example.com",
+		},
+		"PreflightNotAllowed": {
+			origins: []string{"https://example.com"}, method: http.MethodOptions, origin: "https://example.org", preflight: true,
+			want: http.StatusTeapot,
+		},
+	}
+
+	for name, tc := range testcases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := middleware.CORS(tc.origins...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			}))
+			req := httptest.NewRequest(tc.method, "/todos", nil)
+			req.Header.Set("Origin", tc.origin)
+			if tc.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tc.want {
+				t.Errorf("unexpected status, want = %d, given = %d", tc.want, rec.Code)
+			}
+			if given := rec.Header().Get("Access-Control-Allow-Origin"); given != tc.allowOrigin {
+				t.Errorf("unexpected Access-Control-Allow-Origin, want = %q, given = %q", tc.allowOrigin, given)
+			}
+			if tc.want == http.StatusNoContent && rec.Header().Get("Access-Control-Allow-Methods") == "" {
+				t.Error("Access-Control-Allow-Methods must be set on preflight")
+			}
+		})
+	}
+}
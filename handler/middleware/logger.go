@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+)
+
+// Logger returns Middleware storing logger in the request context, so that handlers and
+// the following middlewares log with it by logging.FromContext.
+func Logger(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(logging.ContextWithLogger(r.Context(), logger)))
+		})
+	}
+}
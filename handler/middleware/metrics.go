@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unmatchedRoute は、どのエンドポイントにも一致しないリクエストの route ラベル
+const unmatchedRoute = "unmatched"
+
+// HTTPMetrics records the Prometheus metrics of the requests per route, the pattern
+// stored by Route, method and status.
+type HTTPMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+	panics   *prometheus.CounterVec
+}
+
+// NewHTTPMetrics returns HTTPMetrics registering its metrics to reg.
+func NewHTTPMetrics(reg prometheus.Registerer) (*HTTPMetrics, error) {
+	m := &HTTPMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of HTTP requests handled.",
+		}, []string{"route", "method", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Latency of HTTP requests until the handler returns.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests being handled.",
+		}),
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_panics_recovered_total",
+			Help: "Number of panics recovered by the recovery middleware.",
+		}, []string{"route"}),
+	}
+	for _, c := range []prometheus.Collector{m.requests, m.duration, m.inFlight, m.panics} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Middleware records the metrics of the requests to next. It must be placed inside Route
+// to label the route, and outside RecoveryWith to count the requests recovered from panics.
+func (m *HTTPMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		start := time.Now()
+		sw := &statusResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		labels := prometheus.Labels{
+			"route":  routeLabel(r),
+			"method": methodLabel(r.Method),
+			"status": strconv.Itoa(sw.Status()),
+		}
+		m.requests.With(labels).Inc()
+		m.duration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
+
+// PanicRecovered counts the panic of r. It is meant to be passed to RecoveryWith.
+func (m *HTTPMetrics) PanicRecovered(r *http.Request, v interface{}) {
+	m.panics.WithLabelValues(routeLabel(r)).Inc()
+}
+
+// routeLabel は route ラベルの値を返す。パスをそのまま使うと値の種類が増え続けるので、パターンだけを使う
+func routeLabel(r *http.Request) string {
+	if route := RouteFromContext(r.Context()); route != "" {
+		return route
+	}
+	return unmatchedRoute
+}
+
+// methodLabel は method ラベルの値を返す。任意のメソッドで値の種類が増えないよう、標準のもの以外はまとめる
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
@@ -20,8 +20,19 @@ func UserAgentMiddleware(next http.Handler) http.Handler {
 
 		// 独自の型のキーを使用してOS名をコンテキストに格納
 		ctx := context.WithValue(r.Context(), contextKeyOSName, osName)
+		// 外側の AccessLog にも記録し、User-Agent を二度解析しないようにする
+		if fields, ok := ctx.Value(contextKeyAccessLog).(*accessLogFields); ok {
+			fields.os = osName
+		}
 		r = r.WithContext(ctx)
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// OSNameFromContext returns the OS name of the client stored by UserAgentMiddleware, or
+// an empty string.
+func OSNameFromContext(ctx context.Context) string {
+	osName, _ := ctx.Value(contextKeyOSName).(string)
+	return osName
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+)
+
+// An OpenAPIOption configures OpenAPIValidator on NewOpenAPIValidator.
+type OpenAPIOption func(*OpenAPIValidator)
+
+// WithResponseValidation also validates the responses, replacing the responses that do
+// not conform to the document with 500. It buffers whole responses, so it is meant for
+// tests and the strict mode.
+func WithResponseValidation() OpenAPIOption {
+	return func(v *OpenAPIValidator) {
+		v.responses = true
+	}
+}
+
+// An OpenAPIValidator validates requests, and responses with WithResponseValidation,
+// against the operations of an OpenAPI document.
+type OpenAPIValidator struct {
+	router    routers.Router
+	responses bool
+}
+
+// NewOpenAPIValidator returns OpenAPIValidator of the OpenAPI 3.0 document spec in YAML or JSON.
+func NewOpenAPIValidator(spec []byte, opts ...OpenAPIOption) (*OpenAPIValidator, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI document: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	// servers の URL はドキュメントの例なので、どのホストへのリクエストもパスだけで照合する
+	doc.Servers = nil
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create router: %w", err)
+	}
+
+	v := &OpenAPIValidator{router: router}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v, nil
+}
+
+// Middleware returns http.Handler validating the requests to next. The requests to the
+// operations not in the document are passed to next as they are.
+func (v *OpenAPIValidator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			// ドキュメントにない操作は、404 や 405 を返すハンドラーに任せる
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				// 既定値はハンドラーが決めるので、リクエストを書き換えない
+				SkipSettingDefaults: true,
+				// JSON 以外の本文は検証せず、対応する形式かはハンドラーが判断する
+				ExcludeRequestBody: !isJSON(r.Header.Get("Content-Type")),
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !v.responses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &bufferedResponseWriter{header: http.Header{}}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		err = openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rec.status,
+			Header:                 rec.header,
+			Body:                   ioutil.NopCloser(bytes.NewReader(rec.body.Bytes())),
+			Options: &openapi3filter.Options{
+				ExcludeResponseBody:   !isJSON(rec.header.Get("Content-Type")),
+				IncludeResponseStatus: true,
+			},
+		})
+		if err != nil {
+			logging.Error(r.Context(), "response does not conform to the OpenAPI document",
+				"method", r.Method, "path", r.URL.Path, "err", err)
+			http.Error(w, "Internal Server Error: response does not conform to the OpenAPI document: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for key, values := range rec.header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(rec.status)
+		w.Write(rec.body.Bytes())
+	})
+}
+
+// isJSON は Content-Type が JSON かを返す
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
+
+// bufferedResponseWriter はレスポンスを検証するまで書き込まずに保持する
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
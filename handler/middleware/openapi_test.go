@@ -0,0 +1,120 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+)
+
+const spec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /items:
+    get:
+      parameters:
+        - name: size
+          in: query
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: 200 response
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [items]
+                properties:
+                  items:
+                    type: array
+                    items:
+                      type: string
+`
+
+func TestOpenAPIValidator(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		opts   []middleware.OpenAPIOption
+		target string
+		body   string
+		status int
+		want   int
+	}{
+		"Valid": {
+			target: "/items?size=1",
+			body:   `{"items": ["a"]}`,
+			want:   http.StatusOK,
+		},
+		"InvalidRequest": {
+			target: "/items?size=one",
+			body:   `{"items": ["a"]}`,
+			want:   http.StatusBadRequest,
+		},
+		"InvalidResponseWithoutValidation": {
+			target: "/items",
+			body:   `{"items": [1]}`,
+			want:   http.StatusOK,
+		},
+		"InvalidResponse": {
+			opts:   []middleware.OpenAPIOption{middleware.WithResponseValidation()},
+			target: "/items",
+			body:   `{"items": [1]}`,
+			want:   http.StatusInternalServerError,
+		},
+		"UndocumentedStatus": {
+			opts:   []middleware.OpenAPIOption{middleware.WithResponseValidation()},
+			target: "/items",
+			status: http.StatusTeapot,
+			want:   http.StatusInternalServerError,
+		},
+		"UndocumentedPath": {
+			opts:   []middleware.OpenAPIOption{middleware.WithResponseValidation()},
+			target: "/other?size=one",
+			body:   `{}`,
+			want:   http.StatusOK,
+		},
+	}
+
+	for name, tc := range testcases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := middleware.NewOpenAPIValidator([]byte(spec), tc.opts...)
+			if err != nil {
+				t.Fatal("failed to create validator, err =", err)
+			}
+			h := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+				}
+				w.Write([]byte(tc.body))
+			}))
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.target, nil))
+			if rec.Code != tc.want {
+				t.Errorf("unexpected status, want = %d, given = %d, body = %s", tc.want, rec.Code, rec.Body)
+			}
+			if rec.Code == http.StatusOK && strings.TrimSpace(rec.Body.String()) != tc.body {
+				t.Errorf("response must be written as it is, given = %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestNewOpenAPIValidator_InvalidDocument(t *testing.T) {
+	t.Parallel()
+
+	if _, err := middleware.NewOpenAPIValidator([]byte(strings.Replace(spec, "type: integer", "type: number_or_string", 1))); err == nil {
+		t.Error("invalid document must be an error")
+	}
+}
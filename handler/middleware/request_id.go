@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+)
+
+// RequestIDHeader is the header carrying the request ID in requests and responses.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength は受け付けるリクエストIDの最大の長さ
+const maxRequestIDLength = 128
+
+var contextKeyRequestID = contextKey("RequestID")
+
+// RequestID accepts the request ID of the X-Request-ID header or generates one, and
+// stores it in the context for RequestIDFromContext and as the request_id attribute of the
+// log records. The ID is echoed in the header of
+// the response, and appended to the plain text bodies of the error responses written
+// by http.Error, so that clients can quote it to find the log lines of the request.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		rw := &requestIDResponseWriter{ResponseWriter: w, id: id}
+		ctx := logging.ContextWithAttrs(ContextWithRequestID(r.Context(), id), slog.String("request_id", id))
+		next.ServeHTTP(rw, r.WithContext(ctx))
+		rw.finish()
+	})
+}
+
+// ContextWithRequestID returns a copy of ctx with the request ID id.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKeyRequestID, id)
+}
+
+// RequestIDFromContext returns the request ID stored by RequestID, or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKeyRequestID).(string)
+	return id
+}
+
+// validRequestID は、ログやヘッダーにそのまま書いてよいリクエストIDかを返す
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.ContainsRune("-_.:", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID はランダムな 128 bit のリクエストIDを作る
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// 乱数が得られない環境ではIDがなくても処理は続けられる
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// requestIDResponseWriter はエラーのテキストの本文の末尾にリクエストIDを付け加える
+type requestIDResponseWriter struct {
+	http.ResponseWriter
+	id        string
+	buffering bool // エラーの本文を書き込まずに保持している
+	body      bytes.Buffer
+}
+
+func (w *requestIDResponseWriter) WriteHeader(status int) {
+	// http.Error が書くテキストの本文だけを対象にする
+	if status >= http.StatusBadRequest && strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		w.buffering = true
+		w.Header().Del("Content-Length")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *requestIDResponseWriter) Write(b []byte) (int, error) {
+	if w.buffering {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher interface if the underlying ResponseWriter does.
+func (w *requestIDResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && !w.buffering {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker interface if the underlying ResponseWriter does.
+func (w *requestIDResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("middleware: %T does not implement http.Hijacker", w.ResponseWriter)
+	}
+	return h.Hijack()
+}
+
+// finish は保持していたエラーの本文をリクエストIDを付けて書き込む
+func (w *requestIDResponseWriter) finish() {
+	if !w.buffering {
+		return
+	}
+	msg := strings.TrimRight(w.body.String(), "\n")
+	w.ResponseWriter.Write([]byte(msg + " (request_id: " + w.id + ")\n"))
+}
@@ -0,0 +1,105 @@
+package middleware_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/logging"
+)
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		header   string
+		status   int
+		keep     bool
+		wantBody string
+	}{
+		"Accepted": {
+			header: "client-id.1", status: http.StatusOK, keep: true,
+			wantBody: "ok",
+		},
+		"Generated": {
+			status:   http.StatusOK,
+			wantBody: "ok",
+		},
+		"Invalid": {
+			header: "bad id\nwith newline", status: http.StatusOK,
+			wantBody: "ok",
+		},
+		"TooLong": {
+			header: strings.Repeat("a", 129), status: http.StatusOK,
+			wantBody: "ok",
+		},
+		"Error": {
+			header: "client-id", status: http.StatusNotFound, keep: true,
+			wantBody: "Not Found (request_id: client-id)\n",
+		},
+	}
+
+	for name, tc := range testcases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var fromContext string
+			h := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromContext = middleware.RequestIDFromContext(r.Context())
+				if tc.status != http.StatusOK {
+					http.Error(w, http.StatusText(tc.status), tc.status)
+					return
+				}
+				w.Write([]byte("ok"))
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set(middleware.RequestIDHeader, tc.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(middleware.RequestIDHeader)
+			if id == "" || id != fromContext {
+				t.Errorf("request ID must be echoed and stored in context, header = %q, context = %q", id, fromContext)
+			}
+			if tc.keep != (id == tc.header) {
+				t.Errorf("unexpected request ID, given = %q, header = %q", id, tc.header)
+			}
+			if rec.Code != tc.status || rec.Body.String() != tc.wantBody {
+				t.Errorf("unexpected response, status = %d, body = %q", rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestRequestID_Logging(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatText, slog.LevelInfo)
+	if err != nil {
+		t.Fatal("failed to create logger, err =", err)
+	}
+
+	h := middleware.NewChain(middleware.Logger(logger), middleware.RequestID, middleware.Recovery).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("intentional panic")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middleware.RequestIDHeader, "panic-id")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "panic-id") {
+		t.Errorf("error response must have the request ID, status = %d, body = %q", rec.Code, rec.Body)
+	}
+	if !strings.Contains(buf.String(), "request_id=panic-id") {
+		t.Errorf("log must have the request ID, given = %q", buf.String())
+	}
+}
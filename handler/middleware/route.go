@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+)
+
+var contextKeyRoute = contextKey("Route")
+
+// Route returns Middleware storing the pattern of the handler of mux for the request, such
+// as "/todos/", in the context for RouteFromContext and as the route attribute of the log
+// records. Nothing is stored for the requests no handler matches.
+func Route(mux *http.ServeMux) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, pattern := mux.Handler(r); pattern != "" {
+				ctx := context.WithValue(r.Context(), contextKeyRoute, pattern)
+				r = r.WithContext(logging.ContextWithAttrs(ctx, slog.String("route", pattern)))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RouteFromContext returns the pattern stored by Route, or an empty string.
+func RouteFromContext(ctx context.Context) string {
+	route, _ := ctx.Value(contextKeyRoute).(string)
+	return route
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+)
+
+// tracer はこのパッケージのスパンを作る。otel.SetTracerProvider で設定したものを使う
+var tracer = otel.Tracer("github.com/TechBowl-japan/go-stations/handler/middleware")
+
+// traceContext は W3C Trace Context の traceparent と tracestate のヘッダー
+var traceContext propagation.TraceContext
+
+// Tracing starts the server span of each request with the tracer provider set by
+// otel.SetTracerProvider, continuing the trace of the W3C traceparent header if any.
+// The span is named after the route stored by Route, so it must be placed inside Route,
+// and outside Recovery to record the requests recovered from panics as errors. The
+// trace ID is also added to the log records as the trace_id attribute.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := traceContext.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		route := RouteFromContext(ctx)
+		name := r.Method
+		if route != "" {
+			name += " " + route
+		}
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", route, r)...),
+		)
+		defer span.End()
+
+		// 記録しないスパンでも、呼び出し元から受け取ったトレースはログで追えるようにする
+		if sc := span.SpanContext(); sc.HasTraceID() {
+			ctx = logging.ContextWithAttrs(ctx, slog.String("trace_id", sc.TraceID().String()))
+		}
+
+		sw := &statusResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		status := sw.Status()
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(status, trace.SpanKindServer))
+	})
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// A ReminderHandler implements handling REST endpoints of reminders of a TODO.
+type ReminderHandler struct {
+	svc *service.ReminderService
+}
+
+// NewReminderHandler returns ReminderHandler based http.Handler.
+func NewReminderHandler(svc *service.ReminderService) *ReminderHandler {
+	return &ReminderHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *ReminderHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	todoID := todoIDFromContext(r.Context())
+
+	switch r.Method {
+	case http.MethodPost:
+		// CreateReminderRequest に JSON Decode
+		var req model.CreateReminderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		reminder, err := h.svc.CreateReminder(r.Context(), todoID, req.BeforeSeconds)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.CreateReminderResponse{Reminder: *reminder})
+	case http.MethodGet:
+		reminders, err := h.svc.ReadReminders(r.Context(), todoID)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.ReadReminderResponse{Reminders: reminders})
+	case http.MethodDelete:
+		// DeleteReminderRequest に JSON Decode
+		var req model.DeleteReminderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		// idのリストが空の場合を判定
+		if len(req.IDs) == 0 {
+			http.Error(w, "Bad Request: ids are required", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.svc.DeleteReminders(r.Context(), todoID, req.IDs); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.DeleteReminderResponse{})
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/TechBowl-japan/go-stations/handler/codec"
+	"github.com/TechBowl-japan/go-stations/logging"
+)
+
+// tracer はハンドラーのスパンを作る。otel.SetTracerProvider で設定したものを使う
+var tracer = otel.Tracer("github.com/TechBowl-japan/go-stations/handler")
+
+// A responseWriter writes response bodies in the media type negotiated by the Accept header.
+type responseWriter struct {
+	w     http.ResponseWriter
+	r     *http.Request
+	codec codec.Codec
+}
+
+// negotiate は Accept からレスポンスの形式を選ぶ。
+// 対応する形式がない場合は 406 を返し、false を返す
+func negotiate(w http.ResponseWriter, r *http.Request) (*responseWriter, bool) {
+	// Accept によって内容が変わるのでキャッシュに伝える
+	w.Header().Add("Vary", "Accept")
+
+	c, err := codec.Negotiate(r.Header.Get("Accept"))
+	if err != nil {
+		http.Error(w, "Not Acceptable", http.StatusNotAcceptable)
+		return nil, false
+	}
+	return &responseWriter{w: w, r: r, codec: c}, true
+}
+
+// write は v を status のレスポンスとして書き込む
+func (rw *responseWriter) write(status int, v interface{}) {
+	// 本文の形式への変換にかかる時間を、サービスの処理と分けて見られるようにする
+	ctx, span := tracer.Start(rw.r.Context(), "encode response", trace.WithAttributes(attribute.String("content_type", rw.codec.ContentType())))
+	defer span.End()
+
+	rw.w.Header().Set("Content-Type", rw.codec.ContentType())
+	rw.w.WriteHeader(status)
+	// ヘッダーを書き込んだ後はステータスコードを変えられないので、エラーはログに残す
+	if err := rw.codec.Encode(rw.w, v); err != nil {
+		span.RecordError(err)
+		logging.Error(ctx, "failed to encode response", "err", err)
+	}
+}
+
+// decodeRequest は Content-Type の形式でリクエストの本文を v に読み取る。
+// 対応していない形式の場合は 415、読み取れない場合は 400 を返し、false を返す
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	c, err := codec.ForContentType(r.Header.Get("Content-Type"))
+	if errors.Is(err, codec.ErrUnsupportedMediaType) {
+		http.Error(w, "Unsupported Media Type", http.StatusUnsupportedMediaType)
+		return false
+	}
+
+	_, span := tracer.Start(r.Context(), "decode request", trace.WithAttributes(attribute.String("content_type", c.ContentType())))
+	defer span.End()
+	if err := c.Decode(r.Body, v); err != nil {
+		span.RecordError(err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
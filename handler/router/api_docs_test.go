@@ -0,0 +1,106 @@
+package router_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/blob"
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+)
+
+func TestAPIDocs(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "api_docs_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal("failed to create blob store, err =", err)
+	}
+
+	get := func(h http.Handler, target string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: unexpected status %d, body = %s", target, rec.Code, rec.Body)
+		}
+		return rec
+	}
+
+	// 添付ファイルのエンドポイントは保存先がある場合だけ登録するので、ドキュメントにも載せない
+	for name, tc := range map[string]struct {
+		opts        []router.Option
+		attachments bool
+	}{
+		"WithBlobStore":    {opts: []router.Option{router.WithBlobStore(blobs)}, attachments: true},
+		"WithoutBlobStore": {},
+	} {
+		h := router.NewRouter(todoDB, tc.opts...)
+
+		var doc struct {
+			Paths map[string]json.RawMessage `json:"paths"`
+		}
+		if err := json.Unmarshal(get(h, "/openapi.json").Body.Bytes(), &doc); err != nil {
+			t.Fatalf("%s: failed to decode document, err = %v", name, err)
+		}
+		for _, path := range []string{"/todos", "/todos/{id}/children", "/graphql", "/docs"} {
+			if _, ok := doc.Paths[path]; !ok {
+				t.Errorf("%s: %s must be documented", name, path)
+			}
+		}
+		for _, path := range []string{"/todos/{id}/attachments", "/todos/{id}/attachments/{attachment_id}"} {
+			if _, ok := doc.Paths[path]; ok != tc.attachments {
+				t.Errorf("%s: %s must be documented only with the blob store, given = %t", name, path, ok)
+			}
+		}
+
+		rec := get(h, "/openapi.yaml")
+		if ct := rec.Header().Get("Content-Type"); ct != "application/yaml" {
+			t.Errorf("%s: unexpected Content-Type of openapi.yaml, given = %s", name, ct)
+		}
+		if strings.Contains(rec.Body.String(), "/attachments") != tc.attachments {
+			t.Errorf("%s: openapi.yaml must have the same paths as openapi.json", name)
+		}
+	}
+
+	// ページは外部のスクリプトやスタイルを読み込まない
+	rec := get(router.NewRouter(todoDB), "/docs")
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("unexpected Content-Type of docs, given = %s", ct)
+	}
+	for _, external := range []string{"src=\"http", "href=\"http", "@import", "//cdn"} {
+		if strings.Contains(rec.Body.String(), external) {
+			t.Errorf("docs must be self-contained, given %q", external)
+		}
+	}
+
+	// ページが読み込む Swagger UI は埋め込んだものを提供し、認証を求めない
+	h := router.NewRouter(todoDB, router.WithAuthToken("token"))
+	for target, contentType := range map[string]string{
+		"/docs/swagger-ui-bundle.js": "text/javascript",
+		"/docs/swagger-ui.css":       "text/css",
+	} {
+		if !strings.Contains(rec.Body.String(), strings.TrimPrefix(target, "/")) {
+			t.Errorf("docs must load %s", target)
+		}
+		if ct := get(h, target).Header().Get("Content-Type"); !strings.HasPrefix(ct, contentType) {
+			t.Errorf("unexpected Content-Type of %s, given = %s", target, ct)
+		}
+	}
+	for _, target := range []string{"/docs/", "/docs/LICENSE", "/docs/missing.js"} {
+		rec := httptest.NewRecorder()
+		router.NewRouter(todoDB).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: unexpected status %d", target, rec.Code)
+		}
+	}
+}
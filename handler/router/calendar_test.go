@@ -0,0 +1,298 @@
+package router_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+	"github.com/TechBowl-japan/go-stations/ical"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	t.Parallel()
+
+	newRouter := func(name string) http.Handler {
+		t.Helper()
+		todoDB, err := db.NewDB(filepath.Join(t.TempDir(), name))
+		if err != nil {
+			t.Fatal("failed to create db, err =", err)
+		}
+		t.Cleanup(func() { todoDB.Close() })
+		return router.NewRouter(todoDB)
+	}
+	do := func(h http.Handler, method, target, contentType string, body io.Reader, v interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, body)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status of %s %s, given = %d, body = %s", method, target, rec.Code, rec.Body)
+		}
+		if v != nil {
+			if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+				t.Fatal("failed to decode response, err =", err)
+			}
+		}
+		return rec
+	}
+	doJSON := func(h http.Handler, method, target string, body interface{}, v interface{}) {
+		t.Helper()
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal("failed to encode request, err =", err)
+		}
+		do(h, method, target, "application/json", bytes.NewReader(b), v)
+	}
+
+	dueAt := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	todos := []map[string]interface{}{
+		{"subject": "once", "due_at": dueAt},
+		{"subject": "weekly", "due_at": dueAt, "rrule": "FREQ=WEEKLY;BYDAY=MO"},
+		{"subject": "daily in time zone", "due_at": dueAt, "rrule": "FREQ=DAILY", "timezone": "Asia/Tokyo"},
+		{"subject": "once in time zone", "due_at": dueAt, "timezone": "Europe/Berlin"},
+	}
+	h := newRouter("calendar_test.db")
+	for _, todo := range todos {
+		doJSON(h, http.MethodPost, "/todos", todo, nil)
+	}
+	var feed model.CreateCalendarFeedResponse
+	doJSON(h, http.MethodPost, "/calendar/feeds", map[string]interface{}{"name": "phone"}, &feed)
+	ics := do(h, http.MethodGet, "/todos.ics?token="+url.QueryEscape(feed.Token), "", nil, nil).Body.String()
+
+	calendar, err := ical.Decode(bytes.NewBufferString(ics))
+	if err != nil {
+		t.Fatalf("failed to parse feed, err = %v, feed = %s", err, ics)
+	}
+	var vtodos []*ical.Component
+	timezones := map[string]bool{}
+	for _, c := range calendar.Components {
+		switch c.Name {
+		case "VTODO":
+			vtodos = append(vtodos, c)
+		case "VTIMEZONE":
+			timezones[c.Prop("TZID").Value] = true
+		}
+	}
+	if len(vtodos) != len(todos) {
+		t.Fatalf("unexpected number of VTODOs, given = %d, expected = %d", len(vtodos), len(todos))
+	}
+	for _, c := range vtodos {
+		summary := c.Prop("SUMMARY").Value
+		start, due, duration, rrule := c.Prop("DTSTART"), c.Prop("DUE"), c.Prop("DURATION"), c.Prop("RRULE")
+
+		// TZID ごとに VTIMEZONE が必要（RFC 5545 3.6.5）
+		for _, p := range []*ical.Property{start, due} {
+			if p == nil {
+				continue
+			}
+			if tzid, ok := p.Params["TZID"]; ok && !timezones[tzid] {
+				t.Errorf("%s: VTIMEZONE of %s is missing", summary, tzid)
+			}
+		}
+
+		// RRULE には DTSTART が必要で、DUE と DURATION はどちらか一方だけ（RFC 5545 3.6.2）
+		if rrule != nil && start == nil {
+			t.Errorf("%s: DTSTART is required with RRULE", summary)
+		}
+		if due != nil && duration != nil {
+			t.Errorf("%s: DUE and DURATION must not occur together", summary)
+		}
+		// DUE は DTSTART より後でなければならない（RFC 5545 3.8.2.3）
+		if start != nil && due != nil {
+			s, err := ical.ParseDateTime(start, time.UTC)
+			if err != nil {
+				t.Fatalf("%s: failed to parse DTSTART, err = %v", summary, err)
+			}
+			d, err := ical.ParseDateTime(due, time.UTC)
+			if err != nil {
+				t.Fatalf("%s: failed to parse DUE, err = %v", summary, err)
+			}
+			if !d.After(s) {
+				t.Errorf("%s: DUE must be later than DTSTART, DTSTART = %s, DUE = %s", summary, s, d)
+			}
+		}
+
+		// 期限は DUE か DTSTART と DURATION のどちらかで表す
+		var given time.Time
+		switch {
+		case due != nil:
+			given, err = ical.ParseDateTime(due, time.UTC)
+		case start != nil && duration != nil:
+			if given, err = ical.ParseDateTime(start, time.UTC); err == nil {
+				given, err = ical.AddDuration(given, duration.Value)
+			}
+		default:
+			t.Errorf("%s: due is missing", summary)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: failed to parse due, err = %v", summary, err)
+		}
+		if !given.Equal(dueAt) {
+			t.Errorf("%s: unexpected due, given = %s, expected = %s", summary, given, dueAt)
+		}
+	}
+
+	// フィードをインポートすると、期限と繰り返しが元のとおりになる
+	imported := newRouter("calendar_import_test.db")
+	do(imported, http.MethodPost, "/todos/import", "text/calendar", bytes.NewBufferString(ics), nil)
+	var read model.ReadTODOResponse
+	do(imported, http.MethodGet, "/todos?size=10", "", nil, &read)
+	bySubject := map[string]*model.TODO{}
+	for _, todo := range read.TODOs {
+		bySubject[todo.Subject] = todo
+	}
+	for _, expected := range todos {
+		todo, ok := bySubject[expected["subject"].(string)]
+		rrule, _ := expected["rrule"].(string)
+		timezone, _ := expected["timezone"].(string)
+		if !ok {
+			t.Errorf("todo must be imported, expected = %v", expected)
+			continue
+		}
+		if todo.DueAt == nil || !todo.DueAt.Equal(dueAt) || todo.RRule != rrule || todo.Timezone != timezone {
+			t.Errorf("unexpected imported todo, given = %+v, expected = %v", todo, expected)
+		}
+	}
+}
+
+func TestNewRouter_CalendarFeedUser(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "calendar_feed_user_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	h := router.NewRouter(todoDB,
+		router.WithAuthUser("alice", "alice-token"),
+		router.WithAuthUser("bob", "bob-token"),
+		router.WithOpenAPIValidation(),
+	)
+
+	do := func(method, token string, body map[string]interface{}, v interface{}) int {
+		t.Helper()
+		var buf bytes.Buffer
+		if body != nil {
+			if err := json.NewEncoder(&buf).Encode(body); err != nil {
+				t.Fatal("failed to encode request, err =", err)
+			}
+		}
+		req := httptest.NewRequest(method, "/calendar/feeds", &buf)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code == http.StatusOK && v != nil {
+			if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+				t.Fatal("failed to decode response, err =", err)
+			}
+		}
+		return rec.Code
+	}
+
+	// 本文の name ではなく、認証した利用者にフィードを発行する
+	var created model.CreateCalendarFeedResponse
+	if code := do(http.MethodPost, "alice-token", map[string]interface{}{"name": "bob"}, &created); code != http.StatusOK {
+		t.Fatalf("unexpected status of creating feed, given = %d", code)
+	}
+	if created.Feed.Name != "alice" {
+		t.Errorf("feed must be issued to the authenticated user, given = %q", created.Feed.Name)
+	}
+	if code := do(http.MethodPost, "bob-token", map[string]interface{}{}, nil); code != http.StatusOK {
+		t.Errorf("name must be optional when authenticated, given = %d", code)
+	}
+
+	// ほかの利用者のフィードは一覧に現れず、取り消せない
+	for token, want := range map[string]string{"alice-token": "alice", "bob-token": "bob"} {
+		var read model.ReadCalendarFeedResponse
+		if code := do(http.MethodGet, token, nil, &read); code != http.StatusOK {
+			t.Fatalf("unexpected status of reading feeds, given = %d", code)
+		}
+		if len(read.Feeds) != 1 || read.Feeds[0].Name != want {
+			t.Errorf("only the feeds of %s must be listed, given = %+v", want, read.Feeds)
+		}
+	}
+	ids := map[string]interface{}{"ids": []int64{created.Feed.ID}}
+	if code := do(http.MethodDelete, "bob-token", ids, nil); code != http.StatusNotFound {
+		t.Errorf("feed of other user must not be found, given = %d", code)
+	}
+	if code := do(http.MethodDelete, "alice-token", ids, nil); code != http.StatusOK {
+		t.Errorf("unexpected status of revoking feed, given = %d", code)
+	}
+}
+
+func TestNewRouter_ImportCalendarChildFirst(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "calendar_child_first_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	h := router.NewRouter(todoDB)
+
+	// カレンダーのアプリは親より先に子を書くことがある
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VTODO",
+		"UID:grandchild",
+		"SUMMARY:grandchild",
+		"RELATED-TO:child",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:child",
+		"SUMMARY:child",
+		"RELATED-TO;RELTYPE=PARENT:parent",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:parent",
+		"SUMMARY:parent",
+		"END:VTODO",
+		"END:VCALENDAR",
+	}, "\r\n") + "\r\n"
+
+	req := httptest.NewRequest(http.MethodPost, "/todos/import", strings.NewReader(ics))
+	req.Header.Set("Content-Type", "text/calendar")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status, given = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/todos?size=10", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var read model.ReadTODOResponse
+	if err := json.NewDecoder(rec.Body).Decode(&read); err != nil {
+		t.Fatal("failed to decode response, err =", err)
+	}
+	bySubject := map[string]*model.TODO{}
+	for _, todo := range read.TODOs {
+		bySubject[todo.Subject] = todo
+	}
+	for child, parent := range map[string]string{"grandchild": "child", "child": "parent"} {
+		c, p := bySubject[child], bySubject[parent]
+		if c == nil || p == nil || c.ParentID == nil || *c.ParentID != p.ID {
+			t.Errorf("%s must be imported under %s, given = %+v", child, parent, read.TODOs)
+		}
+	}
+}
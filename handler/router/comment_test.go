@@ -0,0 +1,107 @@
+package router_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestNewRouter_CommentAuthor(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "comment_author_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	h := router.NewRouter(todoDB,
+		router.WithAuthUser("alice", "alice-token"),
+		router.WithAuthUser("bob", "bob-token"),
+		router.WithOpenAPIValidation(),
+	)
+
+	do := func(method, target, token string, body map[string]interface{}, v interface{}) int {
+		t.Helper()
+		var buf bytes.Buffer
+		if body != nil {
+			if err := json.NewEncoder(&buf).Encode(body); err != nil {
+				t.Fatal("failed to encode request, err =", err)
+			}
+		}
+		req := httptest.NewRequest(method, target, &buf)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code == http.StatusOK && v != nil {
+			if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+				t.Fatal("failed to decode response, err =", err)
+			}
+		}
+		return rec.Code
+	}
+
+	var todo model.CreateTODOResponse
+	if code := do(http.MethodPost, "/todos", "alice-token", map[string]interface{}{"subject": "subject"}, &todo); code != http.StatusOK {
+		t.Fatalf("unexpected status of creating todo, given = %d", code)
+	}
+	comments := "/todos/" + strconv.FormatInt(todo.TODO.ID, 10) + "/comments"
+
+	// 本文の author ではなく、認証した利用者が投稿者になる
+	var created model.CreateCommentResponse
+	if code := do(http.MethodPost, comments, "alice-token", map[string]interface{}{"author": "bob", "body": "body"}, &created); code != http.StatusOK {
+		t.Fatalf("unexpected status of creating comment, given = %d", code)
+	}
+	if created.Comment.Author != "alice" {
+		t.Errorf("author must be the authenticated user, given = %q", created.Comment.Author)
+	}
+	id := created.Comment.ID
+
+	// 順に実行し、他人による編集や削除が拒否された後も投稿者は操作できることを確かめる
+	cases := []struct {
+		name   string
+		method string
+		token  string
+		body   map[string]interface{}
+		status int
+	}{
+		{name: "Edit by other", method: http.MethodPut, token: "bob-token", body: map[string]interface{}{"id": id, "body": "edited"}, status: http.StatusForbidden},
+		{name: "Delete by other", method: http.MethodDelete, token: "bob-token", body: map[string]interface{}{"ids": []int64{id}}, status: http.StatusForbidden},
+		{name: "Edit by author", method: http.MethodPut, token: "alice-token", body: map[string]interface{}{"id": id, "body": "edited"}, status: http.StatusOK},
+		{name: "Delete by author", method: http.MethodDelete, token: "alice-token", body: map[string]interface{}{"ids": []int64{id}}, status: http.StatusOK},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var read model.ReadCommentResponse
+			before := do(http.MethodGet, comments, "alice-token", nil, &read)
+			if before != http.StatusOK {
+				t.Fatalf("unexpected status of reading comments, given = %d", before)
+			}
+
+			if code := do(c.method, comments, c.token, c.body, nil); code != c.status {
+				t.Errorf("unexpected status, given = %d, expected = %d", code, c.status)
+			}
+			if c.status != http.StatusForbidden {
+				return
+			}
+
+			var after model.ReadCommentResponse
+			do(http.MethodGet, comments, "alice-token", nil, &after)
+			if len(after.Comments) != len(read.Comments) || after.Comments[0].Body != read.Comments[0].Body {
+				t.Errorf("comment must be unchanged, given = %+v, expected = %+v", after.Comments, read.Comments)
+			}
+		})
+	}
+}
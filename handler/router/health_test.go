@@ -0,0 +1,79 @@
+package router_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+	"github.com/TechBowl-japan/go-stations/health"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestNewRouter_HealthChecks(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "health_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	reg := health.NewRegistry()
+	reg.AddLiveness("deadlock", health.CheckerFunc(func(context.Context) error { return nil }))
+	// 認証を求めても、監視からはトークンなしで呼び出せる
+	h := router.NewRouter(todoDB, router.WithHealthChecks(reg), router.WithAuthToken("secret"))
+
+	check := func(target string, wantStatus int, wantChecks map[string]string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != wantStatus {
+			t.Fatalf("%s: unexpected status, given = %d, expected = %d, body = %s", target, rec.Code, wantStatus, rec.Body)
+		}
+		var res model.HealthCheckResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatalf("%s: failed to decode response, err = %s", target, err)
+		}
+		given := map[string]string{}
+		for _, c := range res.Checks {
+			given[c.Name] = c.Status
+		}
+		if len(given) != len(wantChecks) {
+			t.Errorf("%s: unexpected checks, given = %v, expected = %v", target, given, wantChecks)
+		}
+		for name, status := range wantChecks {
+			if given[name] != status {
+				t.Errorf("%s: unexpected status of %s, given = %q, expected = %q", target, name, given[name], status)
+			}
+		}
+	}
+
+	check("/livez", http.StatusOK, map[string]string{"deadlock": model.HealthStatusOK})
+	check("/readyz", http.StatusOK, map[string]string{
+		"database":  model.HealthStatusOK,
+		"migration": model.HealthStatusOK,
+	})
+
+	reg.AddReadiness("disk", health.CheckerFunc(func(context.Context) error { return errors.New("disk full") }))
+	check("/readyz", http.StatusServiceUnavailable, map[string]string{
+		"database":  model.HealthStatusOK,
+		"migration": model.HealthStatusOK,
+		"disk":      model.HealthStatusFail,
+	})
+
+	// 終了処理中は readiness だけが失敗する
+	reg.Shutdown()
+	check("/livez", http.StatusOK, map[string]string{"deadlock": model.HealthStatusOK})
+	check("/readyz", http.StatusServiceUnavailable, map[string]string{
+		"database":  model.HealthStatusOK,
+		"migration": model.HealthStatusOK,
+		"disk":      model.HealthStatusFail,
+		"shutdown":  model.HealthStatusFail,
+	})
+}
@@ -0,0 +1,90 @@
+package router_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+)
+
+func TestNewRouter_ImportSlowUpload(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "import_slow_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	h := router.NewRouter(todoDB)
+
+	// 送り終わっていないファイルのインポートを始める
+	pr, pw := io.Pipe()
+	imported := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		req := httptest.NewRequest(http.MethodPost, "/todos/import", pr)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		imported <- rec
+	}()
+	if _, err := io.WriteString(pw, `[{"subject": "imported"}`); err != nil {
+		t.Fatal("failed to write body, err =", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// アップロードの間もほかの書き込みは待たされない
+	start := time.Now()
+	req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(`{"subject": "created"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || time.Since(start) > time.Second {
+		t.Errorf("write must not wait for the upload, status = %d, elapsed = %v", rec.Code, time.Since(start))
+	}
+
+	io.WriteString(pw, `]`)
+	pw.Close()
+	if rec := <-imported; rec.Code != http.StatusOK {
+		t.Errorf("unexpected status of import, given = %d, body = %q", rec.Code, rec.Body)
+	}
+}
+
+func TestNewRouter_ImportTooLarge(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "import_large_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	h := router.NewRouter(todoDB)
+
+	// 長さを送らないアップロードも、読み取った大きさで断る
+	body := io.MultiReader(strings.NewReader(`[`), io.LimitReader(neverEnding(' '), 33<<20), strings.NewReader(`]`))
+	req := httptest.NewRequest(http.MethodPost, "/todos/import", body)
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("unexpected status, given = %d", rec.Code)
+	}
+}
+
+// neverEnding は同じバイトを読み取り続ける io.Reader
+type neverEnding byte
+
+func (b neverEnding) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(b)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,67 @@
+package router
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// countTimeout は、収集のたびにTODOを数えるクエリの時間の上限
+const countTimeout = 5 * time.Second
+
+// todoCollector は収集のたびに状態ごとのTODOの数を数える
+type todoCollector struct {
+	svc  *service.TODOService
+	desc *prometheus.Desc
+}
+
+func newTODOCollector(svc *service.TODOService) *todoCollector {
+	return &todoCollector{
+		svc:  svc,
+		desc: prometheus.NewDesc("todos", "Number of TODOs by state.", []string{"state"}, nil),
+	}
+}
+
+func (c *todoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *todoCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+
+	total, completed, err := c.svc.CountTODOs(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(total-completed), "open")
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(completed), "completed")
+}
+
+// registerMetrics は HTTP、DB の接続プール、TODOの数のメトリクスを reg に登録し、
+// HTTP のメトリクスと、それを公開するハンドラーを返す
+func registerMetrics(reg *prometheus.Registry, todoDB *sql.DB, svc *service.TODOService) (*middleware.HTTPMetrics, http.Handler, error) {
+	httpMetrics, err := middleware.NewHTTPMetrics(reg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := reg.Register(collectors.NewDBStatsCollector(todoDB, "todo")); err != nil {
+		return nil, nil, err
+	}
+	if err := reg.Register(newTODOCollector(svc)); err != nil {
+		return nil, nil, err
+	}
+
+	// TODOを数えられなくても、ほかのメトリクスは返す
+	h := promhttp.HandlerFor(reg, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+	return httpMetrics, h, nil
+}
@@ -0,0 +1,62 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+)
+
+func TestNewRouter_Metrics(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "metrics_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+	h := router.NewRouter(todoDB, router.WithMetrics(prometheus.NewRegistry()))
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	do(http.MethodPost, "/todos", `{"subject": "subject"}`)
+	do(http.MethodGet, "/todos/1/children", "")
+	do(http.MethodGet, "/do-panic", "")
+	do(http.MethodGet, "/unknown/path", "")
+
+	rec := do(http.MethodGet, "/metrics", "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("unexpected response, status = %d, header = %v", rec.Code, rec.Header())
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		// パスパラメーターを含むパスはパターンでまとめる
+		`http_requests_total{method="POST",route="/todos",status="200"} 1`,
+		`http_requests_total{method="GET",route="/todos/",status="200"} 1`,
+		`http_requests_total{method="GET",route="/do-panic",status="500"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_count{method="POST",route="/todos",status="200"} 1`,
+		// /metrics 自身のリクエストは処理中として数える
+		`http_requests_in_flight 1`,
+		`http_panics_recovered_total{route="/do-panic"} 1`,
+		`go_sql_max_open_connections{db_name="todo"}`,
+		`todos{state="open"} 1`,
+		`todos{state="completed"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics must have %s, given = %s", want, body)
+		}
+	}
+}
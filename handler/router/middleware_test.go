@@ -0,0 +1,137 @@
+package router_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+	"github.com/TechBowl-japan/go-stations/logging"
+)
+
+func TestNewRouter_Middleware(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "middleware_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	var calls []string
+	record := func(name string) middleware.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	var logs bytes.Buffer
+	logger, err := logging.New(&logs, logging.FormatText, slog.LevelInfo)
+	if err != nil {
+		t.Fatal("failed to create logger, err =", err)
+	}
+	h := router.NewRouter(todoDB,
+		router.WithLogger(logger),
+		router.WithAuthToken("secret"),
+		router.WithCORS("https://example.com"),
+		router.WithMiddleware(record("global1"), record("global2")),
+		router.WithRouteMiddleware("/healthz", record("route")),
+		router.WithOpenAPIValidation(),
+	)
+
+	do := func(method, target string, header http.Header) *httptest.ResponseRecorder {
+		t.Helper()
+		calls = nil
+		req := httptest.NewRequest(method, target, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// すべてのエンドポイントに適用するミドルウェアの後に、エンドポイントごとのミドルウェアを呼ぶ
+	if rec := do(http.MethodGet, "/healthz", nil); rec.Code != http.StatusOK {
+		t.Errorf("unexpected status of healthz, given = %d", rec.Code)
+	}
+	if want := []string{"global1", "global2", "route"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("unexpected order, want = %v, given = %v", want, calls)
+	}
+	if rec := do(http.MethodGet, "/tags", http.Header{"Authorization": {"Bearer secret"}}); rec.Code != http.StatusOK {
+		t.Errorf("unexpected status of tags, given = %d", rec.Code)
+	}
+	if want := []string{"global1", "global2"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("route middleware must apply only to its route, want = %v, given = %v", want, calls)
+	}
+
+	// 認証に失敗したリクエストは、それより内側のミドルウェアに渡さない
+	if rec := do(http.MethodGet, "/tags", nil); rec.Code != http.StatusUnauthorized || len(calls) != 0 {
+		t.Errorf("request without token must be rejected before inner middlewares, status = %d, calls = %v", rec.Code, calls)
+	}
+
+	// preflight は認証より先に CORS で応答する
+	rec := do(http.MethodOptions, "/todos", http.Header{"Origin": {"https://example.com"}, "Access-Control-Request-Method": {http.MethodPost}})
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "https://example.com" {
+		t.Errorf("preflight must be answered without token, status = %d, header = %v", rec.Code, rec.Header())
+	}
+
+	// panic は最も外側の middleware.Recovery で recover する
+	if rec := do(http.MethodGet, "/do-panic", http.Header{"Authorization": {"Bearer secret"}, "X-Request-Id": {"panic-id"}}); rec.Code != http.StatusInternalServerError {
+		t.Errorf("panic must be recovered, given = %d", rec.Code)
+	}
+	// ログにはリクエストIDとエンドポイントのパターンが付く
+	if given := logs.String(); !strings.Contains(given, "request_id=panic-id route=/do-panic") {
+		t.Errorf("unexpected log, given = %q", given)
+	}
+}
+
+func TestNewRouter_AccessLog(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "access_log_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	var buf bytes.Buffer
+	accessLog, err := middleware.AccessLog(&buf, middleware.AccessLogJSON)
+	if err != nil {
+		t.Fatal("failed to create middleware, err =", err)
+	}
+	h := router.NewRouter(todoDB, router.WithAccessLog(accessLog), router.WithAuthToken("secret"))
+
+	req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+	req.Header.Set(middleware.RequestIDHeader, "unauthorized-id")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var entry struct {
+		RequestID string `json:"request_id"`
+		Status    int    `json:"status"`
+		Bytes     int    `json:"bytes"`
+		OS        string `json:"os"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal("failed to parse line, err =", err)
+	}
+	// バイト数は RequestID がリクエストIDを付け加えた、実際に返した本文のもの
+	if !strings.Contains(rec.Body.String(), "unauthorized-id") {
+		t.Errorf("error body must have the request ID, given = %q", rec.Body)
+	}
+	if entry.RequestID != "unauthorized-id" || entry.Status != http.StatusUnauthorized || entry.Bytes != rec.Body.Len() || entry.OS != "macOS" {
+		t.Errorf("unexpected entry, given = %+v, body = %q", entry, rec.Body)
+	}
+}
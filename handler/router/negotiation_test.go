@@ -0,0 +1,76 @@
+package router_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+)
+
+func TestNewRouter_ContentNegotiation(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "negotiation_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	// 本番と同じく、ドキュメントによる検証を通してハンドラーまで届くことも確かめる
+	h := router.NewRouter(todoDB, router.WithOpenAPIValidation())
+
+	subject, err := cbor.Marshal(map[string]interface{}{"subject": "subject"})
+	if err != nil {
+		t.Fatal("failed to encode request, err =", err)
+	}
+
+	testcases := map[string]struct {
+		method      string
+		target      string
+		accept      string
+		contentType string
+		body        []byte
+		want        int
+		wantType    string
+	}{
+		"Healthz not acceptable":   {method: http.MethodGet, target: "/healthz", accept: "application/xml", want: http.StatusNotAcceptable},
+		"Healthz CBOR":             {method: http.MethodGet, target: "/healthz", accept: "application/cbor", want: http.StatusOK, wantType: "application/cbor"},
+		"Read not acceptable":      {method: http.MethodGet, target: "/todos", accept: "text/html", want: http.StatusNotAcceptable},
+		"Read MessagePack":         {method: http.MethodGet, target: "/todos", accept: "application/msgpack", want: http.StatusOK, wantType: "application/msgpack"},
+		"Create unsupported media": {method: http.MethodPost, target: "/todos", contentType: "application/xml", body: []byte(`<request><subject>subject</subject></request>`), want: http.StatusUnsupportedMediaType},
+		"Create not acceptable":    {method: http.MethodPost, target: "/todos", accept: "text/plain", contentType: "application/json", body: []byte(`{"subject":"subject"}`), want: http.StatusNotAcceptable},
+		"Create CBOR":              {method: http.MethodPost, target: "/todos", accept: "application/cbor", contentType: "application/cbor", body: subject, want: http.StatusOK, wantType: "application/cbor"},
+		"Update unsupported media": {method: http.MethodPut, target: "/todos", contentType: "text/plain", body: []byte(`id=1`), want: http.StatusUnsupportedMediaType},
+		"Delete unsupported media": {method: http.MethodDelete, target: "/todos", contentType: "text/plain", body: []byte(`ids=1`), want: http.StatusUnsupportedMediaType},
+	}
+
+	for name, tc := range testcases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tc.method, tc.target, bytes.NewReader(tc.body))
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Fatalf("unexpected status, want = %d, given = %d, body = %s", tc.want, rec.Code, rec.Body)
+			}
+			if given := rec.Header().Get("Content-Type"); tc.wantType != "" && !strings.HasPrefix(given, tc.wantType) {
+				t.Errorf("unexpected content type, want = %s, given = %s", tc.wantType, given)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package router_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/TechBowl-japan/go-stations/blob"
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/docs"
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+)
+
+// TestOpenAPI はドキュメントにあるすべての操作を呼び出し、リクエストとレスポンスがドキュメントどおりかを検証する
+func TestOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "openapi_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal("failed to create blob store, err =", err)
+	}
+	h := router.NewRouter(todoDB, router.WithBlobStore(blobs), router.WithMetrics(prometheus.NewRegistry()),
+		router.WithOpenAPIValidation(middleware.WithResponseValidation()))
+
+	// 呼び出した操作を記録するため、ドキュメントの操作を探す
+	doc, err := openapi3.NewLoader().LoadFromData(docs.OpenAPI)
+	if err != nil {
+		t.Fatal("failed to load document, err =", err)
+	}
+	doc.Servers = nil
+	routes, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		t.Fatal("failed to create router, err =", err)
+	}
+	called := map[string]bool{}
+
+	do := func(method, target, contentType string, body io.Reader) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, body)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if route, _, err := routes.FindRoute(req); err == nil {
+			called[route.Method+" "+route.Path] = true
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code >= 300 {
+			t.Fatalf("%s %s: unexpected status %d, body = %s", method, target, rec.Code, rec.Body)
+		}
+		return rec
+	}
+	doJSON := func(method, target string, in interface{}, out interface{}) {
+		t.Helper()
+		var body io.Reader
+		contentType := ""
+		if in != nil {
+			b, err := json.Marshal(in)
+			if err != nil {
+				t.Fatal("failed to marshal request, err =", err)
+			}
+			body, contentType = bytes.NewReader(b), "application/json"
+		}
+		rec := do(method, target, contentType, body)
+		if out != nil {
+			if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+				t.Fatalf("%s %s: failed to unmarshal response, err = %v", method, target, err)
+			}
+		}
+	}
+	type idResponse struct {
+		ID int64 `json:"id"`
+	}
+
+	do(http.MethodGet, "/healthz", "", nil)
+	do(http.MethodGet, "/livez", "", nil)
+	do(http.MethodGet, "/readyz", "", nil)
+	do(http.MethodGet, "/metrics", "", nil)
+	do(http.MethodGet, "/openapi.yaml", "", nil)
+	do(http.MethodGet, "/openapi.json", "", nil)
+	do(http.MethodGet, "/docs", "", nil)
+
+	// TODO
+	var parent struct{ TODO idResponse }
+	doJSON(http.MethodPost, "/todos", map[string]interface{}{
+		"subject": "parent", "description": "- [ ] task", "tags": []string{"a"},
+		"due_at": "2030-01-01T09:00:00+09:00", "rrule": "FREQ=DAILY", "timezone": "Asia/Tokyo",
+	}, &parent)
+	p := "/todos/" + strconv.FormatInt(parent.TODO.ID, 10)
+	var children [2]struct{ TODO idResponse }
+	for i := range children {
+		doJSON(http.MethodPost, "/todos", map[string]interface{}{"subject": "child", "parent_id": parent.TODO.ID}, &children[i])
+	}
+	doJSON(http.MethodGet, "/todos?size=10&tag=a&tag_match=any&description_format=html", nil, nil)
+	doJSON(http.MethodGet, fmt.Sprintf("/todos?id=%d&id=%d", parent.TODO.ID, children[0].TODO.ID), nil, nil)
+	doJSON(http.MethodPut, "/todos", map[string]interface{}{"id": parent.TODO.ID, "subject": "updated", "completed": false}, nil)
+	doJSON(http.MethodPatch, "/todos", map[string]interface{}{"id": parent.TODO.ID, "completed": true}, nil)
+
+	// エクスポートとインポート
+	export := do(http.MethodGet, "/todos/export?format=json", "", nil)
+	do(http.MethodPost, "/todos/import?format=json&dry_run=true", "application/json", export.Body)
+
+	// カレンダー
+	var feed struct {
+		Feed  idResponse `json:"feed"`
+		Token string     `json:"token"`
+	}
+	doJSON(http.MethodPost, "/calendar/feeds", map[string]interface{}{"name": "phone"}, &feed)
+	doJSON(http.MethodGet, "/calendar/feeds", nil, nil)
+	do(http.MethodGet, "/todos.ics?token="+url.QueryEscape(feed.Token), "", nil)
+	doJSON(http.MethodDelete, "/calendar/feeds", map[string]interface{}{"ids": []int64{feed.Feed.ID}}, nil)
+
+	// サブタスク、並び順、繰り返し
+	doJSON(http.MethodGet, p+"/children?description_format=html", nil, nil)
+	doJSON(http.MethodPut, p+"/children", map[string]interface{}{"ids": []int64{children[1].TODO.ID, children[0].TODO.ID}}, nil)
+	doJSON(http.MethodPost, "/todos/"+strconv.FormatInt(children[0].TODO.ID, 10)+"/move", map[string]interface{}{"before_id": children[1].TODO.ID}, nil)
+	doJSON(http.MethodGet, p+"/occurrences?count=3", nil, nil)
+
+	// リマインダー
+	var reminder struct{ Reminder idResponse }
+	doJSON(http.MethodPost, p+"/reminders", map[string]interface{}{"before_seconds": 60}, &reminder)
+	doJSON(http.MethodGet, p+"/reminders", nil, nil)
+	doJSON(http.MethodDelete, p+"/reminders", map[string]interface{}{"ids": []int64{reminder.Reminder.ID}}, nil)
+
+	// 添付ファイル
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	fw, err := mw.CreateFormFile("file", "memo.txt")
+	if err != nil {
+		t.Fatal("failed to create form file, err =", err)
+	}
+	fw.Write([]byte("memo"))
+	mw.Close()
+	var attachments struct{ Attachments []idResponse }
+	rec := do(http.MethodPost, p+"/attachments", mw.FormDataContentType(), &form)
+	if err := json.Unmarshal(rec.Body.Bytes(), &attachments); err != nil || len(attachments.Attachments) != 1 {
+		t.Fatalf("unexpected attachments, err = %v, body = %s", err, rec.Body)
+	}
+	attachmentID := attachments.Attachments[0].ID
+	doJSON(http.MethodGet, p+"/attachments", nil, nil)
+	do(http.MethodGet, p+"/attachments/"+strconv.FormatInt(attachmentID, 10), "", nil)
+	doJSON(http.MethodDelete, p+"/attachments", map[string]interface{}{"ids": []int64{attachmentID}}, nil)
+
+	// コメント
+	var comment struct{ Comment idResponse }
+	doJSON(http.MethodPost, p+"/comments", map[string]interface{}{"author": "author", "body": "body"}, &comment)
+	doJSON(http.MethodGet, p+"/comments?size=10", nil, nil)
+	doJSON(http.MethodPut, p+"/comments", map[string]interface{}{"id": comment.Comment.ID, "body": "edited"}, nil)
+	doJSON(http.MethodDelete, p+"/comments", map[string]interface{}{"ids": []int64{comment.Comment.ID}}, nil)
+
+	doJSON(http.MethodGet, "/tags", nil, nil)
+
+	// GraphQL
+	doJSON(http.MethodGet, "/graphql?query="+url.QueryEscape("{ todos { edges { cursor } } }"), nil, nil)
+	doJSON(http.MethodPost, "/graphql", map[string]interface{}{"query": "{ todos { edges { cursor } } }"}, nil)
+
+	doJSON(http.MethodDelete, "/todos", map[string]interface{}{"ids": []int64{parent.TODO.ID}}, nil)
+
+	// 空の一覧もドキュメントどおりに返す
+	doJSON(http.MethodGet, "/todos", nil, nil)
+	doJSON(http.MethodGet, "/tags", nil, nil)
+
+	var missing []string
+	for path, item := range doc.Paths {
+		for method := range item.Operations() {
+			if !called[method+" "+path] {
+				missing = append(missing, method+" "+path)
+			}
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) != 0 {
+		t.Errorf("operations must be tested, missing = %s", strings.Join(missing, ", "))
+	}
+}
+
+func TestOpenAPI_InvalidRequest(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "openapi_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+	h := router.NewRouter(todoDB, router.WithOpenAPIValidation())
+
+	testcases := map[string]struct {
+		method      string
+		target      string
+		contentType string
+		body        string
+		want        int
+	}{
+		"InvalidQuery": {
+			method: http.MethodGet,
+			target: "/todos?size=ten",
+			want:   http.StatusBadRequest,
+		},
+		"InvalidEnum": {
+			method: http.MethodGet,
+			target: "/todos/export?format=xml",
+			want:   http.StatusBadRequest,
+		},
+		"InvalidBody": {
+			method:      http.MethodPost,
+			target:      "/todos",
+			contentType: "application/json",
+			body:        `{"subject": 1}`,
+			want:        http.StatusBadRequest,
+		},
+		"MissingRequiredProperty": {
+			method:      http.MethodPut,
+			target:      "/todos",
+			contentType: "application/json",
+			body:        `{"subject": "subject"}`,
+			want:        http.StatusBadRequest,
+		},
+		// JSON 以外の形式はハンドラーが判断する
+		"UnsupportedMediaType": {
+			method:      http.MethodPost,
+			target:      "/todos",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "subject=subject",
+			want:        http.StatusUnsupportedMediaType,
+		},
+		// ドキュメントにないエンドポイントはそのままハンドラーに渡す
+		"Undocumented": {
+			method: http.MethodGet,
+			target: "/undocumented",
+			want:   http.StatusNotFound,
+		},
+		"Valid": {
+			method:      http.MethodPost,
+			target:      "/todos",
+			contentType: "application/json",
+			body:        `{"subject": "subject"}`,
+			want:        http.StatusOK,
+		},
+	}
+
+	for name, tc := range testcases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)).WithContext(context.Background())
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("unexpected status, want = %d, given = %d, body = %s", tc.want, rec.Code, rec.Body)
+			}
+		})
+	}
+}
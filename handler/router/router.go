@@ -1,25 +1,265 @@
 package router
 
 import (
+	"context"
 	"database/sql"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"regexp"
 
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/docs"
+	"github.com/TechBowl-japan/go-stations/gql"
 	"github.com/TechBowl-japan/go-stations/handler"
 	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/health"
 	"github.com/TechBowl-japan/go-stations/service"
 )
 
-func NewRouter(todoDB *sql.DB) *http.ServeMux {
+// An Option configures the router on NewRouter.
+type Option func(*options)
+
+type options struct {
+	blobs                service.BlobStore
+	events               *service.TODOEvents
+	graphqlIntrospection bool
+	openapi              []middleware.OpenAPIOption // nil の場合は検証しない
+	authUsers            map[string]string          // トークンから利用者の名前への対応。空の場合は認証しない
+	corsOrigins          []string
+	middlewares          []middleware.Middleware
+	routeMiddlewares     map[string][]middleware.Middleware
+	logger               *slog.Logger
+	accessLog            middleware.Middleware // nil の場合はアクセスログを書かない
+	metrics              *prometheus.Registry  // nil の場合はメトリクスを公開しない
+	health               *health.Registry      // nil の場合は NewRouter で作る
+}
+
+// WithBlobStore enables the attachment endpoints storing contents in blobs.
+func WithBlobStore(blobs service.BlobStore) Option {
+	return func(o *options) {
+		o.blobs = blobs
+	}
+}
+
+// WithTODOEvents delivers the changes of TODOs made through the endpoints to the
+// watchers of events, such as the gRPC server sharing it.
+func WithTODOEvents(events *service.TODOEvents) Option {
+	return func(o *options) {
+		o.events = events
+	}
+}
+
+// WithGraphQLIntrospection enables introspection queries on the /graphql endpoint.
+func WithGraphQLIntrospection(enabled bool) Option {
+	return func(o *options) {
+		o.graphqlIntrospection = enabled
+	}
+}
+
+// WithOpenAPIValidation validates the requests against docs/openapi.yaml, and also
+// the responses with middleware.WithResponseValidation.
+func WithOpenAPIValidation(opts ...middleware.OpenAPIOption) Option {
+	return func(o *options) {
+		o.openapi = append([]middleware.OpenAPIOption{}, opts...)
+	}
+}
+
+// WithAuthToken requires token as the bearer token on every endpoint except for the
+// health checks, the documents and the calendar feed authenticated by its own token.
+// The requests with token are authenticated as middleware.DefaultUser.
+func WithAuthToken(token string) Option {
+	return WithAuthUser(middleware.DefaultUser, token)
+}
+
+// WithAuthUser is like WithAuthToken, but authenticates the requests with token as the
+// user of name, such as the author of the comments. It can be given for each user.
+func WithAuthUser(name, token string) Option {
+	return func(o *options) {
+		if o.authUsers == nil {
+			o.authUsers = map[string]string{}
+		}
+		o.authUsers[token] = name
+	}
+}
+
+// WithCORS allows the browsers on origins, or any origin with "*", to call the endpoints.
+func WithCORS(origins ...string) Option {
+	return func(o *options) {
+		o.corsOrigins = append(o.corsOrigins, origins...)
+	}
+}
+
+// WithMiddleware applies mws to every endpoint inside of the built-in middlewares.
+// The middlewares added first are the outer ones.
+func WithMiddleware(mws ...middleware.Middleware) Option {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, mws...)
+	}
+}
+
+// WithRouteMiddleware applies mws only to the endpoint registered with pattern, such as
+// "/todos", inside of the middlewares applied to every endpoint.
+func WithRouteMiddleware(pattern string, mws ...middleware.Middleware) Option {
+	return func(o *options) {
+		if o.routeMiddlewares == nil {
+			o.routeMiddlewares = map[string][]middleware.Middleware{}
+		}
+		o.routeMiddlewares[pattern] = append(o.routeMiddlewares[pattern], mws...)
+	}
+}
+
+// WithLogger makes the endpoints and the middlewares log with logger instead of
+// slog.Default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithAccessLog logs every request with accessLog, made by middleware.AccessLog.
+func WithAccessLog(accessLog middleware.Middleware) Option {
+	return func(o *options) {
+		o.accessLog = accessLog
+	}
+}
+
+// WithMetrics serves the metrics of reg on /metrics in the Prometheus text format, after
+// registering the metrics of the requests, the connection pool of the *sql.DB and the
+// TODOs to reg. reg must not be shared with another router.
+func WithMetrics(reg *prometheus.Registry) Option {
+	return func(o *options) {
+		o.metrics = reg
+	}
+}
+
+// WithHealthChecks serves the liveness and readiness checks of reg on /livez and /readyz,
+// after adding the readiness checks of the *sql.DB to reg, so that the caller can add
+// its own checks and make the readiness fail with reg.Shutdown on graceful shutdown.
+func WithHealthChecks(reg *health.Registry) Option {
+	return func(o *options) {
+		o.health = reg
+	}
+}
+
+// publicPaths は WithAuthToken や WithAuthUser でも認証を求めないパス
+var publicPaths = []string{"/healthz", "/livez", "/readyz", "/todos.ics", "/docs", "/docs/swagger-ui-bundle.js", "/docs/swagger-ui.css", "/openapi.yaml", "/openapi.json"}
+
+// NewRouter returns http.ServeMux serving every endpoint through the middlewares below,
+// the first of which is the outermost:
+//
+//  1. middleware.Logger with WithLogger
+//  2. the access log with WithAccessLog, logging the bodies as sent with the request ID
+//  3. middleware.RequestID, so that every log line and error response has the request ID
+//  4. middleware.Route, so that the log records and the metrics have the endpoint
+//  5. middleware.Tracing, starting the span of the request continuing the traceparent header
+//  6. middleware.UserAgentMiddleware
+//  7. the metrics of the requests with WithMetrics
+//  8. middleware.Recovery, recovering panics also in the other middlewares
+//  9. middleware.CORS with WithCORS, answering preflight requests before the authentication
+//  10. middleware.AuthUsers with WithAuthToken or WithAuthUser
+//  11. the middlewares of WithMiddleware
+//  12. the OpenAPI validation with WithOpenAPIValidation
+//  13. the middlewares of WithRouteMiddleware for the endpoint
+func NewRouter(todoDB *sql.DB, opts ...Option) *http.ServeMux {
+	o := options{logger: slog.Default()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// register routes
 	mux := http.NewServeMux()
+	handle := func(pattern string, h http.Handler) {
+		// エンドポイントごとのミドルウェアは、すべてのエンドポイントに適用するものの内側に置く
+		mux.Handle(pattern, middleware.NewChain(o.routeMiddlewares[pattern]...).Then(h))
+	}
 
 	// HealthzHandlerのエンドポイントを登録
 	healthzHandler := handler.NewHealthzHandler() // HealthzHandlerのインスタンスを作成
-	mux.Handle("/healthz", healthzHandler)        // /healthz のエンドポイントに healthzHandler を割り当て
+	handle("/healthz", healthzHandler)            // /healthz のエンドポイントに healthzHandler を割り当て
+
+	// 依存先まで確かめる liveness と readiness のチェックを登録
+	healthChecks := o.health
+	if healthChecks == nil {
+		healthChecks = health.NewRegistry()
+	}
+	healthChecks.AddReadiness("database", health.Ping(todoDB))
+	healthChecks.AddReadiness("migration", health.CheckerFunc(func(ctx context.Context) error {
+		return db.CheckSchemaVersion(ctx, todoDB)
+	}))
+	handle("/livez", handler.NewHealthCheckHandler(healthChecks.Live))
+	handle("/readyz", handler.NewHealthCheckHandler(healthChecks.Ready))
+
+	// 添付ファイルの保存先がある場合は、TODOの削除時に添付ファイルも削除する
+	todoOpts := []service.TODOServiceOption{service.WithLogger(o.logger)}
+	if o.blobs != nil {
+		todoOpts = append(todoOpts, service.WithBlobStore(o.blobs))
+	}
+	if o.events != nil {
+		todoOpts = append(todoOpts, service.WithEvents(o.events))
+	}
+
+	todoService := service.NewTODOService(todoDB, todoOpts...) // TODOServiceのインスタンスを作成
+	todoHandler := handler.NewTODOHandler(todoService)         // TODOHandlerのインスタンスを作成
+	handle("/todos", todoHandler)
+	handle("/todos/export", handler.NewTODOExportHandler(todoService))
+	handle("/todos/import", handler.NewTODOImportHandler(todoService))
+
+	// カレンダーアプリ向けの iCalendar フィードと、その秘密のURLを発行するエンドポイントを登録
+	calendarFeedService := service.NewCalendarFeedService(todoDB) // CalendarFeedServiceのインスタンスを作成
+	handle("/todos.ics", handler.NewTODOCalendarHandler(todoService, calendarFeedService))
+	handle("/calendar/feeds", handler.NewCalendarFeedHandler(calendarFeedService))
 
-	todoService := service.NewTODOService(todoDB)      // TODOServiceのインスタンスを作成
-	todoHandler := handler.NewTODOHandler(todoService) // TODOHandlerのインスタンスを作成
-	mux.Handle("/todos", todoHandler)
+	// /todos/{id}/{subresource} のエンドポイントを登録
+	todoItemMux := handler.NewTODOItemMux()
+	todoItemMux.Handle("children", handler.NewTODOChildrenHandler(todoService))
+	todoItemMux.Handle("move", handler.NewTODOMoveHandler(todoService))
+	todoItemMux.Handle("occurrences", handler.NewTODOOccurrencesHandler(todoService))
+
+	reminderService := service.NewReminderService(todoDB) // ReminderServiceのインスタンスを作成
+	todoItemMux.Handle("reminders", handler.NewReminderHandler(reminderService))
+
+	commentService := service.NewCommentService(todoDB) // CommentServiceのインスタンスを作成
+	todoItemMux.Handle("comments", handler.NewCommentHandler(commentService))
+
+	if o.blobs != nil {
+		attachmentService := service.NewAttachmentService(todoDB, o.blobs) // AttachmentServiceのインスタンスを作成
+		todoItemMux.HandleSubtree("attachments", handler.NewAttachmentHandler(attachmentService))
+	}
+	handle("/todos/", todoItemMux)
+
+	// REST と同じサービスで TODO とコメントを GraphQL でも提供する
+	graphqlHandler, err := gql.NewHandler(todoService, commentService, gql.WithIntrospection(o.graphqlIntrospection))
+	if err != nil {
+		// スキーマは埋め込まれているので、失敗するのはスキーマと resolver が食い違うときだけ
+		panic(err)
+	}
+	handle("/graphql", graphqlHandler)
+
+	tagService := service.NewTagService(todoDB)     // TagServiceのインスタンスを作成
+	tagHandler := handler.NewTagHandler(tagService) // TagHandlerのインスタンスを作成
+	handle("/tags", tagHandler)
+
+	// 監視のためのメトリクスを提供する
+	var httpMetrics *middleware.HTTPMetrics
+	if o.metrics != nil {
+		var metricsHandler http.Handler
+		httpMetrics, metricsHandler, err = registerMetrics(o.metrics, todoDB, todoService)
+		if err != nil {
+			// 登録に失敗するのは、同じ Registry を複数のルーターで使ったときだけ
+			panic(err)
+		}
+		handle("/metrics", metricsHandler)
+	}
+
+	// ドキュメントとそれを表示するページを提供する。ドキュメントには実際に登録したエンドポイントだけを載せる
+	apiDocsHandler := handler.NewAPIDocsHandler(docs.OpenAPI, docs.UI, docs.UIFiles, registeredIn(mux))
+	handle("/openapi.yaml", apiDocsHandler)
+	handle("/openapi.json", apiDocsHandler)
+	handle("/docs", apiDocsHandler)
+	handle("/docs/", apiDocsHandler)
 
 	// 必ずpanicを発生させるHandler
 	/*mux.Handle("/do-panic", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -29,7 +269,68 @@ func NewRouter(todoDB *sql.DB) *http.ServeMux {
 	panicHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		panic("intentional panic")
 	})
-	mux.Handle("/do-panic", middleware.Recovery(panicHandler))
+	// panic はすべてのエンドポイントに適用する middleware.Recovery で recover する
+	handle("/do-panic", panicHandler)
+
+	// 先頭が最も外側になる。panic のログとエラーにもリクエストIDを付けるよう、Recovery はその内側に置く
+	global := middleware.NewChain(middleware.Logger(o.logger))
+	if o.accessLog != nil {
+		// アクセスログのバイト数には、RequestID がエラーの本文に付け加えるリクエストIDも含める
+		global = global.Append(o.accessLog)
+	}
+	global = global.Append(middleware.RequestID, middleware.Route(mux), middleware.Tracing, middleware.UserAgentMiddleware)
+	if httpMetrics != nil {
+		// panic から recover した 500 も数え、recover した回数も記録する
+		global = global.Append(httpMetrics.Middleware, middleware.RecoveryWith(httpMetrics.PanicRecovered))
+	} else {
+		global = global.Append(middleware.Recovery)
+	}
+	if len(o.corsOrigins) != 0 {
+		// preflight には認証情報が付かないので、認証より先に応答する
+		global = global.Append(middleware.CORS(o.corsOrigins...))
+	}
+	if len(o.authUsers) != 0 {
+		global = global.Append(middleware.AuthUsers(o.authUsers, publicPaths...))
+	}
+	global = global.Append(o.middlewares...)
+
+	if o.openapi != nil {
+		// ドキュメントと食い違わないよう、すべてのエンドポイントの前で検証する
+		validator, err := middleware.NewOpenAPIValidator(docs.OpenAPI, o.openapi...)
+		if err != nil {
+			// ドキュメントは埋め込まれているので、失敗するのはドキュメントが不正なときだけ
+			panic(err)
+		}
+		global = global.Append(validator.Middleware)
+	}
+
+	root := http.NewServeMux()
+	root.Handle("/", global.Then(mux))
+	return root
+}
+
+// pathParam はドキュメントのパスにあるパスパラメーター
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
 
-	return mux
+// registeredIn は、ドキュメントのパスを処理するハンドラーが mux に登録されているかを返す関数を返す。
+// TODOItemMux のように登録したハンドラーがさらにパスで振り分ける場合は、その先までたどる
+func registeredIn(mux *http.ServeMux) func(path string) bool {
+	type finder interface {
+		Handler(r *http.Request) (h http.Handler, pattern string)
+	}
+	return func(path string) bool {
+		// パスパラメーターには正しい形式の値を入れる
+		r := &http.Request{Method: http.MethodGet, URL: &url.URL{Path: pathParam.ReplaceAllString(path, "1")}}
+		var h http.Handler = mux
+		for {
+			f, ok := h.(finder)
+			if !ok {
+				return true
+			}
+			var pattern string
+			if h, pattern = f.Handler(r); pattern == "" {
+				return false
+			}
+		}
+	}
 }
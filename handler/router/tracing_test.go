@@ -0,0 +1,97 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+)
+
+// TestNewRouter_Tracing は traceparent のトレースを引き継ぎ、ハンドラー、サービス、SQL のスパンを作ることを確認する。
+// プロセス全体の TracerProvider を設定するので、このパッケージでは他のテストで設定しない
+func TestNewRouter_Tracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "tracing_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+	h := router.NewRouter(todoDB)
+
+	testcases := map[string]struct {
+		method      string
+		operation   string
+		body        string
+		traceparent string
+		wantParent  string
+		wantSpans   []string
+	}{
+		"Create": {
+			method:      http.MethodPost,
+			operation:   "CreateTODO",
+			body:        `{"subject": "subject"}`,
+			traceparent: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+			wantParent:  "b7ad6b7169203331",
+			wantSpans:   []string{"POST /todos", "decode request", "TODOHandler.CreateTODO", "TODOService.CreateTODO", "sql.conn.exec", "encode response"},
+		},
+		"Read": {
+			method:      http.MethodGet,
+			operation:   "ReadTODO",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantParent:  "00f067aa0ba902b7",
+			wantSpans:   []string{"GET /todos", "TODOHandler.ReadTODO", "TODOService.ReadTODO", "sql.conn.query", "encode response"},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/todos", strings.NewReader(tc.body))
+			req.Header.Set("traceparent", tc.traceparent)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("unexpected status, given = %d, body = %s", rec.Code, rec.Body)
+			}
+
+			traceID := strings.Split(tc.traceparent, "-")[1]
+			spans := map[string]sdktrace.ReadOnlySpan{}
+			for _, s := range exporter.GetSpans().Snapshots() {
+				if s.SpanContext().TraceID().String() == traceID {
+					spans[s.Name()] = s
+				}
+			}
+			for _, name := range tc.wantSpans {
+				if spans[name] == nil {
+					t.Errorf("span %q must be recorded in the trace of traceparent, given = %v", name, spans)
+				}
+			}
+
+			// リクエストのスパンは呼び出し元のスパンの子になり、ほかのスパンはその内側に入る
+			server := spans[tc.wantSpans[0]]
+			if server == nil {
+				return
+			}
+			if server.SpanKind() != trace.SpanKindServer || server.Parent().SpanID().String() != tc.wantParent || !server.Parent().IsRemote() {
+				t.Errorf("unexpected server span, kind = %v, parent = %v", server.SpanKind(), server.Parent())
+			}
+			handler, service := spans["TODOHandler."+tc.operation], spans["TODOService."+tc.operation]
+			if handler == nil || handler.Parent().SpanID() != server.SpanContext().SpanID() {
+				t.Error("handler span must be the child of the server span")
+			}
+			if service == nil || handler == nil || service.Parent().SpanID() != handler.SpanContext().SpanID() {
+				t.Error("service span must be the child of the handler span")
+			}
+		})
+	}
+}
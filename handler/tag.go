@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// A TagHandler implements handling REST endpoints of tags.
+type TagHandler struct {
+	svc *service.TagService
+}
+
+// NewTagHandler returns TagHandler based http.Handler.
+func NewTagHandler(svc *service.TagService) *TagHandler {
+	return &TagHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *TagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// タグの一覧を使用数と共に取得
+	tags, err := h.svc.ReadTags(r.Context())
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(model.ReadTagResponse{Tags: tags}); err != nil {
+		http.Error(w, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -2,162 +2,23 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/TechBowl-japan/go-stations/model"
 	"github.com/TechBowl-japan/go-stations/service"
 )
 
-func (h *TODOHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodPost:
-		// CreateTODORequest に JSON Decode
-		var req model.CreateTODORequest
-		err := json.NewDecoder(r.Body).Decode(&req)
-		if err != nil {
-			http.Error(w, "Bad Request", http.StatusBadRequest)
-			return
-		}
-
-		// subject が空文字列の場合を判定
-		if req.Subject == "" {
-			http.Error(w, "Bad Request: subject is required", http.StatusBadRequest)
-			return
-		}
-
-		// CreateTODO メソッドを呼び出し
-		todo, err := h.svc.CreateTODO(r.Context(), req.Subject, req.Description)
-		if err != nil {
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
-		}
-
-		// CreateTODOResponse に代入し、JSON Encode を行い HTTP Response を返す
-		if todo != nil {
-			resp := model.CreateTODOResponse{TODO: *todo} // ポインタから値を取り出す
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(resp)
-		} else {
-			// todoがnilの場合の適切なエラーハンドリング
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		}
-	case http.MethodPut:
-		// UpdateTODORequest に JSON Decode
-		var req model.UpdateTODORequest
-		err := json.NewDecoder(r.Body).Decode(&req)
-		if err != nil {
-			http.Error(w, "Bad Request", http.StatusBadRequest)
-			return
-		}
-
-		// id が 0 の場合や subject が空文字列の場合を判定
-		if req.ID == 0 || req.Subject == "" {
-			http.Error(w, "Bad Request: ID and subject are required", http.StatusBadRequest)
-			return
-		}
-
-		todo, err := h.svc.UpdateTODO(r.Context(), req.ID, req.Subject, req.Description)
-		if err != nil {
-			var notFound *model.ErrNotFound
-			if errors.As(err, &notFound) {
-				http.Error(w, "Not Found", http.StatusNotFound)
-			} else {
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			}
-			return
-		}
-		// 更新成功時のレスポンスを返す
-		resp := model.UpdateTODOResponse{TODO: *todo}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(resp)
-	case http.MethodGet:
-		// クエリパラメータからprev_idとsizeを取得し、整数に変換
-		prevIDStr := r.URL.Query().Get("prev_id")
-		sizeStr := r.URL.Query().Get("size")
-		var prevID int64 = 0 // デフォルト値として0を設定
-		var size int64 = 10  // デフォルト値として10を設定（例）
-
-		if prevIDStr != "" {
-			var err error
-			prevID, err = strconv.ParseInt(prevIDStr, 10, 64)
-			if err != nil {
-				http.Error(w, "Invalid prev_id parameter", http.StatusBadRequest)
-				return
-			}
-		}
-
-		if sizeStr != "" {
-			var err error
-			size, err = strconv.ParseInt(sizeStr, 10, 64)
-			if err != nil {
-				http.Error(w, "Invalid size parameter", http.StatusBadRequest)
-				return
-			}
-		}
-
-		// ReadTODOメソッドを呼び出してTODOリストを取得
-		todos, err := h.svc.ReadTODO(r.Context(), prevID, size)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		// レスポンスにContent-Typeを設定
-		w.Header().Set("Content-Type", "application/json")
-		// 取得したTODOリストをエンコードしてレスポンスとして返す
-		if err := json.NewEncoder(w).Encode(map[string]interface{}{"todos": todos}); err != nil {
-			http.Error(w, "Error encoding response", http.StatusInternalServerError)
-			return
-		}
-
-	case http.MethodDelete:
-		// DeleteTODORequestにJSON Decode
-		var req model.DeleteTODORequest
-		err := json.NewDecoder(r.Body).Decode(&req)
-		if err != nil {
-			http.Error(w, "Bad Request", http.StatusBadRequest)
-			return
-		}
-
-		// idのリストが空の場合を判定
-		if len(req.IDs) == 0 {
-			http.Error(w, "Bad Request: ids are required", http.StatusBadRequest)
-			return
-		}
-
-		// DeleteTODOメソッドを呼び出し
-		err = h.svc.DeleteTODO(r.Context(), req.IDs)
-		if err != nil {
-			// ErrNotFoundが返却された場合は404 NotFoundとしてHTTP Responseを返す
-			var notFound *model.ErrNotFound
-			if errors.As(err, &notFound) {
-				http.Error(w, "Not Found", http.StatusNotFound)
-			} else {
-				// その他のエラーの場合は500 Internal Server Errorを返す
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			}
-			return
-		}
-
-		// 削除できた場合は、DeleteTODOResponseを作成し、JSON Encodeを行いHTTP Responseを返す
-		resp := model.DeleteTODOResponse{}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(resp)
-	default:
-		// PUTとPOST以外のメソッドに対してはMethod Not Allowedを返す
-		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-	}
-}
+// /todos の TODOServer と、それにリクエストを渡す serveTODOServer は docs/openapi.yaml から todo.gen.go に生成する
+//go:generate go run ../cmd/openapigen -spec ../docs/openapi.yaml -tag todos -kind server -package handler -model github.com/TechBowl-japan/go-stations/model -server TODOServer -o todo.gen.go
 
 // A TODOHandler implements handling REST endpoints.
 type TODOHandler struct {
 	svc *service.TODOService
 }
 
+var _ TODOServer = (*TODOHandler)(nil)
+
 // NewTODOHandler returns TODOHandler based http.Handler.
 func NewTODOHandler(svc *service.TODOService) *TODOHandler {
 	return &TODOHandler{
@@ -165,26 +26,154 @@ func NewTODOHandler(svc *service.TODOService) *TODOHandler {
 	}
 }
 
-// Create handles the endpoint that creates the TODO.
-func (h *TODOHandler) Create(ctx context.Context, req *model.CreateTODORequest) (*model.CreateTODOResponse, error) {
-	_, _ = h.svc.CreateTODO(ctx, "", "")
-	return &model.CreateTODOResponse{}, nil
+// ServeHTTP implements http.Handler interface.
+func (h *TODOHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveTODOServer(h, w, r)
 }
 
-// Read handles the endpoint that reads the TODOs.
-func (h *TODOHandler) Read(ctx context.Context, req *model.ReadTODORequest) (*model.ReadTODOResponse, error) {
-	_, _ = h.svc.ReadTODO(ctx, 0, 0)
-	return &model.ReadTODOResponse{}, nil
+// ReadTODO handles the endpoint that reads the TODOs.
+func (h *TODOHandler) ReadTODO(ctx context.Context, req *model.ReadTODORequest) (*model.ReadTODOResponse, error) {
+	ctx, span := tracer.Start(ctx, "TODOHandler.ReadTODO")
+	defer span.End()
+
+	// tag（複数指定可）と tag_match で絞り込み条件を指定
+	opts := []service.ReadOption{service.WithTagFilter(req.Tags, req.TagMatch), service.WithIDFilter(req.IDs)}
+	if req.DescriptionFormat == "html" {
+		opts = append(opts, service.WithDescriptionHTML())
+	}
+
+	todos, err := h.svc.ReadTODO(ctx, req.PrevID, req.Size, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ReadTODOResponse{TODOs: todos}, nil
 }
 
-// Update handles the endpoint that updates the TODO.
-func (h *TODOHandler) Update(ctx context.Context, req *model.UpdateTODORequest) (*model.UpdateTODOResponse, error) {
-	_, _ = h.svc.UpdateTODO(ctx, 0, "", "")
-	return &model.UpdateTODOResponse{}, nil
+// CreateTODO handles the endpoint that creates the TODO.
+func (h *TODOHandler) CreateTODO(ctx context.Context, req *model.CreateTODORequest) (*model.CreateTODOResponse, error) {
+	ctx, span := tracer.Start(ctx, "TODOHandler.CreateTODO")
+	defer span.End()
+
+	// subject が空文字列の場合を判定
+	if req.Subject == "" {
+		return nil, &model.ErrInvalidArgument{Field: "subject", Reason: "is required"}
+	}
+
+	opts := []service.TODOOption{service.WithTags(req.Tags), service.WithParent(req.ParentID)}
+	if req.DueAt != nil {
+		opts = append(opts, service.WithDueAt(*req.DueAt))
+	}
+	if req.RRule != "" || req.Timezone != "" {
+		opts = append(opts, service.WithRRule(req.RRule), service.WithTimezone(req.Timezone))
+	}
+
+	todo, err := h.svc.CreateTODO(ctx, req.Subject, req.Description, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if todo == nil {
+		return nil, errors.New("created todo is nil")
+	}
+	return &model.CreateTODOResponse{TODO: *todo}, nil
 }
 
-// Delete handles the endpoint that deletes the TODOs.
-func (h *TODOHandler) Delete(ctx context.Context, req *model.DeleteTODORequest) (*model.DeleteTODOResponse, error) {
-	_ = h.svc.DeleteTODO(ctx, nil)
+// UpdateTODO handles the endpoint that updates the TODO.
+func (h *TODOHandler) UpdateTODO(ctx context.Context, req *model.UpdateTODORequest) (*model.UpdateTODOResponse, error) {
+	ctx, span := tracer.Start(ctx, "TODOHandler.UpdateTODO")
+	defer span.End()
+
+	// id が 0 の場合や subject が空文字列の場合を判定
+	if req.ID == 0 {
+		return nil, &model.ErrInvalidArgument{Field: "id", Reason: "is required"}
+	}
+	if req.Subject == "" {
+		return nil, &model.ErrInvalidArgument{Field: "subject", Reason: "is required"}
+	}
+
+	// subject と description 以外は PATCH と同じく省略された項目を変更しない
+	todo, err := h.patchTODO(ctx, &model.PatchTODORequest{
+		ID:          req.ID,
+		Subject:     &req.Subject,
+		Description: &req.Description,
+		Tags:        req.Tags,
+		ParentID:    req.ParentID,
+		Completed:   req.Completed,
+		DueAt:       req.DueAt,
+		RRule:       req.RRule,
+		Timezone:    req.Timezone,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.UpdateTODOResponse{TODO: *todo}, nil
+}
+
+// PatchTODO handles the endpoint that updates the given fields of the TODO.
+func (h *TODOHandler) PatchTODO(ctx context.Context, req *model.PatchTODORequest) (*model.PatchTODOResponse, error) {
+	ctx, span := tracer.Start(ctx, "TODOHandler.PatchTODO")
+	defer span.End()
+
+	if req.ID == 0 {
+		return nil, &model.ErrInvalidArgument{Field: "id", Reason: "is required"}
+	}
+	if req.Subject != nil && *req.Subject == "" {
+		return nil, &model.ErrInvalidArgument{Field: "subject", Reason: "must not be empty"}
+	}
+
+	todo, err := h.patchTODO(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &model.PatchTODOResponse{TODO: *todo}, nil
+}
+
+// patchTODO は省略された項目を変更せずに TODO を更新する
+func (h *TODOHandler) patchTODO(ctx context.Context, req *model.PatchTODORequest) (*model.TODO, error) {
+	// 現在の値を読んで書き戻すと並行した更新を消してしまうため、省略された項目はサービスのトランザクションの中で残す
+	opts := []service.TODOOption{service.WithTags(req.Tags)}
+	var subject, description string
+	if req.Subject != nil {
+		subject = *req.Subject
+	} else {
+		opts = append(opts, service.KeepSubject())
+	}
+	if req.Description != nil {
+		description = *req.Description
+	} else {
+		opts = append(opts, service.KeepDescription())
+	}
+	if req.ParentID != nil {
+		opts = append(opts, service.WithParent(*req.ParentID))
+	}
+	if req.Completed != nil {
+		opts = append(opts, service.WithCompleted(*req.Completed))
+	}
+	if req.DueAt != nil {
+		opts = append(opts, service.WithDueAt(*req.DueAt))
+	}
+	if req.RRule != nil {
+		opts = append(opts, service.WithRRule(*req.RRule))
+	}
+	if req.Timezone != nil {
+		opts = append(opts, service.WithTimezone(*req.Timezone))
+	}
+
+	return h.svc.UpdateTODO(ctx, req.ID, subject, description, opts...)
+}
+
+// DeleteTODO handles the endpoint that deletes the TODOs.
+func (h *TODOHandler) DeleteTODO(ctx context.Context, req *model.DeleteTODORequest) (*model.DeleteTODOResponse, error) {
+	ctx, span := tracer.Start(ctx, "TODOHandler.DeleteTODO")
+	defer span.End()
+
+	// idのリストが空の場合を判定
+	if len(req.IDs) == 0 {
+		return nil, &model.ErrInvalidArgument{Field: "ids", Reason: "is required"}
+	}
+
+	// ErrNotFound の場合は 404 を返す
+	if err := h.svc.DeleteTODO(ctx, req.IDs); err != nil {
+		return nil, err
+	}
 	return &model.DeleteTODOResponse{}, nil
 }
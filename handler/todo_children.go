@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// A TODOChildrenHandler implements handling REST endpoints of subtasks.
+type TODOChildrenHandler struct {
+	svc *service.TODOService
+}
+
+// NewTODOChildrenHandler returns TODOChildrenHandler based http.Handler.
+func NewTODOChildrenHandler(svc *service.TODOService) *TODOChildrenHandler {
+	return &TODOChildrenHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *TODOChildrenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := todoIDFromContext(r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		var opts []service.ReadOption
+		if html, err := wantsDescriptionHTML(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if html {
+			opts = append(opts, service.WithDescriptionHTML())
+		}
+
+		// サブタスクを並び順どおりに取得
+		todos, err := h.svc.ReadChildren(r.Context(), id, opts...)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(model.ReadTODOChildrenResponse{TODOs: todos}); err != nil {
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodPut:
+		// ReorderTODOChildrenRequest に JSON Decode
+		var req model.ReorderTODOChildrenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.svc.ReorderChildren(r.Context(), id, req.IDs); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		// 並び替え後のサブタスクを返す
+		todos, err := h.svc.ReadChildren(r.Context(), id)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model.ReadTODOChildrenResponse{TODOs: todos})
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// wantsDescriptionHTML は description_format=html で説明の HTML が要求されているかを返す
+func wantsDescriptionHTML(r *http.Request) (bool, error) {
+	switch r.URL.Query().Get("description_format") {
+	case "":
+		return false, nil
+	case "html":
+		return true, nil
+	default:
+		return false, errors.New("Invalid description_format parameter")
+	}
+}
+
+// writeServiceError はサービス層のエラーを対応する HTTP status code で返す
+func writeServiceError(w http.ResponseWriter, err error) {
+	var (
+		notFound  *model.ErrNotFound
+		invalid   *model.ErrInvalidArgument
+		forbidden *model.ErrForbidden
+	)
+	switch {
+	case errors.As(err, &notFound):
+		http.Error(w, "Not Found", http.StatusNotFound)
+	case errors.As(err, &forbidden):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	case errors.As(err, &invalid):
+		http.Error(w, "Bad Request: "+invalid.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,425 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// エクスポートとインポートのファイル形式
+const (
+	formatCSV    = "csv"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+)
+
+// csvHeader は CSV の列。インポートでは subject 以外の列は省略できる
+var csvHeader = []string{"id", "subject", "description", "tags", "parent_id", "completed_at", "due_at", "rrule", "timezone", "created_at", "updated_at"}
+
+// csvTagSeparator は CSV の tags 列でタグを区切る文字
+const csvTagSeparator = ";"
+
+// maxImportSize はインポートで受け付けるファイルの大きさの上限
+const maxImportSize = 32 << 20
+
+// A TODOExportHandler implements handling the endpoint exporting all TODOs.
+type TODOExportHandler struct {
+	svc *service.TODOService
+}
+
+// NewTODOExportHandler returns TODOExportHandler based http.Handler.
+func NewTODOExportHandler(svc *service.TODOService) *TODOExportHandler {
+	return &TODOExportHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *TODOExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatJSON
+	}
+
+	contentTypes := map[string]string{
+		formatCSV:    "text/csv; charset=utf-8",
+		formatJSON:   "application/json",
+		formatNDJSON: "application/x-ndjson",
+	}
+	contentType, ok := contentTypes[format]
+	if !ok {
+		http.Error(w, "Invalid format parameter", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "todos." + format}))
+
+	var (
+		write  func(*model.TODO) error
+		finish func() error
+	)
+	switch format {
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		// 最初の行はヘッダー
+		cw.Write(csvHeader)
+		write = func(todo *model.TODO) error {
+			cw.Write(todoToCSV(todo))
+			return cw.Error()
+		}
+		finish = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case formatJSON:
+		// 配列全体をメモリに載せないよう、要素を1つずつ書き出す
+		enc := json.NewEncoder(w)
+		sep := "["
+		write = func(todo *model.TODO) error {
+			if _, err := io.WriteString(w, sep); err != nil {
+				return err
+			}
+			sep = ","
+			return enc.Encode(todo)
+		}
+		finish = func() error {
+			if sep == "[" {
+				_, err := io.WriteString(w, "[]\n")
+				return err
+			}
+			_, err := io.WriteString(w, "]\n")
+			return err
+		}
+	case formatNDJSON:
+		enc := json.NewEncoder(w)
+		write = func(todo *model.TODO) error { return enc.Encode(todo) }
+		finish = func() error { return nil }
+	}
+
+	// 書き出し始めた後はステータスコードを変えられないので、エラーはログに残して打ち切る
+	started := false
+	err := h.svc.ExportTODO(r.Context(), func(todo *model.TODO) error {
+		started = true
+		return write(todo)
+	})
+	if err != nil {
+		logging.Error(r.Context(), "failed to export todos", "err", err)
+		if !started {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+	if err := finish(); err != nil {
+		logging.Error(r.Context(), "failed to export todos", "err", err)
+	}
+}
+
+// A TODOImportHandler implements handling the endpoint importing TODOs.
+type TODOImportHandler struct {
+	svc *service.TODOService
+}
+
+// NewTODOImportHandler returns TODOImportHandler based http.Handler.
+func NewTODOImportHandler(svc *service.TODOService) *TODOImportHandler {
+	return &TODOImportHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *TODOImportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// format の指定がない場合は Content-Type から判定する
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			format = formatCSV
+		case "application/x-ndjson":
+			format = formatNDJSON
+		case "text/calendar":
+			format = formatICS
+		default:
+			format = formatJSON
+		}
+	}
+
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "Invalid dry_run parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	switch format {
+	case formatCSV, formatJSON, formatNDJSON, formatICS:
+	default:
+		http.Error(w, "Invalid format parameter", http.StatusBadRequest)
+		return
+	}
+
+	// インポートのトランザクションは書き込みのロックを取るので、転送の遅いアップロードの間に
+	// ほかの書き込みを止めないよう、トランザクションを始める前にファイルを一時ファイルへ受け取る
+	if r.ContentLength > maxImportSize {
+		http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	body, err := os.CreateTemp("", "todo-import-*")
+	if err != nil {
+		logging.Error(r.Context(), "failed to create temporary file", "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		body.Close()
+		os.Remove(body.Name())
+	}()
+	if _, err := io.Copy(body, http.MaxBytesReader(w, r.Body, maxImportSize)); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		logging.Error(r.Context(), "failed to rewind temporary file", "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	var next func() (*model.TODO, error)
+	switch format {
+	case formatCSV:
+		next, err = csvTODOReader(body)
+	case formatJSON:
+		next, err = jsonTODOReader(body)
+	case formatNDJSON:
+		next = ndjsonTODOReader(body)
+	case formatICS:
+		next, err = icsTODOReader(body)
+	}
+	if err != nil {
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.svc.ImportTODO(r.Context(), next, dryRun)
+	if err != nil {
+		var syntax *importSyntaxError
+		if errors.As(err, &syntax) {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	// 不正な行がある場合は何も作成していない
+	w.Header().Set("Content-Type", "application/json")
+	if len(result.Errors) > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// importSyntaxError はファイルの形式が壊れていて、以降の行を読み取れない場合のエラー
+type importSyntaxError struct {
+	err error
+}
+
+func (e *importSyntaxError) Error() string { return e.err.Error() }
+
+func (e *importSyntaxError) Unwrap() error { return e.err }
+
+// jsonTODOReader はTODOの配列の JSON から要素を1つずつ読み取る関数を返す
+func jsonTODOReader(r io.Reader) (func() (*model.TODO, error), error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, errors.New("JSON must be an array of TODOs")
+	}
+
+	return func() (*model.TODO, error) {
+		if !dec.More() {
+			if _, err := dec.Token(); err != nil {
+				return nil, &importSyntaxError{err: err}
+			}
+			return nil, io.EOF
+		}
+		return decodeTODO(dec)
+	}, nil
+}
+
+// ndjsonTODOReader は1行に1つのTODOの JSON を読み取る関数を返す
+func ndjsonTODOReader(r io.Reader) func() (*model.TODO, error) {
+	dec := json.NewDecoder(r)
+	return func() (*model.TODO, error) {
+		if !dec.More() {
+			return nil, io.EOF
+		}
+		return decodeTODO(dec)
+	}
+}
+
+// decodeTODO は JSON の値を1つTODOとして読み取る。型が合わない値はその行だけの誤りとして扱う
+func decodeTODO(dec *json.Decoder) (*model.TODO, error) {
+	var todo model.TODO
+	err := dec.Decode(&todo)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return nil, &model.ErrInvalidArgument{Field: typeErr.Field, Reason: "must be " + typeErr.Type.String()}
+	}
+	var timeErr *time.ParseError
+	if errors.As(err, &timeErr) {
+		return nil, &model.ErrInvalidArgument{Field: "time", Reason: timeErr.Error()}
+	}
+	if err != nil {
+		return nil, &importSyntaxError{err: err}
+	}
+	return &todo, nil
+}
+
+// csvTODOReader はヘッダー付きの CSV から1行ずつTODOを読み取る関数を返す
+func csvTODOReader(r io.Reader) (func() (*model.TODO, error), error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["subject"]; !ok {
+		return nil, errors.New("CSV header must have subject column")
+	}
+
+	return func() (*model.TODO, error) {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, &importSyntaxError{err: err}
+		}
+		if len(record) != len(header) {
+			return nil, &model.ErrInvalidArgument{Field: "row", Reason: fmt.Sprintf("must have %d fields", len(header))}
+		}
+		get := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return record[i]
+			}
+			return ""
+		}
+		return todoFromCSV(get)
+	}, nil
+}
+
+// todoToCSV はTODOを csvHeader の順の値にする
+func todoToCSV(todo *model.TODO) []string {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	parentID := ""
+	if todo.ParentID != nil {
+		parentID = strconv.FormatInt(*todo.ParentID, 10)
+	}
+	return []string{
+		strconv.FormatInt(todo.ID, 10), todo.Subject, todo.Description, strings.Join(todo.Tags, csvTagSeparator), parentID,
+		formatTime(todo.CompletedAt), formatTime(todo.DueAt), todo.RRule, todo.Timezone,
+		formatTime(&todo.CreatedAt), formatTime(&todo.UpdatedAt),
+	}
+}
+
+// todoFromCSV は列名から値を返す get を使って1行分のTODOを読み取る
+func todoFromCSV(get func(name string) string) (*model.TODO, error) {
+	todo := model.TODO{
+		Subject:     get("subject"),
+		Description: get("description"),
+		RRule:       get("rrule"),
+		Timezone:    get("timezone"),
+	}
+
+	parseID := func(name string) (int64, error) {
+		id, err := strconv.ParseInt(get(name), 10, 64)
+		if err != nil {
+			return 0, &model.ErrInvalidArgument{Field: name, Reason: "must be an integer"}
+		}
+		return id, nil
+	}
+	parseTime := func(name string) (*time.Time, error) {
+		t, err := time.Parse(time.RFC3339, get(name))
+		if err != nil {
+			return nil, &model.ErrInvalidArgument{Field: name, Reason: "must be RFC 3339 date-time"}
+		}
+		return &t, nil
+	}
+
+	var err error
+	if get("id") != "" {
+		if todo.ID, err = parseID("id"); err != nil {
+			return nil, err
+		}
+	}
+	if get("parent_id") != "" {
+		parentID, err := parseID("parent_id")
+		if err != nil {
+			return nil, err
+		}
+		todo.ParentID = &parentID
+	}
+	if tags := get("tags"); tags != "" {
+		todo.Tags = strings.Split(tags, csvTagSeparator)
+	}
+	if get("completed_at") != "" {
+		if todo.CompletedAt, err = parseTime("completed_at"); err != nil {
+			return nil, err
+		}
+	}
+	if get("due_at") != "" {
+		if todo.DueAt, err = parseTime("due_at"); err != nil {
+			return nil, err
+		}
+	}
+	for name, dst := range map[string]*time.Time{"created_at": &todo.CreatedAt, "updated_at": &todo.UpdatedAt} {
+		if get(name) == "" {
+			continue
+		}
+		t, err := parseTime(name)
+		if err != nil {
+			return nil, err
+		}
+		*dst = *t
+	}
+	return &todo, nil
+}
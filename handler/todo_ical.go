@@ -0,0 +1,344 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/ical"
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// formatICS は iCalendar のファイル形式
+const formatICS = "ics"
+
+// icalProdID は出力する iCalendar の PRODID
+const icalProdID = "-//TechBowl-japan//go-stations//JA"
+
+// icalUIDSuffix は TODO の ID から VTODO の UID を作るための接尾辞
+const icalUIDSuffix = "@go-stations"
+
+// A TODOCalendarHandler implements handling the iCalendar feed of TODOs.
+type TODOCalendarHandler struct {
+	svc   *service.TODOService
+	feeds *service.CalendarFeedService
+}
+
+// NewTODOCalendarHandler returns TODOCalendarHandler based http.Handler.
+func NewTODOCalendarHandler(svc *service.TODOService, feeds *service.CalendarFeedService) *TODOCalendarHandler {
+	return &TODOCalendarHandler{
+		svc:   svc,
+		feeds: feeds,
+	}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *TODOCalendarHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// 発行していないトークンの場合はフィードの存在を明かさない
+	feed, err := h.feeds.FeedByToken(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	// フィードのトークンは利用者ごとに発行するので、ログにはその利用者を残す
+	r = r.WithContext(logging.ContextWithAttrs(r.Context(), slog.String("user", feed.Name)))
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+
+	now := time.Now()
+	enc := ical.NewEncoder(w)
+	enc.Begin("VCALENDAR")
+	enc.WriteProperty(&ical.Property{Name: "VERSION", Value: "2.0"})
+	enc.WriteProperty(&ical.Property{Name: "PRODID", Value: icalProdID})
+
+	// TZID ごとに VTIMEZONE が必要なので（RFC 5545 3.6.5）、使ったタイムゾーンと期限の範囲を集める
+	zones := map[string]*icalZone{}
+	err = h.svc.ExportTODO(r.Context(), func(todo *model.TODO) error {
+		if loc := todoLocation(todo); loc != nil {
+			zone, ok := zones[loc.String()]
+			if !ok {
+				zone = &icalZone{loc: loc, from: *todo.DueAt, to: *todo.DueAt}
+				zones[loc.String()] = zone
+			}
+			if todo.DueAt.Before(zone.from) {
+				zone.from = *todo.DueAt
+			}
+			if todo.DueAt.After(zone.to) {
+				zone.to = *todo.DueAt
+			}
+		}
+		return enc.Encode(todoToVTODO(todo, now))
+	})
+	if err != nil {
+		logging.Error(r.Context(), "failed to write calendar feed", "err", err)
+		return
+	}
+	// VTODO は読み取りながら書き出すので、VTIMEZONE はその後ろにまとめる。
+	// 繰り返しの先の回も変わり目に従うよう、最後の期限から icalZoneYears 年分の変わり目を含める
+	tzids := make([]string, 0, len(zones))
+	for tzid := range zones {
+		tzids = append(tzids, tzid)
+	}
+	sort.Strings(tzids)
+	for _, tzid := range tzids {
+		zone := zones[tzid]
+		enc.Encode(ical.Timezone(zone.loc, zone.from, zone.to.AddDate(icalZoneYears, 0, 0)))
+	}
+	enc.End("VCALENDAR")
+	if err := enc.Flush(); err != nil {
+		logging.Error(r.Context(), "failed to write calendar feed", "err", err)
+	}
+}
+
+// todoToVTODO はTODOを VTODO コンポーネントにする
+func todoToVTODO(todo *model.TODO, now time.Time) *ical.Component {
+	c := &ical.Component{Name: "VTODO"}
+	c.Add("UID", todoUID(todo.ID))
+	c.Add("DTSTAMP", ical.FormatDateTime(now))
+	c.Add("CREATED", ical.FormatDateTime(todo.CreatedAt))
+	c.Add("LAST-MODIFIED", ical.FormatDateTime(todo.UpdatedAt))
+	c.Add("SUMMARY", ical.EscapeText(todo.Subject))
+	if todo.Description != "" {
+		c.Add("DESCRIPTION", ical.EscapeText(todo.Description))
+	}
+
+	if todo.DueAt != nil {
+		// 繰り返しはタイムゾーンでの時刻で計算するので、タイムゾーンがある場合はその時刻で出力する
+		var due *ical.Property
+		if loc := todoLocation(todo); loc != nil {
+			due = &ical.Property{Name: "DUE", Params: map[string]string{"TZID": loc.String()}, Value: todo.DueAt.In(loc).Format("20060102T150405")}
+		} else {
+			due = &ical.Property{Name: "DUE", Value: ical.FormatDateTime(*todo.DueAt)}
+		}
+		if todo.RRule != "" {
+			// RRULE には DTSTART が必要だが、DUE は DTSTART より後でなければならない（RFC 5545 3.8.2.3）。
+			// 繰り返しを期限から数えるため、期限を DTSTART とし、期限までの DURATION を0にする
+			due.Name = "DTSTART"
+			c.Props = append(c.Props, due)
+			c.Add("DURATION", "PT0S")
+			c.Add("RRULE", todo.RRule)
+		} else {
+			c.Props = append(c.Props, due)
+		}
+	}
+
+	if todo.CompletedAt != nil {
+		c.Add("STATUS", "COMPLETED")
+		c.Add("COMPLETED", ical.FormatDateTime(*todo.CompletedAt))
+	} else {
+		c.Add("STATUS", "NEEDS-ACTION")
+	}
+
+	if len(todo.Tags) > 0 {
+		tags := make([]string, len(todo.Tags))
+		for i, tag := range todo.Tags {
+			tags[i] = ical.EscapeText(tag)
+		}
+		c.Add("CATEGORIES", strings.Join(tags, ","))
+	}
+	if todo.ParentID != nil {
+		c.Add("RELATED-TO", todoUID(*todo.ParentID))
+	}
+	return c
+}
+
+// icalZoneYears は VTIMEZONE に含める、最後の期限より後の年数
+const icalZoneYears = 10
+
+// icalZone はフィードで使ったタイムゾーンと、その期限の範囲
+type icalZone struct {
+	loc      *time.Location
+	from, to time.Time
+}
+
+// todoLocation は期限を TZID 付きで出力するタイムゾーンを返す。UTC で出力する場合は nil を返す
+func todoLocation(todo *model.TODO) *time.Location {
+	if todo.DueAt == nil || todo.Timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(todo.Timezone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// todoUID はTODOの VTODO の UID を返す
+func todoUID(id int64) string {
+	return fmt.Sprintf("todo-%d%s", id, icalUIDSuffix)
+}
+
+// icsTODOReader は iCalendar の VTODO を1つずつTODOとして読み取る関数を返す。
+// RELATED-TO で親を指定された VTODO は、同じファイルの親の VTODO の下に作成する。
+func icsTODOReader(r io.Reader) (func() (*model.TODO, error), error) {
+	calendar, err := ical.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	if calendar.Name != "VCALENDAR" {
+		return nil, errors.New("iCalendar must be VCALENDAR")
+	}
+
+	var vtodos []*ical.Component
+	for _, c := range calendar.Components {
+		if c.Name == "VTODO" {
+			vtodos = append(vtodos, c)
+		}
+	}
+
+	// カレンダーのアプリは親を先に書くとは限らないので、親が子より前に並ぶよう並べ替える
+	vtodos = sortVTODOsByParent(vtodos)
+
+	// 行のIDは VTODO の順番とし、UID から親の行を引けるようにする
+	rows := map[string]int64{}
+	for i, c := range vtodos {
+		if uid := c.Prop("UID"); uid != nil {
+			rows[uid.Value] = int64(i + 1)
+		}
+	}
+
+	i := 0
+	return func() (*model.TODO, error) {
+		if i == len(vtodos) {
+			return nil, io.EOF
+		}
+		i++
+		todo, err := vtodoToTODO(vtodos[i-1], rows)
+		if err != nil {
+			return nil, err
+		}
+		todo.ID = int64(i)
+		return todo, nil
+	}, nil
+}
+
+// sortVTODOsByParent は RELATED-TO の親が子より前に並ぶよう、ファイルの順番をなるべく保って並べ替える。
+// 親子の関係が循環している場合はいずれかの親が子より後になり、インポートで不正な行として報告する
+func sortVTODOsByParent(vtodos []*ical.Component) []*ical.Component {
+	byUID := map[string]*ical.Component{}
+	for _, c := range vtodos {
+		if uid := c.Prop("UID"); uid != nil {
+			byUID[uid.Value] = c
+		}
+	}
+
+	sorted := make([]*ical.Component, 0, len(vtodos))
+	visited := map[*ical.Component]bool{}
+	var visit func(c *ical.Component)
+	visit = func(c *ical.Component) {
+		if visited[c] {
+			return
+		}
+		visited[c] = true
+		if parent, ok := byUID[vtodoParentUID(c)]; ok {
+			visit(parent)
+		}
+		sorted = append(sorted, c)
+	}
+	for _, c := range vtodos {
+		visit(c)
+	}
+	return sorted
+}
+
+// vtodoParentUID は VTODO の RELATED-TO で指定された親の UID を返す。親がない場合は空文字列を返す
+func vtodoParentUID(c *ical.Component) string {
+	p := c.Prop("RELATED-TO")
+	if p == nil || (p.Params["RELTYPE"] != "" && !strings.EqualFold(p.Params["RELTYPE"], "PARENT")) {
+		return ""
+	}
+	return p.Value
+}
+
+// vtodoToTODO は VTODO コンポーネントをTODOにする
+func vtodoToTODO(c *ical.Component, rows map[string]int64) (*model.TODO, error) {
+	var todo model.TODO
+
+	parseTime := func(name string) (*time.Time, error) {
+		p := c.Prop(name)
+		if p == nil {
+			return nil, nil
+		}
+		t, err := ical.ParseDateTime(p, time.Local)
+		if err != nil {
+			return nil, &model.ErrInvalidArgument{Field: strings.ToLower(name), Reason: err.Error()}
+		}
+		return &t, nil
+	}
+
+	if p := c.Prop("SUMMARY"); p != nil {
+		todo.Subject = ical.UnescapeText(p.Value)
+	}
+	if p := c.Prop("DESCRIPTION"); p != nil {
+		todo.Description = ical.UnescapeText(p.Value)
+	}
+
+	var err error
+	if todo.DueAt, err = parseTime("DUE"); err != nil {
+		return nil, err
+	}
+	if p := c.Prop("DUE"); p != nil {
+		todo.Timezone = p.Params["TZID"]
+	}
+	// DUE の代わりに DTSTART と DURATION で期限を表すこともできる
+	if p, duration := c.Prop("DTSTART"), c.Prop("DURATION"); todo.DueAt == nil && p != nil && duration != nil {
+		start, err := parseTime("DTSTART")
+		if err != nil {
+			return nil, err
+		}
+		due, err := ical.AddDuration(*start, duration.Value)
+		if err != nil {
+			return nil, &model.ErrInvalidArgument{Field: "duration", Reason: err.Error()}
+		}
+		todo.DueAt = &due
+		todo.Timezone = p.Params["TZID"]
+	}
+	if p := c.Prop("RRULE"); p != nil {
+		todo.RRule = p.Value
+	}
+
+	for name, dst := range map[string]*time.Time{"CREATED": &todo.CreatedAt, "LAST-MODIFIED": &todo.UpdatedAt} {
+		t, err := parseTime(name)
+		if err != nil {
+			return nil, err
+		}
+		if t != nil {
+			*dst = *t
+		}
+	}
+
+	if todo.CompletedAt, err = parseTime("COMPLETED"); err != nil {
+		return nil, err
+	}
+	if p := c.Prop("STATUS"); p != nil && strings.EqualFold(p.Value, "COMPLETED") && todo.CompletedAt == nil {
+		now := time.Now()
+		todo.CompletedAt = &now
+	}
+
+	for _, p := range c.Props {
+		if p.Name == "CATEGORIES" {
+			todo.Tags = append(todo.Tags, ical.SplitText(p.Value)...)
+		}
+	}
+
+	// ファイルに含まれない親は指定しない
+	if uid := vtodoParentUID(c); uid != "" {
+		if row, ok := rows[uid]; ok {
+			todo.ParentID = &row
+		}
+	}
+
+	return &todo, nil
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 独自の型を定義
+type contextKey string
+
+// コンテキストに格納するためのキーとして使用する独自の型の変数を定義
+var (
+	contextKeyTODOID  = contextKey("TODOID")
+	contextKeySubPath = contextKey("SubPath")
+)
+
+// A TODOItemMux dispatches /todos/{id}/{subresource} endpoints to the registered handlers.
+type TODOItemMux struct {
+	handlers map[string]http.Handler
+	subtrees map[string]bool
+}
+
+// NewTODOItemMux returns TODOItemMux based http.Handler.
+func NewTODOItemMux() *TODOItemMux {
+	return &TODOItemMux{
+		handlers: map[string]http.Handler{},
+		subtrees: map[string]bool{},
+	}
+}
+
+// Handle registers the handler for /todos/{id}/{name}.
+func (m *TODOItemMux) Handle(name string, h http.Handler) {
+	m.handlers[name] = h
+}
+
+// HandleSubtree registers the handler for /todos/{id}/{name} and the paths under it.
+func (m *TODOItemMux) HandleSubtree(name string, h http.Handler) {
+	m.handlers[name] = h
+	m.subtrees[name] = true
+}
+
+// Handler returns the handler for r and its pattern like http.ServeMux.Handler.
+// If no handler is registered for the path of r, it returns nil and an empty pattern.
+func (m *TODOItemMux) Handler(r *http.Request) (h http.Handler, pattern string) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/todos/"), "/", 2)
+	if len(parts) != 2 {
+		return nil, ""
+	}
+	h, name, _, ok := m.lookup(parts[1])
+	if !ok {
+		return nil, ""
+	}
+	pattern = "/todos/{id}/" + name
+	if m.subtrees[name] {
+		pattern += "/"
+	}
+	return h, pattern
+}
+
+// lookup は {id} より後ろのパスを処理するハンドラーと、{name} およびそれより後ろのパスを返す
+func (m *TODOItemMux) lookup(rest string) (h http.Handler, name, subPath string, ok bool) {
+	// {name} より後ろのパスは HandleSubtree で登録したハンドラーにのみ渡す
+	name = rest
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		name, subPath = name[:i], name[i+1:]
+		if !m.subtrees[name] {
+			return nil, "", "", false
+		}
+	}
+
+	h, ok = m.handlers[name]
+	return h, name, subPath, ok
+}
+
+// ServeHTTP implements http.Handler interface.
+func (m *TODOItemMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// /todos/{id}/{name} を id と name に分割
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/todos/"), "/", 2)
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid TODO id", http.StatusBadRequest)
+		return
+	}
+
+	h, _, subPath, ok := m.lookup(parts[1])
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// 独自の型のキーを使用してTODOのIDをコンテキストに格納
+	ctx := context.WithValue(r.Context(), contextKeyTODOID, id)
+	ctx = context.WithValue(ctx, contextKeySubPath, subPath)
+	h.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// todoIDFromContext は TODOItemMux がコンテキストに格納したTODOのIDを取り出す
+func todoIDFromContext(ctx context.Context) int64 {
+	id, _ := ctx.Value(contextKeyTODOID).(int64)
+	return id
+}
+
+// subPathFromContext は TODOItemMux がコンテキストに格納した /todos/{id}/{name}/ より後ろのパスを取り出す
+func subPathFromContext(ctx context.Context) string {
+	subPath, _ := ctx.Value(contextKeySubPath).(string)
+	return subPath
+}
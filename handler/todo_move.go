@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// A TODOMoveHandler implements handling the endpoint that changes the order of TODOs.
+type TODOMoveHandler struct {
+	svc *service.TODOService
+}
+
+// NewTODOMoveHandler returns TODOMoveHandler based http.Handler.
+func NewTODOMoveHandler(svc *service.TODOService) *TODOMoveHandler {
+	return &TODOMoveHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *TODOMoveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// MoveTODORequest に JSON Decode
+	var req model.MoveTODORequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	todo, err := h.svc.MoveTODO(r.Context(), todoIDFromContext(r.Context()), req.BeforeID, req.AfterID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(model.MoveTODOResponse{TODO: *todo})
+}
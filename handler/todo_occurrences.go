@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// A TODOOccurrencesHandler implements handling the endpoint that previews upcoming occurrences of a recurring TODO.
+type TODOOccurrencesHandler struct {
+	svc *service.TODOService
+}
+
+// NewTODOOccurrencesHandler returns TODOOccurrencesHandler based http.Handler.
+func NewTODOOccurrencesHandler(svc *service.TODOService) *TODOOccurrencesHandler {
+	return &TODOOccurrencesHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *TODOOccurrencesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// クエリパラメータからcountを取得し、整数に変換
+	count := 5 // デフォルト値として5を設定
+	if countStr := r.URL.Query().Get("count"); countStr != "" {
+		var err error
+		count, err = strconv.Atoi(countStr)
+		if err != nil {
+			http.Error(w, "Invalid count parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	occurrences, err := h.svc.ReadOccurrences(r.Context(), todoIDFromContext(r.Context()), count)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(model.ReadTODOOccurrencesResponse{Occurrences: occurrences})
+}
@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// errDiskSpaceUnsupported は空き容量を調べられない環境で freeBytes が返すエラー
+var errDiskSpaceUnsupported = errors.New("disk space is not supported on this platform")
+
+// Ping returns Checker pinging db, failing if the connection cannot be made in time.
+func Ping(db *sql.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	})
+}
+
+// DiskSpace returns Checker failing if the file system of dir has less than minFree bytes
+// available, such as the directory of the database file. It always passes on the
+// platforms where the space cannot be read.
+func DiskSpace(dir string, minFree uint64) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		free, err := freeBytes(dir)
+		if errors.Is(err, errDiskSpaceUnsupported) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("%d bytes available in %s, less than %d bytes", free, dir, minFree)
+		}
+		return nil
+	})
+}
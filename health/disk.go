@@ -0,0 +1,16 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package health
+
+import "syscall"
+
+// freeBytes は dir のファイルシステムで一般ユーザーが使える空き容量を返す
+func freeBytes(dir string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	// Bavail と Bsize の型は OS によって異なる
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
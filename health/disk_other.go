@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd)
+// +build !linux,!darwin,!freebsd
+
+package health
+
+// freeBytes は Statfs の使えない OS では空き容量を調べない
+func freeBytes(dir string) (uint64, error) {
+	return 0, errDiskSpaceUnsupported
+}
@@ -0,0 +1,165 @@
+// Package health runs the liveness and readiness checks of the server.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// DefaultTimeout is the time limit of each check without WithTimeout.
+const DefaultTimeout = 2 * time.Second
+
+// errShuttingDown は終了処理中に readiness のチェックが返すエラー
+var errShuttingDown = errors.New("server is shutting down")
+
+// A Checker checks a dependency of the server, returning the reason if it is not usable.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// The CheckerFunc type is an adapter to allow the use of ordinary functions as Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx).
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// check は名前を付けて登録した Checker
+type check struct {
+	name    string
+	checker Checker
+}
+
+// A Registry holds the checks of liveness, whether the process should be restarted, and
+// readiness, whether the server can take requests. It is safe for concurrent use.
+type Registry struct {
+	timeout time.Duration
+
+	mu        sync.RWMutex
+	liveness  []check
+	readiness []check
+
+	shuttingDown int32 // 0 以外になったら readiness は失敗する
+}
+
+// An Option configures Registry on NewRegistry.
+type Option func(*Registry)
+
+// WithTimeout limits each check to d instead of DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(r *Registry) {
+		r.timeout = d
+	}
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry(opts ...Option) *Registry {
+	r := &Registry{timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// AddLiveness adds checker of name to the liveness checks. A failing liveness check means
+// that the process cannot recover by itself, so add only the checks of such states.
+func (r *Registry) AddLiveness(name string, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.liveness = append(r.liveness, check{name: name, checker: checker})
+}
+
+// AddReadiness adds checker of name to the readiness checks.
+func (r *Registry) AddReadiness(name string, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readiness = append(r.readiness, check{name: name, checker: checker})
+}
+
+// Shutdown makes the readiness checks fail from now on, so that load balancers stop
+// sending requests before the server stops accepting them.
+func (r *Registry) Shutdown() {
+	atomic.StoreInt32(&r.shuttingDown, 1)
+}
+
+// Live runs the liveness checks.
+func (r *Registry) Live(ctx context.Context) *model.HealthCheckResponse {
+	r.mu.RLock()
+	checks := append([]check{}, r.liveness...)
+	r.mu.RUnlock()
+	return r.run(ctx, checks)
+}
+
+// Ready runs the readiness checks, adding the failing "shutdown" check after Shutdown.
+func (r *Registry) Ready(ctx context.Context) *model.HealthCheckResponse {
+	r.mu.RLock()
+	checks := append([]check{}, r.readiness...)
+	r.mu.RUnlock()
+
+	if atomic.LoadInt32(&r.shuttingDown) != 0 {
+		checks = append(checks, check{name: "shutdown", checker: CheckerFunc(func(context.Context) error {
+			return errShuttingDown
+		})})
+	}
+	return r.run(ctx, checks)
+}
+
+// run はチェックを並行して実行し、登録した順に結果を並べる
+func (r *Registry) run(ctx context.Context, checks []check) *model.HealthCheckResponse {
+	resp := &model.HealthCheckResponse{Status: model.HealthStatusOK, Checks: make([]*model.HealthCheckResult, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			resp.Checks[i] = r.runCheck(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, result := range resp.Checks {
+		if result.Status != model.HealthStatusOK {
+			resp.Status = model.HealthStatusFail
+		}
+	}
+	return resp
+}
+
+// runCheck は1つのチェックを時間の上限を付けて実行する
+func (r *Registry) runCheck(ctx context.Context, c check) *model.HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		// チェックがコンテキストを無視しても、時間の上限で結果を返す
+		errc <- c.checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", r.timeout)
+	}
+
+	result := &model.HealthCheckResult{
+		Name:       c.name,
+		Status:     model.HealthStatusOK,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = model.HealthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/health"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	pass := health.CheckerFunc(func(context.Context) error { return nil })
+	fail := health.CheckerFunc(func(context.Context) error { return errors.New("broken") })
+	// コンテキストを無視して終わらないチェックも、時間の上限で失敗にする
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	hang := health.CheckerFunc(func(context.Context) error {
+		<-done
+		return nil
+	})
+
+	cases := map[string]struct {
+		readiness []health.Checker
+		shutdown  bool
+		status    string
+		results   []string
+	}{
+		"No checks":     {status: model.HealthStatusOK, results: []string{}},
+		"Pass":          {readiness: []health.Checker{pass, pass}, status: model.HealthStatusOK, results: []string{model.HealthStatusOK, model.HealthStatusOK}},
+		"Fail":          {readiness: []health.Checker{pass, fail}, status: model.HealthStatusFail, results: []string{model.HealthStatusOK, model.HealthStatusFail}},
+		"Timeout":       {readiness: []health.Checker{hang}, status: model.HealthStatusFail, results: []string{model.HealthStatusFail}},
+		"Shutting down": {readiness: []health.Checker{pass}, shutdown: true, status: model.HealthStatusFail, results: []string{model.HealthStatusOK, model.HealthStatusFail}},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			reg := health.NewRegistry(health.WithTimeout(50 * time.Millisecond))
+			for i, checker := range c.readiness {
+				reg.AddReadiness(string(rune('a'+i)), checker)
+			}
+			if c.shutdown {
+				reg.Shutdown()
+			}
+
+			// Live には readiness のチェックも終了処理も影響しない
+			if live := reg.Live(context.Background()); live.Status != model.HealthStatusOK || len(live.Checks) != 0 {
+				t.Errorf("unexpected liveness, given = %+v", live)
+			}
+
+			res := reg.Ready(context.Background())
+			if res.Status != c.status {
+				t.Errorf("unexpected status, given = %q, expected = %q", res.Status, c.status)
+			}
+			if len(res.Checks) != len(c.results) {
+				t.Fatalf("unexpected number of checks, given = %d, expected = %d", len(res.Checks), len(c.results))
+			}
+			for i, result := range res.Checks {
+				if result.Status != c.results[i] {
+					t.Errorf("unexpected status of %s, given = %q, expected = %q", result.Name, result.Status, c.results[i])
+				}
+				if (result.Status == model.HealthStatusFail) != (result.Error != "") {
+					t.Errorf("error of %s must be set only on failure, given = %q", result.Name, result.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestDiskSpace(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("disk space is not checked on windows")
+	}
+
+	dir := t.TempDir()
+	if err := health.DiskSpace(dir, 0).Check(context.Background()); err != nil {
+		t.Error("unexpected error, err =", err)
+	}
+	if err := health.DiskSpace(dir, math.MaxUint64).Check(context.Background()); err == nil {
+		t.Error("expected error for insufficient disk space")
+	}
+	if err := health.DiskSpace(dir+"/nothing", 0).Check(context.Background()); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
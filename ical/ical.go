@@ -0,0 +1,436 @@
+// Package ical reads and writes iCalendar (RFC 5545) content.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A Component is an iCalendar component such as VCALENDAR or VTODO.
+type Component struct {
+	Name       string
+	Props      []*Property
+	Components []*Component
+}
+
+// A Property is a content line of a component.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Prop returns the first property of name, or nil if the component does not have it.
+func (c *Component) Prop(name string) *Property {
+	for _, p := range c.Props {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Add appends a property of name and value.
+func (c *Component) Add(name, value string) *Property {
+	p := &Property{Name: name, Value: value}
+	c.Props = append(c.Props, p)
+	return p
+}
+
+// maxLineLength は改行を除いた1行の最大のバイト数（RFC 5545 3.1）
+const maxLineLength = 75
+
+// An Encoder writes iCalendar content lines to an output stream.
+type Encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewEncoder returns a new Encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Begin writes the BEGIN line of the component of name.
+func (e *Encoder) Begin(name string) error {
+	return e.WriteProperty(&Property{Name: "BEGIN", Value: name})
+}
+
+// End writes the END line of the component of name.
+func (e *Encoder) End(name string) error {
+	return e.WriteProperty(&Property{Name: "END", Value: name})
+}
+
+// Encode writes the component with its properties and sub-components.
+func (e *Encoder) Encode(c *Component) error {
+	e.Begin(c.Name)
+	for _, p := range c.Props {
+		e.WriteProperty(p)
+	}
+	for _, sub := range c.Components {
+		e.Encode(sub)
+	}
+	return e.End(c.Name)
+}
+
+// WriteProperty writes the property as a content line folded at 75 octets.
+func (e *Encoder) WriteProperty(p *Property) error {
+	if e.err != nil {
+		return e.err
+	}
+
+	var line strings.Builder
+	line.WriteString(p.Name)
+	for _, name := range sortedKeys(p.Params) {
+		line.WriteString(";" + name + "=" + quoteParam(p.Params[name]))
+	}
+	line.WriteString(":" + p.Value)
+
+	// 長い行は UTF-8 の文字の途中で区切らないよう折り返す
+	s := line.String()
+	limit := maxLineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		e.writeString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = maxLineLength - 1 // 折り返した行の先頭の空白の分
+	}
+	e.writeString(s + "\r\n")
+	return e.err
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (e *Encoder) Flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	e.err = e.w.Flush()
+	return e.err
+}
+
+func (e *Encoder) writeString(s string) {
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
+
+// Decode reads the first component, such as VCALENDAR, from r.
+func Decode(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var stack []*Component
+	for i, line := range lines {
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("ical: line %d: %w", i+1, err)
+		}
+
+		switch p.Name {
+		case "BEGIN":
+			c := &Component{Name: strings.ToUpper(p.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, fmt.Errorf("ical: line %d: unexpected END:%s", i+1, p.Value)
+			}
+			if len(stack) == 1 {
+				return stack[0], nil
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("ical: line %d: property outside of component", i+1)
+			}
+			c := stack[len(stack)-1]
+			c.Props = append(c.Props, p)
+		}
+	}
+	return nil, errors.New("ical: component is not closed")
+}
+
+// unfold は折り返された行をつなげて content line のリストにする
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine は "NAME;PARAM=VALUE:value" の形式の content line を読み取る
+func parseLine(line string) (*Property, error) {
+	p := &Property{}
+
+	// 名前は ';' か ':' まで。パラメーターの値は '"' で囲まれていれば ':' や ';' を含められる
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return nil, errors.New("missing property name")
+	}
+	p.Name = strings.ToUpper(line[:i])
+	rest := line[i:]
+
+	for rest != "" && rest[0] == ';' {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid parameter of %s", p.Name)
+		}
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+
+		var value string
+		if rest != "" && rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated parameter of %s", p.Name)
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.IndexAny(rest, ";:")
+			if end < 0 {
+				return nil, fmt.Errorf("missing value of %s", p.Name)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+		if p.Params == nil {
+			p.Params = map[string]string{}
+		}
+		p.Params[name] = value
+	}
+
+	if rest == "" || rest[0] != ':' {
+		return nil, fmt.Errorf("missing value of %s", p.Name)
+	}
+	p.Value = rest[1:]
+	return p, nil
+}
+
+// EscapeText escapes s as a TEXT value.
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// UnescapeText unescapes a TEXT value.
+func UnescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+// SplitText splits a TEXT list value such as CATEGORIES at unescaped commas and unescapes the items.
+func SplitText(s string) []string {
+	var (
+		items []string
+		item  strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			item.WriteString(s[i : i+2])
+			i++
+		case s[i] == ',':
+			items = append(items, UnescapeText(item.String()))
+			item.Reset()
+		default:
+			item.WriteByte(s[i])
+		}
+	}
+	return append(items, UnescapeText(item.String()))
+}
+
+// dateTimeUTC は UTC の DATE-TIME の形式
+const dateTimeUTC = "20060102T150405Z"
+
+// FormatDateTime formats t as a DATE-TIME value in UTC.
+func FormatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeUTC)
+}
+
+// ParseDateTime parses the DATE-TIME or DATE value of the property. A value with
+// TZID is in that time zone, and a floating value or a DATE is in loc.
+func ParseDateTime(p *Property, loc *time.Location) (time.Time, error) {
+	if tzid, ok := p.Params["TZID"]; ok {
+		tz, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("ical: unknown TZID %q", tzid)
+		}
+		loc = tz
+	}
+
+	switch {
+	case p.Params["VALUE"] == "DATE" || len(p.Value) == len("20060102"):
+		return time.ParseInLocation("20060102", p.Value, loc)
+	case strings.HasSuffix(p.Value, "Z"):
+		return time.Parse(dateTimeUTC, p.Value)
+	default:
+		return time.ParseInLocation("20060102T150405", p.Value, loc)
+	}
+}
+
+// timezoneScanStep は VTIMEZONE の時差の変わり目を探す間隔。変わり目の間はこれより長いものとする
+const timezoneScanStep = 12 * time.Hour
+
+// Timezone returns the VTIMEZONE component of loc, whose TZID is the name of loc, with an
+// observance from from and one for every transition of the UTC offset until to.
+func Timezone(loc *time.Location, from, to time.Time) *Component {
+	c := &Component{Name: "VTIMEZONE"}
+	c.Add("TZID", loc.String())
+
+	name, offset := from.In(loc).Zone()
+	c.Components = append(c.Components, timezoneObservance(from.In(loc), offset, offset, name))
+
+	// 一定の間隔で時差を比べ、変わっていたら二分探索で変わり目の時刻を求める
+	for t := from; t.Before(to); t = t.Add(timezoneScanStep) {
+		next := t.Add(timezoneScanStep)
+		nextName, nextOffset := next.In(loc).Zone()
+		if nextName == name && nextOffset == offset {
+			continue
+		}
+		lo, hi := t, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			if n, o := mid.In(loc).Zone(); n == name && o == offset {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		hi = hi.Truncate(time.Second)
+		c.Components = append(c.Components, timezoneObservance(hi.In(loc), offset, nextOffset, nextName))
+		name, offset = nextName, nextOffset
+	}
+	return c
+}
+
+// timezoneObservance は t から時差が offsetTo になる STANDARD か DAYLIGHT のコンポーネントを返す。
+// DTSTART は変わる前の時差での現地時刻で表す（RFC 5545 3.6.5）
+func timezoneObservance(t time.Time, offsetFrom, offsetTo int, name string) *Component {
+	c := &Component{Name: "STANDARD"}
+	if t.IsDST() {
+		c.Name = "DAYLIGHT"
+	}
+	c.Add("DTSTART", t.In(time.FixedZone("", offsetFrom)).Format("20060102T150405"))
+	c.Add("TZOFFSETFROM", formatUTCOffset(offsetFrom))
+	c.Add("TZOFFSETTO", formatUTCOffset(offsetTo))
+	c.Add("TZNAME", EscapeText(name))
+	return c
+}
+
+// formatUTCOffset は UTC からの秒数を UTC-OFFSET の値（+0900 など）にする
+func formatUTCOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	s := fmt.Sprintf("%c%02d%02d", sign, offset/3600, offset/60%60)
+	if offset%60 != 0 {
+		s += fmt.Sprintf("%02d", offset%60)
+	}
+	return s
+}
+
+// AddDuration adds the DURATION value such as "P1DT2H" to t. Weeks and days are nominal
+// (RFC 5545 3.3.6), so that a day across a DST transition keeps the local time of t.
+func AddDuration(t time.Time, value string) (time.Time, error) {
+	s := value
+	sign := 1
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) == 1 {
+		return time.Time{}, fmt.Errorf("ical: invalid duration %q", value)
+	}
+	s = s[1:]
+
+	var (
+		days    int
+		d       time.Duration
+		inTime  bool
+		hasUnit bool
+	)
+	for s != "" {
+		if s[0] == 'T' && !inTime {
+			inTime, s = true, s[1:]
+			continue
+		}
+		n := 0
+		i := 0
+		for ; i < len(s) && '0' <= s[i] && s[i] <= '9'; i++ {
+			n = n*10 + int(s[i]-'0')
+		}
+		if i == 0 || i == len(s) {
+			return time.Time{}, fmt.Errorf("ical: invalid duration %q", value)
+		}
+		switch unit := s[i]; {
+		case unit == 'W' && !inTime:
+			days += 7 * n
+		case unit == 'D' && !inTime:
+			days += n
+		case unit == 'H' && inTime:
+			d += time.Duration(n) * time.Hour
+		case unit == 'M' && inTime:
+			d += time.Duration(n) * time.Minute
+		case unit == 'S' && inTime:
+			d += time.Duration(n) * time.Second
+		default:
+			return time.Time{}, fmt.Errorf("ical: invalid duration %q", value)
+		}
+		hasUnit, s = true, s[i+1:]
+	}
+	if !hasUnit {
+		return time.Time{}, fmt.Errorf("ical: invalid duration %q", value)
+	}
+	return t.AddDate(0, 0, sign*days).Add(time.Duration(sign) * d), nil
+}
+
+// quoteParam は ':' ';' ',' を含むパラメーターの値を '"' で囲む
+func quoteParam(v string) string {
+	if strings.ContainsAny(v, ":;,") {
+		return `"` + strings.ReplaceAll(v, `"`, "") + `"`
+	}
+	return v
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,188 @@
+package ical_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/ical"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	description := "line1\nline2; with, specials \\ and a long text 日本語の説明文が七十五オクテットを超えると折り返される"
+
+	todo := &ical.Component{Name: "VTODO"}
+	todo.Add("UID", "todo-1@go-stations")
+	todo.Add("DESCRIPTION", ical.EscapeText(description))
+	todo.Props = append(todo.Props, &ical.Property{Name: "DUE", Params: map[string]string{"TZID": "Asia/Tokyo"}, Value: "20261019T090000"})
+	todo.Add("CATEGORIES", ical.EscapeText("a,b")+","+ical.EscapeText("c"))
+
+	var buf bytes.Buffer
+	enc := ical.NewEncoder(&buf)
+	if err := enc.Encode(&ical.Component{Name: "VCALENDAR", Components: []*ical.Component{todo}}); err != nil {
+		t.Fatal("failed to encode, err =", err)
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatal("failed to flush, err =", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line is not folded, given = %q", line)
+		}
+	}
+
+	calendar, err := ical.Decode(&buf)
+	if err != nil {
+		t.Fatal("failed to decode, err =", err)
+	}
+	if calendar.Name != "VCALENDAR" || len(calendar.Components) != 1 {
+		t.Fatalf("unexpected component, given = %+v", calendar)
+	}
+	got := calendar.Components[0]
+
+	if v := ical.UnescapeText(got.Prop("DESCRIPTION").Value); v != description {
+		t.Errorf("unexpected description, given = %q, expected = %q", v, description)
+	}
+	if v := ical.SplitText(got.Prop("CATEGORIES").Value); len(v) != 2 || v[0] != "a,b" || v[1] != "c" {
+		t.Errorf("unexpected categories, given = %q", v)
+	}
+
+	due, err := ical.ParseDateTime(got.Prop("DUE"), time.UTC)
+	if err != nil {
+		t.Fatal("failed to parse due, err =", err)
+	}
+	if want := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC); !due.Equal(want) {
+		t.Errorf("unexpected due, given = %s, expected = %s", due, want)
+	}
+}
+
+func TestParseDateTime(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		prop *ical.Property
+		want time.Time
+	}{
+		"UTC":      {prop: &ical.Property{Value: "20261019T090000Z"}, want: time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		"Floating": {prop: &ical.Property{Value: "20261019T090000"}, want: time.Date(2026, 10, 19, 9, 0, 0, 0, time.FixedZone("", 3600))},
+		"Date":     {prop: &ical.Property{Params: map[string]string{"VALUE": "DATE"}, Value: "20261019"}, want: time.Date(2026, 10, 19, 0, 0, 0, 0, time.FixedZone("", 3600))},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ical.ParseDateTime(c.prop, time.FixedZone("", 3600))
+			if err != nil {
+				t.Fatal("unexpected error, err =", err)
+			}
+			if !got.Equal(c.want) {
+				t.Errorf("unexpected time, given = %s, expected = %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestAddDuration(t *testing.T) {
+	t.Parallel()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal("failed to load location, err =", err)
+	}
+	start := time.Date(2026, 3, 7, 9, 0, 0, 0, newYork)
+
+	cases := map[string]struct {
+		value string
+		want  time.Time
+	}{
+		"Zero":         {value: "PT0S", want: start},
+		"Time":         {value: "PT1H30M15S", want: start.Add(90*time.Minute + 15*time.Second)},
+		"Day and time": {value: "P1DT2H", want: time.Date(2026, 3, 8, 11, 0, 0, 0, newYork)},
+		"Week":         {value: "P1W", want: time.Date(2026, 3, 14, 9, 0, 0, 0, newYork)},
+		"Negative":     {value: "-P1D", want: time.Date(2026, 3, 6, 9, 0, 0, 0, newYork)},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ical.AddDuration(start, c.value)
+			if err != nil {
+				t.Fatal("unexpected error, err =", err)
+			}
+			if !got.Equal(c.want) {
+				t.Errorf("unexpected time, given = %s, expected = %s", got, c.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"", "P", "PT", "1D", "P1H", "PT1D", "P1", "PTT1S"} {
+		if _, err := ical.AddDuration(start, value); err == nil {
+			t.Errorf("%q: error is expected", value)
+		}
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	t.Parallel()
+
+	// observance は種類、DTSTART、TZOFFSETFROM、TZOFFSETTO、TZNAME をつなげて比べる
+	cases := map[string]struct {
+		name string
+		want []string
+	}{
+		"DST": {name: "Europe/Berlin", want: []string{
+			"STANDARD 20230101T000000 +0100 +0100 CET",
+			"DAYLIGHT 20230326T020000 +0100 +0200 CEST",
+			"STANDARD 20231029T030000 +0200 +0100 CET",
+		}},
+		"No DST":          {name: "Asia/Tokyo", want: []string{"STANDARD 20230101T000000 +0900 +0900 JST"}},
+		"Negative offset": {name: "America/St_Johns", want: []string{"STANDARD 20230101T000000 -0330 -0330 NST", "DAYLIGHT 20230312T020000 -0330 -0230 NDT", "STANDARD 20231105T020000 -0230 -0330 NST"}},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			loc, err := time.LoadLocation(c.name)
+			if err != nil {
+				t.Fatal("failed to load location, err =", err)
+			}
+			tz := ical.Timezone(loc, time.Date(2023, 1, 1, 0, 0, 0, 0, loc), time.Date(2024, 1, 1, 0, 0, 0, 0, loc))
+			if tz.Name != "VTIMEZONE" || tz.Prop("TZID").Value != c.name {
+				t.Errorf("unexpected component, given = %+v", tz)
+			}
+			var got []string
+			for _, o := range tz.Components {
+				got = append(got, strings.Join([]string{o.Name, o.Prop("DTSTART").Value, o.Prop("TZOFFSETFROM").Value, o.Prop("TZOFFSETTO").Value, o.Prop("TZNAME").Value}, " "))
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("unexpected observances, given = %q, expected = %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	t.Parallel()
+
+	for name, input := range map[string]string{
+		"Not closed":   "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VTODO\r\n",
+		"Mismatch":     "BEGIN:VCALENDAR\r\nEND:VTODO\r\n",
+		"No value":     "BEGIN:VCALENDAR\r\nSUMMARY\r\nEND:VCALENDAR\r\n",
+		"Outside prop": "SUMMARY:x\r\n",
+	} {
+		if _, err := ical.Decode(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: error is expected", name)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Package logging builds the structured logger of the server and carries it, with the
+// attributes of the request being processed, in contexts.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Output formats of New.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// New returns a logger writing records of level or higher to w in format, FormatText or
+// FormatJSON. An empty format means FormatText. The records are written with the
+// attributes stored in their contexts by ContextWithAttrs.
+func New(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	switch format {
+	case "", FormatText:
+		h = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("logging: unknown format %q", format)
+	}
+	return slog.New(&contextHandler{Handler: h}), nil
+}
+
+type contextKey string
+
+var (
+	contextKeyLogger = contextKey("Logger")
+	contextKeyAttrs  = contextKey("Attrs")
+)
+
+// ContextWithLogger returns a copy of ctx with logger, returned by FromContext.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKeyLogger, logger)
+}
+
+// FromContext returns the logger stored by ContextWithLogger, or slog.Default.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKeyLogger).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// ContextWithAttrs returns a copy of ctx with attrs added, such as the request ID, so that
+// the loggers of New write them on every record logged with the context.
+func ContextWithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	// 親のコンテキストの属性を書き換えないよう、複製してから追加する
+	parent := attrsFromContext(ctx)
+	merged := make([]slog.Attr, 0, len(parent)+len(attrs))
+	merged = append(merged, parent...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, contextKeyAttrs, merged)
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(contextKeyAttrs).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler はコンテキストの属性をレコードに加える
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := attrsFromContext(ctx); len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// Debug logs at slog.LevelDebug with the logger and the attributes of ctx.
+func Debug(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).DebugContext(ctx, msg, args...)
+}
+
+// Info logs at slog.LevelInfo with the logger and the attributes of ctx.
+func Info(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).InfoContext(ctx, msg, args...)
+}
+
+// Warn logs at slog.LevelWarn with the logger and the attributes of ctx.
+func Warn(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).WarnContext(ctx, msg, args...)
+}
+
+// Error logs at slog.LevelError with the logger and the attributes of ctx.
+func Error(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).ErrorContext(ctx, msg, args...)
+}
@@ -0,0 +1,67 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatJSON, slog.LevelInfo)
+	if err != nil {
+		t.Fatal("failed to create logger, err =", err)
+	}
+
+	ctx := logging.ContextWithAttrs(context.Background(), slog.String("request_id", "id"))
+	child := logging.ContextWithAttrs(ctx, slog.String("route", "/todos"))
+	logger.DebugContext(child, "hidden")
+	logger.InfoContext(child, "message", "n", 1)
+	logger.InfoContext(ctx, "parent")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("records below the level must be dropped, given = %q", buf.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal("failed to parse record, err =", err)
+	}
+	if record["msg"] != "message" || record["n"] != 1.0 || record["request_id"] != "id" || record["route"] != "/todos" {
+		t.Errorf("unexpected record, given = %v", record)
+	}
+	// 子のコンテキストの属性は親に影響しない
+	if strings.Contains(lines[1], "route") {
+		t.Errorf("unexpected record, given = %s", lines[1])
+	}
+
+	if _, err := logging.New(&buf, "xml", slog.LevelInfo); err == nil {
+		t.Error("unknown format must be rejected")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	if logging.FromContext(context.Background()) != slog.Default() {
+		t.Error("slog.Default must be returned without logger")
+	}
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatText, slog.LevelDebug)
+	if err != nil {
+		t.Fatal("failed to create logger, err =", err)
+	}
+	ctx := logging.ContextWithAttrs(logging.ContextWithLogger(context.Background(), logger), slog.String("user", "alice"))
+	logging.Debug(ctx, "message")
+	if given := buf.String(); !strings.Contains(given, "level=DEBUG msg=message user=alice") {
+		t.Errorf("unexpected record, given = %q", given)
+	}
+}
@@ -1,13 +1,37 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/smtp"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+
+	"github.com/TechBowl-japan/go-stations/blob"
 	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
 	"github.com/TechBowl-japan/go-stations/handler/router"
+	"github.com/TechBowl-japan/go-stations/health"
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/reminder"
+	"github.com/TechBowl-japan/go-stations/rpc"
+	"github.com/TechBowl-japan/go-stations/service"
+	"github.com/TechBowl-japan/go-stations/tracing"
 )
 
 func main() {
@@ -20,8 +44,19 @@ func main() {
 func realMain() error {
 	// config values
 	const (
-		defaultPort   = ":8080"
-		defaultDBPath = ".sqlite3/todo.db"
+		defaultPort     = ":8080"
+		defaultGRPCPort = ":9090"
+		defaultDBPath   = ".sqlite3/todo.db"
+
+		defaultAttachmentDir = ".sqlite3/attachments"
+
+		rebalanceInterval = time.Hour
+
+		// DB のディレクトリの空き容量がこれを下回ったら readiness を失敗にする
+		minDiskFree = 64 << 20
+		// 終了処理では readiness の失敗がロードバランサーに伝わるまで待ってから、処理中のリクエストを待つ
+		shutdownDelay   = 5 * time.Second
+		shutdownTimeout = 30 * time.Second
 	)
 
 	port := os.Getenv("PORT")
@@ -29,13 +64,108 @@ func realMain() error {
 		port = defaultPort
 	}
 
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = defaultGRPCPort
+	}
+
 	dbPath := os.Getenv("DB_PATH")
 	if dbPath == "" {
 		dbPath = defaultDBPath
 	}
 
+	attachmentDir := os.Getenv("ATTACHMENT_DIR")
+	if attachmentDir == "" {
+		attachmentDir = defaultAttachmentDir
+	}
+
+	// GraphQL のイントロスペクションは、スキーマを公開してよい環境でだけ有効にする
+	graphqlIntrospection := os.Getenv("GRAPHQL_INTROSPECTION") == "true"
+
+	// リクエストは docs/openapi.yaml で検証する。strict ではレスポンスも検証し、off では検証しない
+	routerOpts := []router.Option{router.WithOpenAPIValidation()}
+	switch mode := os.Getenv("OPENAPI_VALIDATION"); mode {
+	case "", "request":
+	case "strict":
+		routerOpts = []router.Option{router.WithOpenAPIValidation(middleware.WithResponseValidation())}
+	case "off":
+		routerOpts = nil
+	default:
+		return fmt.Errorf("unknown OPENAPI_VALIDATION %q", mode)
+	}
+
+	// AUTH_TOKEN を設定した場合は、すべてのエンドポイントでその bearer トークンを求める
+	// gRPC の API にも同じトークンを求めるよう、トークンから利用者への対応も残す
+	authUsers := map[string]string{}
+	if token := os.Getenv("AUTH_TOKEN"); token != "" {
+		routerOpts = append(routerOpts, router.WithAuthToken(token))
+		authUsers[token] = middleware.DefaultUser
+	}
+	// AUTH_USERS（"名前:トークン" のカンマ区切り）を設定した場合は、利用者ごとのトークンを受け付ける
+	if users := os.Getenv("AUTH_USERS"); users != "" {
+		for _, user := range strings.Split(users, ",") {
+			name, token, ok := strings.Cut(strings.TrimSpace(user), ":")
+			if !ok || name == "" || token == "" {
+				return fmt.Errorf("invalid AUTH_USERS entry %q, expected name:token", user)
+			}
+			routerOpts = append(routerOpts, router.WithAuthUser(name, token))
+			authUsers[token] = name
+		}
+	}
+	// CORS_ALLOWED_ORIGINS（カンマ区切り）のオリジンのブラウザーから呼び出せるようにする
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		for _, origin := range strings.Split(origins, ",") {
+			routerOpts = append(routerOpts, router.WithCORS(strings.TrimSpace(origin)))
+		}
+	}
+
+	// LOG_LEVEL（debug、info、warn、error）以上のログを LOG_FORMAT（text、json）の形式で出力する
+	var level slog.Level
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			return fmt.Errorf("invalid LOG_LEVEL %q: %w", v, err)
+		}
+	}
+	logger, err := logging.New(os.Stderr, os.Getenv("LOG_FORMAT"), level)
+	if err != nil {
+		return err
+	}
+	// log パッケージで書かれたログも同じ形式で出力する
+	slog.SetDefault(logger)
+	routerOpts = append(routerOpts, router.WithLogger(logger))
+
+	// アクセスログは ACCESS_LOG（combined、json）の形式で標準出力に書く。off では書かない
+	if format := os.Getenv("ACCESS_LOG"); format != "off" {
+		if format == "" {
+			format = middleware.AccessLogCombined
+		}
+		accessLog, err := middleware.AccessLog(os.Stdout, format)
+		if err != nil {
+			return err
+		}
+		routerOpts = append(routerOpts, router.WithAccessLog(accessLog))
+	}
+
+	// /metrics で Prometheus のメトリクスを公開する。Go のランタイムとプロセスのメトリクスも含める
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	routerOpts = append(routerOpts, router.WithMetrics(registry))
+
+	// TRACE_EXPORTER（otlp、stdout）を設定した場合は、リクエストのスパンをその送り先に書き出す
+	if name := os.Getenv("TRACE_EXPORTER"); name != "" {
+		exporter, err := tracing.NewExporter(context.Background(), name, os.Stdout)
+		if err != nil {
+			return err
+		}
+		tp := tracing.NewTracerProvider(exporter)
+		// 終了時に残りのスパンを書き出す
+		defer tp.Shutdown(context.Background())
+		otel.SetTracerProvider(tp)
+	}
+	// 外部へのリクエストにも W3C の traceparent でトレースを引き継ぐ
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
 	// set time zone
-	var err error
 	time.Local, err = time.LoadLocation("Asia/Tokyo")
 	if err != nil {
 		return err
@@ -48,14 +178,141 @@ func realMain() error {
 	}
 	defer todoDB.Close()
 
-	// NOTE: 新しいエンドポイントの登録はrouter.NewRouterの内部で行うようにする
-	mux := router.NewRouter(todoDB)
+	// 並び順のキーが長くなりすぎないよう、定期的に振り直す
+	go rebalancePositions(service.NewTODOService(todoDB, service.WithLogger(logger)), logger, rebalanceInterval)
+
+	// リマインダーを期限の前に通知する
+	notifier, err := newNotifier(logger)
+	if err != nil {
+		return err
+	}
+	scheduler := reminder.NewScheduler(service.NewReminderService(todoDB), notifier, reminder.RealClock())
+	go scheduler.Run(logging.ContextWithLogger(context.Background(), logger))
 
-	// TODO: サーバーをlistenする
-	err = http.ListenAndServe(port, mux)
+	// 添付ファイルの内容はローカルのディレクトリに保存する
+	blobs, err := blob.NewLocalStore(attachmentDir)
 	if err != nil {
 		return err
 	}
 
+	// REST と gRPC のどちらで変更しても WatchTODOs に届くよう、イベントの配信先を共有する
+	events := service.NewTODOEvents()
+
+	// gRPC の API は別のポートで提供する
+	lis, err := net.Listen("tcp", grpcPort)
+	if err != nil {
+		return err
+	}
+	var grpcOpts []grpc.ServerOption
+	if len(authUsers) > 0 {
+		grpcOpts = rpc.Auth(authUsers)
+	}
+	grpcServer := rpc.NewServer(service.NewTODOService(todoDB, service.WithBlobStore(blobs), service.WithEvents(events),
+		service.WithLogger(logger)), logger, grpcOpts...)
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			logger.Error("failed to serve gRPC", "err", err)
+		}
+	}()
+	defer grpcServer.Stop() // シグナルを受け取る前に終了する場合に備える。停止した後に呼んでも何もしない
+
+	// /livez と /readyz のチェックに、DB のディレクトリの空き容量を加える
+	healthChecks := health.NewRegistry()
+	healthChecks.AddReadiness("disk", health.DiskSpace(filepath.Dir(dbPath), minDiskFree))
+
+	// NOTE: 新しいエンドポイントの登録はrouter.NewRouterの内部で行うようにする
+	routerOpts = append(routerOpts, router.WithBlobStore(blobs), router.WithTODOEvents(events),
+		router.WithGraphQLIntrospection(graphqlIntrospection), router.WithHealthChecks(healthChecks))
+	mux := router.NewRouter(todoDB, routerOpts...)
+
+	srv := &http.Server{Addr: port, Handler: mux}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+
+	// SIGINT か SIGTERM を受け取ったら、新しいリクエストを止めてから処理中のリクエストを待って終了する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	stop() // 2回目のシグナルではすぐに終了する
+
+	logger.Info("shutting down", "delay", shutdownDelay)
+	healthChecks.Shutdown()
+	// WatchTODOs のストリームは自ら終わらないので、先に閉じて GracefulStop を待たせない
+	events.Close()
+	time.Sleep(shutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	// gRPC も同時に、処理中の呼び出しが終わるのを待って止める
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	if err := srv.Shutdown(ctx); err != nil {
+		return err
+	}
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		// 時間内に終わらなかった呼び出しは打ち切る
+		grpcServer.Stop()
+		<-grpcStopped
+	}
+	// Shutdown の後に ListenAndServe が返す http.ErrServerClosed は正常な終了
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
 	return nil
 }
+
+// rebalancePositions は起動時と interval ごとにTODOの並び順のキーを振り直す
+func rebalancePositions(svc *service.TODOService, logger *slog.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx := context.Background()
+	for {
+		if _, err := svc.RebalancePositions(ctx); err != nil {
+			logger.ErrorContext(ctx, "failed to rebalance positions", "err", err)
+		}
+		<-ticker.C
+	}
+}
+
+// newNotifier は環境変数 REMINDER_NOTIFIERS（カンマ区切り）で指定された通知先の Notifier を作成する。
+// 指定がない場合はログに出力する。
+func newNotifier(logger *slog.Logger) (reminder.Notifier, error) {
+	names := os.Getenv("REMINDER_NOTIFIERS")
+	if names == "" {
+		names = "log"
+	}
+
+	var notifiers reminder.MultiNotifier
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "log":
+			notifiers = append(notifiers, reminder.NewLogNotifier(logger))
+		case "smtp":
+			addr := os.Getenv("SMTP_ADDR")
+			n := reminder.NewSMTPNotifier(addr, os.Getenv("SMTP_FROM"), strings.Split(os.Getenv("SMTP_TO"), ","))
+			if username := os.Getenv("SMTP_USERNAME"); username != "" {
+				host := strings.Split(addr, ":")[0]
+				n.Auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+			}
+			notifiers = append(notifiers, n)
+		case "webhook":
+			notifiers = append(notifiers, reminder.NewWebhookNotifier(os.Getenv("REMINDER_WEBHOOK_URL")))
+		default:
+			return nil, fmt.Errorf("unknown notifier %q", name)
+		}
+	}
+	return notifiers, nil
+}
@@ -0,0 +1,41 @@
+// Package markdown renders CommonMark into HTML that is safe to embed in web pages.
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// A Renderer renders CommonMark with GFM task lists into sanitized HTML.
+// It is safe for concurrent use.
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+}
+
+// NewRenderer returns a new Renderer.
+func NewRenderer() *Renderer {
+	// goldmark は既定で生の HTML を出力しないが、リンクの URL なども含めて出力後にも無害化する
+	policy := bluemonday.UGCPolicy()
+	// タスクリストのチェックボックスだけは input を許可する
+	policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	policy.AllowAttrs("checked", "disabled").OnElements("input")
+
+	return &Renderer{
+		md:     goldmark.New(goldmark.WithExtensions(extension.TaskList)),
+		policy: policy,
+	}
+}
+
+// Render converts the CommonMark source into sanitized HTML.
+func (r *Renderer) Render(src string) (string, error) {
+	var buf bytes.Buffer
+	if err := r.md.Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+	return r.policy.SanitizeReader(&buf).String(), nil
+}
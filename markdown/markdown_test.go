@@ -0,0 +1,62 @@
+package markdown_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/markdown"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		src      string
+		contains []string
+		excludes []string
+	}{
+		"CommonMark": {
+			src:      "# Title\n\n**bold** and [link](https://example.com)",
+			contains: []string{"<h1>Title</h1>", "<strong>bold</strong>", `<a href="https://example.com" rel="nofollow">link</a>`},
+		},
+		"TaskList": {
+			src:      "- [x] done\n- [ ] todo",
+			contains: []string{`<input checked="" disabled="" type="checkbox"> done`, `<input disabled="" type="checkbox"> todo`},
+		},
+		"RawHTML": {
+			src:      "<script>alert(1)</script>\n\n<img src=x onerror=alert(1)>",
+			excludes: []string{"<script", "onerror"},
+		},
+		"JavaScriptURL": {
+			src:      "[click](javascript:alert(1))",
+			excludes: []string{"javascript:"},
+		},
+		"TextInput": {
+			src:      `<input type="text" value="x">`,
+			excludes: []string{"<input"},
+		},
+	}
+
+	r := markdown.NewRenderer()
+	for name, tc := range testcases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := r.Render(tc.src)
+			if err != nil {
+				t.Fatal("failed to render, err =", err)
+			}
+			for _, s := range tc.contains {
+				if !strings.Contains(got, s) {
+					t.Errorf("rendered html must contain %q, given = %q", s, got)
+				}
+			}
+			for _, s := range tc.excludes {
+				if strings.Contains(got, s) {
+					t.Errorf("rendered html must not contain %q, given = %q", s, got)
+				}
+			}
+		})
+	}
+}
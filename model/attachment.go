@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type (
+	// An Attachment expresses a file attached to a TODO.
+	Attachment struct {
+		ID          int64     `json:"id"`
+		TODOID      int64     `json:"todo_id"`
+		Filename    string    `json:"filename"`
+		ContentType string    `json:"content_type"`
+		Size        int64     `json:"size"`   // バイト数
+		SHA256      string    `json:"sha256"` // 内容の SHA-256（16進数）。BlobStore のキーになる
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	// A CreateAttachmentResponse expresses ...
+	CreateAttachmentResponse struct {
+		Attachments []*Attachment `json:"attachments"`
+	}
+
+	// A ReadAttachmentResponse expresses ...
+	ReadAttachmentResponse struct {
+		Attachments []*Attachment `json:"attachments"`
+	}
+
+	// A DeleteAttachmentRequest expresses ...
+	DeleteAttachmentRequest struct {
+		IDs []int64 `json:"ids" binding:"required"`
+	}
+	// A DeleteAttachmentResponse expresses ...
+	DeleteAttachmentResponse struct{}
+)
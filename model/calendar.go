@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+type (
+	// A CalendarFeed expresses a secret URL of the iCalendar feed issued to a user.
+	CalendarFeed struct {
+		ID        int64     `json:"id"`
+		Name      string    `json:"name"` // フィードを使う利用者の名前
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// A CreateCalendarFeedRequest expresses ...
+	CreateCalendarFeedRequest struct {
+		Name string `json:"name"` // 認証しない場合は必須
+	}
+	// A CreateCalendarFeedResponse expresses ...
+	CreateCalendarFeedResponse struct {
+		Feed  CalendarFeed `json:"feed"`
+		Token string       `json:"token"` // フィードのURLに含めるトークン（作成時にのみ返す）
+		URL   string       `json:"url"`   // フィードのURLのパス
+	}
+
+	// A ReadCalendarFeedResponse expresses ...
+	ReadCalendarFeedResponse struct {
+		Feeds []*CalendarFeed `json:"feeds"`
+	}
+
+	// A DeleteCalendarFeedRequest expresses ...
+	DeleteCalendarFeedRequest struct {
+		IDs []int64 `json:"ids" binding:"required"`
+	}
+	// A DeleteCalendarFeedResponse expresses ...
+	DeleteCalendarFeedResponse struct{}
+)
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+type (
+	// A Comment expresses a comment posted on a TODO.
+	Comment struct {
+		ID        int64     `json:"id"`
+		TODOID    int64     `json:"todo_id"`
+		Author    string    `json:"author"` // 投稿者の名前
+		Body      string    `json:"body"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+
+	// A CreateCommentRequest expresses ...
+	CreateCommentRequest struct {
+		Author string `json:"author"`                  // 認証しない場合は必須
+		Body   string `json:"body" binding:"required"` // 必須
+	}
+	// A CreateCommentResponse expresses ...
+	CreateCommentResponse struct {
+		Comment Comment `json:"comment"`
+	}
+
+	// A ReadCommentResponse expresses ...
+	ReadCommentResponse struct {
+		Comments []*Comment `json:"comments"` // 投稿順のコメントのリスト
+	}
+
+	// An UpdateCommentRequest expresses ...
+	UpdateCommentRequest struct {
+		ID   int64  `json:"id" binding:"required"`   // 必須
+		Body string `json:"body" binding:"required"` // 必須
+	}
+	// An UpdateCommentResponse expresses ...
+	UpdateCommentResponse struct {
+		Comment Comment `json:"comment"` // 編集されたコメント
+	}
+
+	// A DeleteCommentRequest expresses ...
+	DeleteCommentRequest struct {
+		IDs []int64 `json:"ids" binding:"required"`
+	}
+	// A DeleteCommentResponse expresses ...
+	DeleteCommentResponse struct{}
+)
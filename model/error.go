@@ -12,3 +12,25 @@ type ErrNotFound struct {
 func (e *ErrNotFound) Error() string {
 	return fmt.Sprintf("%s with ID %d not found", e.Resource, e.ID)
 }
+
+// ErrInvalidArgument は引数の値が不正な場合のエラーを表す。
+type ErrInvalidArgument struct {
+	Field  string // 不正だった引数の名前
+	Reason string // 不正である理由
+}
+
+// ErrInvalidArgument 構造体の Error メソッドを定義
+func (e *ErrInvalidArgument) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+}
+
+// ErrForbidden は利用者にリソースを操作する権限がない場合のエラーを表す。
+type ErrForbidden struct {
+	Resource string // 操作しようとしたリソースの種類
+	ID       int64  // 操作しようとしたリソースのID
+}
+
+// ErrForbidden 構造体の Error メソッドを定義
+func (e *ErrForbidden) Error() string {
+	return fmt.Sprintf("%s with ID %d is not allowed to be modified", e.Resource, e.ID)
+}
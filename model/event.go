@@ -0,0 +1,15 @@
+package model
+
+// Types of TODOEvent.
+const (
+	TODOEventCreated = "created"
+	TODOEventUpdated = "updated"
+	TODOEventDeleted = "deleted"
+)
+
+// A TODOEvent expresses a change of TODOs delivered to the watchers.
+type TODOEvent struct {
+	Type string  `json:"type"`
+	TODO *TODO   `json:"todo,omitempty"` // 作成・更新されたTODO
+	IDs  []int64 `json:"ids,omitempty"`  // 削除されたTODOのID（一緒に削除されたサブタスクを含む）
+}
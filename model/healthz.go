@@ -4,3 +4,25 @@ package model
 type HealthzResponse struct {
 	Message string `json:"message"`
 }
+
+type (
+	// A HealthCheckResponse expresses the results of the checks of /livez or /readyz.
+	HealthCheckResponse struct {
+		Status string               `json:"status"` // すべてのチェックが通った場合は HealthStatusOK
+		Checks []*HealthCheckResult `json:"checks"`
+	}
+
+	// A HealthCheckResult expresses the result of a check of a dependency.
+	HealthCheckResult struct {
+		Name       string  `json:"name"`
+		Status     string  `json:"status"`
+		Error      string  `json:"error,omitempty"` // 失敗した理由
+		DurationMS float64 `json:"duration_ms"`
+	}
+)
+
+// ヘルスチェックの結果
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+type (
+	// A Reminder expresses a notification sent before the due time of a TODO.
+	Reminder struct {
+		ID            int64      `json:"id"`
+		TODOID        int64      `json:"todo_id"`
+		BeforeSeconds int64      `json:"before_seconds"`            // 期限の何秒前に通知するか
+		RemindAt      *time.Time `json:"remind_at,omitempty"`       // 通知する日時（TODOに期限がない場合はnil）
+		Status        string     `json:"status"`                    // pending, sent, failed のいずれか
+		Attempts      int        `json:"attempts"`                  // 通知に失敗した回数
+		LastError     string     `json:"last_error,omitempty"`      // 最後に通知に失敗した理由
+		NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"` // 失敗した通知を再送する日時
+		SentAt        *time.Time `json:"sent_at,omitempty"`         // 通知した日時
+		CreatedAt     time.Time  `json:"created_at"`
+	}
+
+	// A ReminderNotification expresses a reminder to be delivered together with its TODO.
+	ReminderNotification struct {
+		Reminder Reminder `json:"reminder"`
+		TODO     TODO     `json:"todo"`
+	}
+
+	// A CreateReminderRequest expresses ...
+	CreateReminderRequest struct {
+		BeforeSeconds int64 `json:"before_seconds"` // 必須ではない（0の場合は期限ちょうどに通知する）
+	}
+	// A CreateReminderResponse expresses ...
+	CreateReminderResponse struct {
+		Reminder Reminder `json:"reminder"`
+	}
+
+	// A ReadReminderResponse expresses ...
+	ReadReminderResponse struct {
+		Reminders []*Reminder `json:"reminders"`
+	}
+
+	// A DeleteReminderRequest expresses ...
+	DeleteReminderRequest struct {
+		IDs []int64 `json:"ids" binding:"required"`
+	}
+	// A DeleteReminderResponse expresses ...
+	DeleteReminderResponse struct{}
+)
+
+// リマインダーの状態
+const (
+	ReminderStatusPending = "pending" // 通知待ち
+	ReminderStatusSent    = "sent"    // 通知済み
+	ReminderStatusFailed  = "failed"  // 再送を諦めた
+)
@@ -0,0 +1,21 @@
+package model
+
+type (
+	// A Tag expresses a label attached to TODOs.
+	Tag struct {
+		ID    int64  `json:"id"`
+		Name  string `json:"name"`
+		Count int64  `json:"count"` // タグが付与されているTODOの数
+	}
+
+	// A ReadTagResponse expresses ...
+	ReadTagResponse struct {
+		Tags []*Tag `json:"tags"`
+	}
+)
+
+// タグによる絞り込みの一致条件
+const (
+	TagMatchAny = "any" // いずれかのタグが付与されている
+	TagMatchAll = "all" // すべてのタグが付与されている
+)
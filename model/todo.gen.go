@@ -0,0 +1,97 @@
+// Code generated by openapigen. DO NOT EDIT.
+
+package model
+
+import "time"
+
+// TODO is a task with its schedule.
+type TODO struct {
+	ID              int64      `json:"id"`
+	Subject         string     `json:"subject"`
+	Description     string     `json:"description"`
+	DescriptionHTML string     `json:"description_html,omitempty"` // Only with description_format=html.
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Tags            []string   `json:"tags,omitempty"`         // Tag names in name order.
+	ParentID        *int64     `json:"parent_id,omitempty"`    // ID of the parent TODO of a subtask.
+	CompletedAt     *time.Time `json:"completed_at,omitempty"` // Omitted until the TODO is completed.
+	DueAt           *time.Time `json:"due_at,omitempty"`
+	RRule           string     `json:"rrule,omitempty"`    // Recurrence rule as RRULE of RFC 5545. Completing the TODO creates the next occurrence with the subtasks copied uncompleted.
+	Timezone        string     `json:"timezone,omitempty"` // Time zone to compute the recurrences in, or the time zone of the server if empty.
+	CommentCount    int64      `json:"comment_count,omitempty"`
+}
+
+// ReadTODORequest is the request of GET /todos: List TODOs.
+type ReadTODORequest struct {
+	PrevID            int64    `query:"prev_id"`            // ID of the last TODO of the previous page.
+	Size              int64    `query:"size"`               // Maximum number of TODOs to return.
+	Tags              []string `query:"tag"`                // Tag names to filter TODOs by.
+	IDs               []int64  `query:"id"`                 // IDs to read only the TODOs of. Repeat the parameter for each ID.
+	TagMatch          string   `query:"tag_match"`          // Whether TODOs must have any or all of the tags.
+	DescriptionFormat string   `query:"description_format"` // Set html to also return description_html rendered from CommonMark with task lists and sanitized.
+}
+
+// ReadTODOResponse is the response of GET /todos.
+type ReadTODOResponse struct {
+	TODOs []*TODO `json:"todos"`
+}
+
+// CreateTODORequest is the request of POST /todos: Create TODO.
+type CreateTODORequest struct {
+	Subject     string     `json:"subject"`
+	Description string     `json:"description"`
+	Tags        []string   `json:"tags"`
+	ParentID    int64      `json:"parent_id"` // ID of the parent TODO, or 0 for a TODO without a parent.
+	DueAt       *time.Time `json:"due_at"`
+	RRule       string     `json:"rrule"`    // Recurrence rule as RRULE of RFC 5545. due_at is required with it.
+	Timezone    string     `json:"timezone"` // Time zone to compute the recurrences in.
+}
+
+// CreateTODOResponse is the response of POST /todos.
+type CreateTODOResponse struct {
+	TODO TODO `json:"todo"`
+}
+
+// UpdateTODORequest is the request of PUT /todos: Update TODO.
+type UpdateTODORequest struct {
+	ID          int64      `json:"id"`
+	Subject     string     `json:"subject"`
+	Description string     `json:"description"`
+	Tags        []string   `json:"tags"`      // Tags are not changed if omitted.
+	ParentID    *int64     `json:"parent_id"` // Not changed if omitted, and 0 removes the parent.
+	Completed   *bool      `json:"completed"` // Not changed if omitted.
+	DueAt       *time.Time `json:"due_at"`    // Not changed if omitted.
+	RRule       *string    `json:"rrule"`     // Not changed if omitted, and an empty string stops the recurrence.
+	Timezone    *string    `json:"timezone"`  // Not changed if omitted.
+}
+
+// UpdateTODOResponse is the response of PUT /todos.
+type UpdateTODOResponse struct {
+	TODO TODO `json:"todo"`
+}
+
+// PatchTODORequest is the request of PATCH /todos: Update fields of TODO.
+type PatchTODORequest struct {
+	ID          int64      `json:"id"`
+	Subject     *string    `json:"subject"`     // Not changed if omitted, and must not be empty.
+	Description *string    `json:"description"` // Not changed if omitted.
+	Tags        []string   `json:"tags"`        // Tags are not changed if omitted.
+	ParentID    *int64     `json:"parent_id"`   // Not changed if omitted, and 0 removes the parent.
+	Completed   *bool      `json:"completed"`   // Not changed if omitted.
+	DueAt       *time.Time `json:"due_at"`      // Not changed if omitted.
+	RRule       *string    `json:"rrule"`       // Not changed if omitted, and an empty string stops the recurrence.
+	Timezone    *string    `json:"timezone"`    // Not changed if omitted.
+}
+
+// PatchTODOResponse is the response of PATCH /todos.
+type PatchTODOResponse struct {
+	TODO TODO `json:"todo"`
+}
+
+// DeleteTODORequest is the request of DELETE /todos: Delete TODO.
+type DeleteTODORequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+// DeleteTODOResponse is the response of DELETE /todos.
+type DeleteTODOResponse struct{}
@@ -2,49 +2,44 @@ package model
 
 import "time"
 
+// TODO と /todos のリクエスト、レスポンスの型は docs/openapi.yaml から todo.gen.go に生成する
+//go:generate go run ../cmd/openapigen -spec ../docs/openapi.yaml -tag todos -kind types -package model -o todo.gen.go
+
 type (
-	// A TODO expresses ...
-	TODO struct {
-		ID          int64     `json:"id"`
-		Subject     string    `json:"subject"`
-		Description string    `json:"description"`
-		CreatedAt   time.Time `json:"created_at"`
-		UpdatedAt   time.Time `json:"updated_at"`
+	// A ReadTODOChildrenResponse expresses ...
+	ReadTODOChildrenResponse struct {
+		TODOs []*TODO `json:"todos"` // 並び順どおりのサブタスクのリスト
 	}
 
-	// 利用者から受け取る値の定義
-	CreateTODORequest struct {
-		Subject     string `json:"subject" binding:"required"`
-		Description string `json:"description"` // 必須ではない
+	// A MoveTODORequest expresses ...
+	MoveTODORequest struct {
+		BeforeID int64 `json:"before_id"` // このTODOの直前に移動する
+		AfterID  int64 `json:"after_id"`  // このTODOの直後に移動する
 	}
-	// 利用者に返す値（この場合は構造体）の定義
-	CreateTODOResponse struct {
-		TODO TODO `json:"todo"`
+	// A MoveTODOResponse expresses ...
+	MoveTODOResponse struct {
+		TODO TODO `json:"todo"` // 移動したTODO
 	}
 
-	// A ReadTODORequest expresses ...
-	ReadTODORequest struct {
-		PrevID int64 `json:"prev_id"` // 前回取得した最後のTODOのID
-		Size   int64 `json:"size"`    // 取得するTODOの最大数
-	}
-	// A ReadTODOResponse expresses ...
-	ReadTODOResponse struct {
-		TODOs []TODO `json:"todos"` // 取得したTODOのリスト
+	// A ReadTODOOccurrencesResponse expresses ...
+	ReadTODOOccurrencesResponse struct {
+		Occurrences []time.Time `json:"occurrences"` // 現在の期限以降の繰り返しの期限
 	}
 
-	UpdateTODORequest struct {
-		ID          int64  `json:"id" binding:"required"`      // 必須
-		Subject     string `json:"subject" binding:"required"` // 必須
-		Description string `json:"description"`                // 必須ではない
+	// An ImportTODOResponse expresses ...
+	ImportTODOResponse struct {
+		Imported int                `json:"imported"` // 作成した（dry_run の場合は作成できる）TODOの数
+		DryRun   bool               `json:"dry_run"`
+		Errors   []*ImportTODOError `json:"errors"` // 不正な行（1件でもある場合は何も作成しない）
 	}
-
-	UpdateTODOResponse struct {
-		TODO TODO `json:"todo"` // 変更されたTODO
+	// An ImportTODOError expresses ...
+	ImportTODOError struct {
+		Row   int    `json:"row"` // 不正な行の番号（ヘッダーを除いて1から数える）
+		Error string `json:"error"`
 	}
 
-	DeleteTODORequest struct {
-		IDs []int64 `json:"ids" binding:"required"`
+	// A ReorderTODOChildrenRequest expresses ...
+	ReorderTODOChildrenRequest struct {
+		IDs []int64 `json:"ids" binding:"required"` // 新しい並び順のサブタスクのID（すべてのサブタスクを含む）
 	}
-	// A DeleteTODOResponse expresses ...
-	DeleteTODOResponse struct{}
 )
@@ -0,0 +1,28 @@
+package reminder
+
+import "time"
+
+// A Clock tells the current time and waits for a duration. Tests inject a fake
+// implementation to control when reminders fire.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock は time パッケージをそのまま使う Clock
+type realClock struct{}
+
+// RealClock returns Clock based on the time package.
+func RealClock() Clock {
+	return realClock{}
+}
+
+// Now implements Clock interface.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After implements Clock interface.
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,73 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// A Notifier delivers a reminder of a TODO.
+type Notifier interface {
+	Notify(ctx context.Context, n *model.ReminderNotification) error
+}
+
+// A LogNotifier implements Notifier writing reminders to the log.
+type LogNotifier struct {
+	Logger *slog.Logger // nil の場合はコンテキストのロガーを使う
+}
+
+// NewLogNotifier returns LogNotifier based Notifier writing reminders with logger.
+func NewLogNotifier(logger *slog.Logger) *LogNotifier {
+	return &LogNotifier{Logger: logger}
+}
+
+// Notify implements Notifier interface. The reminders are logged at slog.LevelInfo with
+// the attributes of ctx.
+func (n *LogNotifier) Notify(ctx context.Context, notification *model.ReminderNotification) error {
+	logger := n.Logger
+	if logger == nil {
+		logger = logging.FromContext(ctx)
+	}
+	logger.InfoContext(ctx, "reminder",
+		"reminder_id", notification.Reminder.ID,
+		"todo_id", notification.TODO.ID,
+		"subject", notification.TODO.Subject,
+		"due_at", notification.TODO.DueAt)
+	return nil
+}
+
+// A MultiNotifier implements Notifier delivering reminders to all of the Notifiers.
+type MultiNotifier []Notifier
+
+// Notify implements Notifier interface. It tries every Notifier and returns their errors joined.
+func (m MultiNotifier) Notify(ctx context.Context, n *model.ReminderNotification) error {
+	var msgs []string
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, n); err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// subject は通知の件名を返す
+func subject(n *model.ReminderNotification) string {
+	return fmt.Sprintf("Reminder: %s", n.TODO.Subject)
+}
+
+// body は通知の本文を返す
+func body(n *model.ReminderNotification) string {
+	due := ""
+	if n.TODO.DueAt != nil {
+		due = n.TODO.DueAt.Local().Format("2006-01-02 15:04 MST")
+	}
+	return fmt.Sprintf("%s\n\nDue: %s\n\n%s\n", n.TODO.Subject, due, n.TODO.Description)
+}
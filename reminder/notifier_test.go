@@ -0,0 +1,47 @@
+package reminder_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/reminder"
+)
+
+func TestLogNotifier(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatJSON, slog.LevelInfo)
+	if err != nil {
+		t.Fatal("failed to create logger, err =", err)
+	}
+
+	// コンテキストの属性も記録する
+	ctx := logging.ContextWithAttrs(context.Background(), slog.String("user", "alice"))
+	err = reminder.NewLogNotifier(logger).Notify(ctx, &model.ReminderNotification{
+		Reminder: model.Reminder{ID: 2, TODOID: 3},
+		TODO:     model.TODO{ID: 3, Subject: "subject"},
+	})
+	if err != nil {
+		t.Fatal("failed to notify, err =", err)
+	}
+
+	var record struct {
+		Msg        string `json:"msg"`
+		ReminderID int64  `json:"reminder_id"`
+		TODOID     int64  `json:"todo_id"`
+		Subject    string `json:"subject"`
+		User       string `json:"user"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("failed to decode log, err = %v, log = %q", err, buf.String())
+	}
+	if record.Msg != "reminder" || record.ReminderID != 2 || record.TODOID != 3 || record.Subject != "subject" || record.User != "alice" {
+		t.Errorf("unexpected log, given = %q", buf.String())
+	}
+}
@@ -0,0 +1,123 @@
+package reminder
+
+import (
+	"context"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// A Store persists reminders and records their delivery.
+// *service.ReminderService implements it.
+type Store interface {
+	PendingReminders(ctx context.Context) ([]*model.ReminderNotification, error)
+	MarkReminderSent(ctx context.Context, id int64, sentAt time.Time) error
+	MarkReminderFailed(ctx context.Context, id int64, cause error, retryAt time.Time) error
+}
+
+// A Scheduler delivers reminders stored in Store through Notifier when their time comes.
+// Because the state of every reminder is kept in Store, reminders whose time came while
+// the server was stopped are delivered as soon as the Scheduler runs again.
+type Scheduler struct {
+	store    Store
+	notifier Notifier
+	clock    Clock
+
+	PollInterval time.Duration // 新しく作成されたリマインダーを確認する間隔
+	MaxAttempts  int           // 通知に失敗した場合に再送を諦めるまでの回数
+	RetryDelay   time.Duration // 最初の再送までの時間。再送のたびに2倍にする
+}
+
+// NewScheduler returns new Scheduler.
+func NewScheduler(store Store, notifier Notifier, clock Clock) *Scheduler {
+	return &Scheduler{
+		store:        store,
+		notifier:     notifier,
+		clock:        clock,
+		PollInterval: time.Minute,
+		MaxAttempts:  5,
+		RetryDelay:   time.Minute,
+	}
+}
+
+// Run delivers reminders until ctx is canceled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		next, err := s.RunOnce(ctx)
+		if err != nil {
+			logging.Error(ctx, "failed to deliver reminders", "err", err)
+		}
+
+		// 次のリマインダーの時刻まで待つ。ただし新しいリマインダーに気づけるよう PollInterval より長くは待たない
+		wait := s.PollInterval
+		if !next.IsZero() {
+			if d := next.Sub(s.clock.Now()); d < wait {
+				wait = d
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.clock.After(wait):
+		}
+	}
+}
+
+// RunOnce delivers the reminders whose time has come, and returns the time of the
+// next pending reminder, or the zero time if there is none.
+func (s *Scheduler) RunOnce(ctx context.Context) (time.Time, error) {
+	pending, err := s.store.PendingReminders(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := s.clock.Now()
+	var next time.Time
+	earlier := func(t time.Time) {
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+
+	for _, n := range pending {
+		at := fireAt(&n.Reminder)
+		if at.After(now) {
+			earlier(at)
+			continue
+		}
+
+		if err := s.notifier.Notify(ctx, n); err != nil {
+			// 失敗した回数に応じて再送までの時間を延ばし、MaxAttempts 回失敗したら諦める
+			var retryAt time.Time
+			if n.Reminder.Attempts+1 < s.MaxAttempts {
+				retryAt = now.Add(s.RetryDelay << uint(n.Reminder.Attempts))
+				earlier(retryAt)
+			}
+			logging.Warn(ctx, "failed to notify reminder", "reminder_id", n.Reminder.ID, "attempts", n.Reminder.Attempts+1, "err", err)
+			if err := s.store.MarkReminderFailed(ctx, n.Reminder.ID, err, retryAt); err != nil {
+				return next, err
+			}
+			continue
+		}
+
+		if err := s.store.MarkReminderSent(ctx, n.Reminder.ID, now); err != nil {
+			return next, err
+		}
+	}
+
+	return next, nil
+}
+
+// fireAt はリマインダーを通知する時刻を返す。再送待ちの場合は再送する時刻のほうが遅ければそれを返す
+func fireAt(r *model.Reminder) time.Time {
+	var at time.Time
+	if r.RemindAt != nil {
+		at = *r.RemindAt
+	}
+	if r.NextAttemptAt != nil && r.NextAttemptAt.After(at) {
+		at = *r.NextAttemptAt
+	}
+	return at
+}
@@ -0,0 +1,224 @@
+package reminder_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/reminder"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// fakeClock は Advance を呼ぶまで時刻が進まない Clock
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
+
+// recordingNotifier は受け取った通知を記録する Notifier。errs が残っている間は先頭のエラーを返す
+type recordingNotifier struct {
+	mu   sync.Mutex
+	errs []error
+	sent chan *model.ReminderNotification
+}
+
+func newRecordingNotifier(errs ...error) *recordingNotifier {
+	return &recordingNotifier{errs: errs, sent: make(chan *model.ReminderNotification, 10)}
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification *model.ReminderNotification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.errs) > 0 {
+		err := n.errs[0]
+		n.errs = n.errs[1:]
+		return err
+	}
+	n.sent <- notification
+	return nil
+}
+
+func (n *recordingNotifier) count() int {
+	return len(n.sent)
+}
+
+func TestScheduler(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "reminder_test.db")
+	todoDB, err := db.NewDB(path)
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+	todo, err := service.NewTODOService(todoDB).CreateTODO(ctx, "subject", "description", service.WithDueAt(start.Add(time.Hour)))
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	if _, err := service.NewReminderService(todoDB).CreateReminder(ctx, todo.ID, int64((10 * time.Minute).Seconds())); err != nil {
+		t.Fatal("failed to create reminder, err =", err)
+	}
+
+	clock := &fakeClock{now: start}
+	notifier := newRecordingNotifier()
+	scheduler := reminder.NewScheduler(service.NewReminderService(todoDB), notifier, clock)
+
+	next, err := scheduler.RunOnce(ctx)
+	if err != nil {
+		t.Fatal("unexpected error, err =", err)
+	}
+	if want := start.Add(50 * time.Minute); !next.Equal(want) {
+		t.Errorf("unexpected next time, given = %s, expected = %s", next, want)
+	}
+	if notifier.count() != 0 {
+		t.Fatal("reminder is notified too early")
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- scheduler.Run(runCtx) }()
+
+	clock.Advance(50 * time.Minute)
+
+	select {
+	case n := <-notifier.sent:
+		if n.TODO.ID != todo.ID {
+			t.Errorf("unexpected todo, given = %d, expected = %d", n.TODO.ID, todo.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reminder is not notified")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error, given = %v, expected = %v", err, context.Canceled)
+	}
+
+	// 再起動を想定して開き直したDBからは、通知済みのリマインダーは通知しない
+	if err := todoDB.Close(); err != nil {
+		t.Fatal("failed to close db, err =", err)
+	}
+	todoDB, err = db.NewDB(path)
+	if err != nil {
+		t.Fatal("failed to reopen db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	restarted := reminder.NewScheduler(service.NewReminderService(todoDB), notifier, clock)
+	if _, err := restarted.RunOnce(ctx); err != nil {
+		t.Fatal("unexpected error, err =", err)
+	}
+	if notifier.count() != 0 {
+		t.Error("sent reminder is notified again after restart")
+	}
+}
+
+func TestSchedulerRetry(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "reminder_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+	todo, err := service.NewTODOService(todoDB).CreateTODO(ctx, "subject", "", service.WithDueAt(start))
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	reminders := service.NewReminderService(todoDB)
+	created, err := reminders.CreateReminder(ctx, todo.ID, 0)
+	if err != nil {
+		t.Fatal("failed to create reminder, err =", err)
+	}
+
+	clock := &fakeClock{now: start}
+	notifier := newRecordingNotifier(errors.New("temporary failure"))
+	scheduler := reminder.NewScheduler(reminders, notifier, clock)
+
+	next, err := scheduler.RunOnce(ctx)
+	if err != nil {
+		t.Fatal("unexpected error, err =", err)
+	}
+	if want := start.Add(scheduler.RetryDelay); !next.Equal(want) {
+		t.Errorf("unexpected retry time, given = %s, expected = %s", next, want)
+	}
+
+	got, err := reminders.ReadReminders(ctx, todo.ID)
+	if err != nil {
+		t.Fatal("failed to read reminders, err =", err)
+	}
+	if got[0].ID != created.ID || got[0].Attempts != 1 || got[0].Status != model.ReminderStatusPending || got[0].LastError == "" {
+		t.Errorf("failure is not recorded, given = %+v", got[0])
+	}
+
+	clock.Advance(scheduler.RetryDelay)
+	if _, err := scheduler.RunOnce(ctx); err != nil {
+		t.Fatal("unexpected error, err =", err)
+	}
+	if notifier.count() != 1 {
+		t.Fatal("reminder is not retried")
+	}
+
+	got, err = reminders.ReadReminders(ctx, todo.ID)
+	if err != nil {
+		t.Fatal("failed to read reminders, err =", err)
+	}
+	if got[0].Status != model.ReminderStatusSent || got[0].SentAt == nil || !got[0].SentAt.Equal(clock.Now()) {
+		t.Errorf("delivery is not recorded, given = %+v", got[0])
+	}
+}
@@ -0,0 +1,49 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// An SMTPNotifier implements Notifier sending reminders by email.
+type SMTPNotifier struct {
+	Addr string    // SMTP サーバーのアドレス（host:port）
+	Auth smtp.Auth // nil の場合は認証しない
+	From string
+	To   []string
+}
+
+// NewSMTPNotifier returns SMTPNotifier based Notifier.
+func NewSMTPNotifier(addr, from string, to []string) *SMTPNotifier {
+	return &SMTPNotifier{
+		Addr: addr,
+		From: from,
+		To:   to,
+	}
+}
+
+// Notify implements Notifier interface.
+func (n *SMTPNotifier) Notify(ctx context.Context, notification *model.ReminderNotification) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject(notification)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body(notification), "\n", "\r\n"))
+
+	// net/smtp は context に対応していないため、送信中のキャンセルはできない
+	if err := smtp.SendMail(n.Addr, n.Auth, n.From, n.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send reminder mail: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package reminder_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/reminder"
+)
+
+// fakeSMTPMessage は fakeSMTPServer が受け取ったメール
+type fakeSMTPMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer は1通のメールを受け取るだけの最小限の SMTP サーバーを起動し、そのアドレスを返す
+func fakeSMTPServer(t *testing.T) (string, <-chan fakeSMTPMessage) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("failed to listen, err =", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	received := make(chan fakeSMTPMessage, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		var msg fakeSMTPMessage
+		tp.PrintfLine("220 localhost fake SMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				tp.PrintfLine("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				msg.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+				tp.PrintfLine("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+				tp.PrintfLine("250 OK")
+			case cmd == "DATA":
+				tp.PrintfLine("354 Go ahead")
+				data, err := tp.ReadDotLines()
+				if err != nil {
+					return
+				}
+				msg.data = strings.Join(data, "\n")
+				tp.PrintfLine("250 OK")
+			case cmd == "QUIT":
+				tp.PrintfLine("221 Bye")
+				received <- msg
+				return
+			default:
+				tp.PrintfLine("502 Not implemented")
+			}
+		}
+	}()
+
+	return l.Addr().String(), received
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	t.Parallel()
+
+	addr, received := fakeSMTPServer(t)
+
+	due := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	n := reminder.NewSMTPNotifier(addr, "todo@example.com", []string{"user@example.com"})
+	err := n.Notify(context.Background(), &model.ReminderNotification{
+		Reminder: model.Reminder{ID: 1, TODOID: 1},
+		TODO:     model.TODO{ID: 1, Subject: "ゴミ出し", Description: "燃えるゴミ", DueAt: &due},
+	})
+	if err != nil {
+		t.Fatal("unexpected error, err =", err)
+	}
+
+	select {
+	case msg := <-received:
+		if msg.from != "todo@example.com" {
+			t.Errorf("unexpected sender, given = %s", msg.from)
+		}
+		if len(msg.to) != 1 || msg.to[0] != "user@example.com" {
+			t.Errorf("unexpected recipients, given = %v", msg.to)
+		}
+
+		r := textproto.NewReader(bufio.NewReader(strings.NewReader(msg.data + "\n")))
+		header, err := r.ReadMIMEHeader()
+		if err != nil {
+			t.Fatal("failed to parse header, err =", err)
+		}
+		if got := header.Get("Subject"); !strings.HasPrefix(got, "=?utf-8?") {
+			t.Errorf("subject is not encoded, given = %s", got)
+		}
+		if !strings.Contains(msg.data, "燃えるゴミ") {
+			t.Errorf("body does not contain description, given = %s", msg.data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("mail is not received")
+	}
+}
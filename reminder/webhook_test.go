@@ -0,0 +1,51 @@
+package reminder_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/reminder"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		status  int
+		wantErr bool
+	}{
+		"Accepted":     {status: http.StatusNoContent},
+		"Server error": {status: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var got model.ReminderNotification
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Error("failed to decode payload, err =", err)
+				}
+				w.WriteHeader(c.status)
+			}))
+			defer srv.Close()
+
+			err := reminder.NewWebhookNotifier(srv.URL).Notify(context.Background(), &model.ReminderNotification{
+				Reminder: model.Reminder{ID: 2, TODOID: 3},
+				TODO:     model.TODO{ID: 3, Subject: "subject"},
+			})
+			if (err != nil) != c.wantErr {
+				t.Fatalf("unexpected error, given = %v, expected error = %t", err, c.wantErr)
+			}
+			if got.Reminder.ID != 2 || got.TODO.Subject != "subject" {
+				t.Errorf("unexpected payload, given = %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+)
+
+// Auth returns the server options accepting only the calls with any token of users, a map
+// from the bearer tokens to the names of the users, in the authorization metadata, like
+// middleware.AuthUsers of the REST endpoints.
+func Auth(users map[string]string) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			user, err := authenticate(ctx, users)
+			if err != nil {
+				return nil, err
+			}
+			return handler(logging.ContextWithAttrs(ctx, slog.String("user", user)), req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			user, err := authenticate(ss.Context(), users)
+			if err != nil {
+				return err
+			}
+			// ログにも認証した利用者を残す
+			return handler(srv, &contextServerStream{ServerStream: ss, ctx: logging.ContextWithAttrs(ss.Context(), slog.String("user", user))})
+		}),
+	}
+}
+
+// authenticate は呼び出しの authorization メタデータの bearer トークンが users のいずれかか確認し、その利用者を返す
+func authenticate(ctx context.Context, users map[string]string) (string, error) {
+	const prefix = "Bearer "
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		// スキームは大文字と小文字を区別しない
+		if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+			continue
+		}
+		// トークンの比較にかかる時間から推測されないよう、すべてのトークンと一定時間で比較する
+		found, user := false, ""
+		for token, name := range users {
+			if subtle.ConstantTimeCompare([]byte(auth[len(prefix):]), []byte(token)) == 1 {
+				found, user = true, name
+			}
+		}
+		if found {
+			return user, nil
+		}
+	}
+	return "", status.Error(codes.Unauthenticated, "unauthenticated")
+}
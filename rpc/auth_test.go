@@ -0,0 +1,60 @@
+package rpc_test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/TechBowl-japan/go-stations/rpc"
+	"github.com/TechBowl-japan/go-stations/rpc/todopb"
+)
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newClient(t, rpc.Auth(map[string]string{"token-a": "alice", "token-b": "bob"})...)
+
+	cases := map[string]struct {
+		authorization []string
+		code          codes.Code
+	}{
+		"No token":        {code: codes.Unauthenticated},
+		"Wrong token":     {authorization: []string{"Bearer wrong"}, code: codes.Unauthenticated},
+		"Not bearer":      {authorization: []string{"Basic token-a"}, code: codes.Unauthenticated},
+		"Token of a user": {authorization: []string{"Bearer token-b"}, code: codes.OK},
+		"Lower scheme":    {authorization: []string{"bearer token-a"}, code: codes.OK},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			for _, auth := range c.authorization {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", auth)
+			}
+
+			_, err := client.CreateTODO(ctx, &todopb.CreateTODORequest{Subject: "subject"})
+			if code := status.Code(err); code != c.code {
+				t.Errorf("unexpected code of unary call, given = %v, expected = %v", code, c.code)
+			}
+
+			// ストリームの呼び出しも同じく認証する。エラーは最初の Recv で返る
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			stream, err := client.WatchTODOs(ctx, &todopb.WatchTODOsRequest{})
+			if err != nil {
+				t.Fatal("failed to watch todos, err =", err)
+			}
+			if c.code != codes.OK {
+				if _, err := stream.Recv(); status.Code(err) != c.code {
+					t.Errorf("unexpected error of stream call, given = %v, expected = %v", err, c.code)
+				}
+			}
+		})
+	}
+}
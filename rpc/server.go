@@ -0,0 +1,246 @@
+// Package rpc serves the TODO API over gRPC with the same services as the REST endpoints.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/rpc/todopb"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// A TODOServer implements todopb.TODOServiceServer backed by service.TODOService.
+type TODOServer struct {
+	todopb.UnimplementedTODOServiceServer
+
+	svc    *service.TODOService
+	logger *slog.Logger
+}
+
+// NewTODOServer returns new TODOServer logging with logger, or slog.Default if it is nil.
+func NewTODOServer(svc *service.TODOService, logger *slog.Logger) *TODOServer {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &TODOServer{
+		svc:    svc,
+		logger: logger,
+	}
+}
+
+// NewServer returns a gRPC server serving TODOServer of svc. The calls are logged by logger
+// with the attribute of the method called, like the route of the REST endpoints.
+func NewServer(svc *service.TODOService, logger *slog.Logger, opts ...grpc.ServerOption) *grpc.Server {
+	// ほかのインターセプターのログにもメソッドが残るよう、最も外側に置く
+	opts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(logging.ContextWithAttrs(ctx, slog.String("method", info.FullMethod)), req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return handler(srv, &contextServerStream{ServerStream: ss, ctx: logging.ContextWithAttrs(ss.Context(), slog.String("method", info.FullMethod))})
+		}),
+	}, opts...)
+	s := grpc.NewServer(opts...)
+	todopb.RegisterTODOServiceServer(s, NewTODOServer(svc, logger))
+	return s
+}
+
+// contextServerStream はインターセプターで属性を加えたコンテキストを返す grpc.ServerStream
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// CreateTODO implements todopb.TODOServiceServer.
+func (s *TODOServer) CreateTODO(ctx context.Context, req *todopb.CreateTODORequest) (*todopb.CreateTODOResponse, error) {
+	if req.Subject == "" {
+		return nil, status.Error(codes.InvalidArgument, "subject is required")
+	}
+
+	opts := []service.TODOOption{service.WithTags(req.Tags), service.WithParent(req.ParentId)}
+	if req.DueAt != nil {
+		opts = append(opts, service.WithDueAt(req.DueAt.AsTime()))
+	}
+	if req.Rrule != "" || req.Timezone != "" {
+		opts = append(opts, service.WithRRule(req.Rrule), service.WithTimezone(req.Timezone))
+	}
+
+	todo, err := s.svc.CreateTODO(ctx, req.Subject, req.Description, opts...)
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+	return &todopb.CreateTODOResponse{Todo: toProto(todo)}, nil
+}
+
+// ReadTODO implements todopb.TODOServiceServer.
+func (s *TODOServer) ReadTODO(ctx context.Context, req *todopb.ReadTODORequest) (*todopb.ReadTODOResponse, error) {
+	// REST と同じく、サイズの指定がない場合は10件
+	size := req.Size
+	if size == 0 {
+		size = 10
+	}
+
+	tagMatch := req.TagMatch
+	switch tagMatch {
+	case "":
+		tagMatch = model.TagMatchAny
+	case model.TagMatchAny, model.TagMatchAll:
+	default:
+		return nil, status.Error(codes.InvalidArgument, "tag_match must be any or all")
+	}
+
+	todos, err := s.svc.ReadTODO(ctx, req.PrevId, size, service.WithTagFilter(req.Tags, tagMatch))
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	resp := &todopb.ReadTODOResponse{Todos: make([]*todopb.TODO, len(todos))}
+	for i, todo := range todos {
+		resp.Todos[i] = toProto(todo)
+	}
+	return resp, nil
+}
+
+// UpdateTODO implements todopb.TODOServiceServer.
+func (s *TODOServer) UpdateTODO(ctx context.Context, req *todopb.UpdateTODORequest) (*todopb.UpdateTODOResponse, error) {
+	if req.Id == 0 || req.Subject == "" {
+		return nil, status.Error(codes.InvalidArgument, "id and subject are required")
+	}
+
+	// 指定されていないフィールドは変更しない
+	var opts []service.TODOOption
+	if req.Tags != nil {
+		// 空の TagList はタグをすべて外す
+		tags := append([]string{}, req.Tags.Names...)
+		opts = append(opts, service.WithTags(tags))
+	}
+	if req.ParentId != nil {
+		opts = append(opts, service.WithParent(*req.ParentId))
+	}
+	if req.Completed != nil {
+		opts = append(opts, service.WithCompleted(*req.Completed))
+	}
+	if req.DueAt != nil {
+		opts = append(opts, service.WithDueAt(req.DueAt.AsTime()))
+	}
+	if req.Rrule != nil {
+		opts = append(opts, service.WithRRule(*req.Rrule))
+	}
+	if req.Timezone != nil {
+		opts = append(opts, service.WithTimezone(*req.Timezone))
+	}
+
+	todo, err := s.svc.UpdateTODO(ctx, req.Id, req.Subject, req.Description, opts...)
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+	return &todopb.UpdateTODOResponse{Todo: toProto(todo)}, nil
+}
+
+// DeleteTODO implements todopb.TODOServiceServer.
+func (s *TODOServer) DeleteTODO(ctx context.Context, req *todopb.DeleteTODORequest) (*todopb.DeleteTODOResponse, error) {
+	if len(req.Ids) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "ids are required")
+	}
+
+	if err := s.svc.DeleteTODO(ctx, req.Ids); err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+	return &todopb.DeleteTODOResponse{}, nil
+}
+
+// WatchTODOs implements todopb.TODOServiceServer.
+func (s *TODOServer) WatchTODOs(req *todopb.WatchTODOsRequest, stream todopb.TODOService_WatchTODOsServer) error {
+	ctx := stream.Context()
+	events := s.svc.WatchTODO(ctx)
+
+	// 監視を始めたことをクライアントが待てるよう、先にヘッダーを送る
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for event := range events {
+		if err := stream.Send(eventToProto(event)); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	// サーバーが止まる前に閉じられた場合は、ほかのサーバーで監視し直してもらう
+	if s.svc.WatchClosed() {
+		return status.Error(codes.Unavailable, "server is shutting down, watch again")
+	}
+	// 受け取りが遅れてイベントを取りこぼした場合は、クライアントに読み込み直してもらう
+	return status.Error(codes.Aborted, "watcher fell behind, watch again after reloading TODOs")
+}
+
+// toStatus はサービス層のエラーを対応する gRPC の status にする
+func (s *TODOServer) toStatus(ctx context.Context, err error) error {
+	var (
+		notFound *model.ErrNotFound
+		invalid  *model.ErrInvalidArgument
+	)
+	switch {
+	case errors.As(err, &notFound):
+		return status.Error(codes.NotFound, "not found")
+	case errors.As(err, &invalid):
+		return status.Error(codes.InvalidArgument, invalid.Error())
+	default:
+		s.logger.ErrorContext(ctx, "gRPC internal error", "err", err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// toProto は model.TODO を todopb.TODO にする
+func toProto(todo *model.TODO) *todopb.TODO {
+	timestamp := func(t *time.Time) *timestamppb.Timestamp {
+		if t == nil {
+			return nil
+		}
+		return timestamppb.New(*t)
+	}
+
+	return &todopb.TODO{
+		Id:           todo.ID,
+		Subject:      todo.Subject,
+		Description:  todo.Description,
+		CreatedAt:    timestamppb.New(todo.CreatedAt),
+		UpdatedAt:    timestamppb.New(todo.UpdatedAt),
+		Tags:         todo.Tags,
+		ParentId:     todo.ParentID,
+		CompletedAt:  timestamp(todo.CompletedAt),
+		DueAt:        timestamp(todo.DueAt),
+		Rrule:        todo.RRule,
+		Timezone:     todo.Timezone,
+		CommentCount: todo.CommentCount,
+	}
+}
+
+// eventToProto は model.TODOEvent を todopb.TODOEvent にする
+func eventToProto(event *model.TODOEvent) *todopb.TODOEvent {
+	types := map[string]todopb.TODOEvent_Type{
+		model.TODOEventCreated: todopb.TODOEvent_TYPE_CREATED,
+		model.TODOEventUpdated: todopb.TODOEvent_TYPE_UPDATED,
+		model.TODOEventDeleted: todopb.TODOEvent_TYPE_DELETED,
+	}
+
+	pb := &todopb.TODOEvent{Type: types[event.Type], Ids: event.IDs}
+	if event.TODO != nil {
+		pb.Todo = toProto(event.TODO)
+	}
+	return pb
+}
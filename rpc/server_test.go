@@ -0,0 +1,340 @@
+package rpc_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/rpc"
+	"github.com/TechBowl-japan/go-stations/rpc/todopb"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// newClient は bufconn で接続した gRPC のクライアントと、同じ DB の TODOService を返す
+func newClient(t *testing.T, opts ...grpc.ServerOption) (todopb.TODOServiceClient, *service.TODOService) {
+	t.Helper()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "rpc_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	// REST 側の TODOService での変更も届くよう、イベントを共有する
+	events := service.NewTODOEvents()
+	svc := service.NewTODOService(todoDB, service.WithEvents(events))
+
+	server := rpc.NewServer(service.NewTODOService(todoDB, service.WithEvents(events)), nil, opts...)
+	return serve(t, server), svc
+}
+
+// serve は server を bufconn で動かし、接続したクライアントを返す
+func serve(t *testing.T, server *grpc.Server) todopb.TODOServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatal("failed to dial, err =", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return todopb.NewTODOServiceClient(conn)
+}
+
+func TestTODOServer(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newClient(t)
+	ctx := context.Background()
+
+	created, err := client.CreateTODO(ctx, &todopb.CreateTODORequest{Subject: "subject", Description: "description", Tags: []string{"b", "a"}})
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	if created.Todo.Id == 0 || created.Todo.Subject != "subject" || len(created.Todo.Tags) != 2 || created.Todo.CreatedAt == nil {
+		t.Errorf("unexpected todo, given = %v", created.Todo)
+	}
+
+	// tags を省略した場合は変更しない
+	completed := true
+	updated, err := client.UpdateTODO(ctx, &todopb.UpdateTODORequest{Id: created.Todo.Id, Subject: "updated", Completed: &completed})
+	if err != nil {
+		t.Fatal("failed to update todo, err =", err)
+	}
+	if updated.Todo.Subject != "updated" || len(updated.Todo.Tags) != 2 || updated.Todo.CompletedAt == nil {
+		t.Errorf("unexpected todo, given = %v", updated.Todo)
+	}
+
+	updated, err = client.UpdateTODO(ctx, &todopb.UpdateTODORequest{Id: created.Todo.Id, Subject: "updated", Tags: &todopb.TagList{}})
+	if err != nil {
+		t.Fatal("failed to update todo, err =", err)
+	}
+	if len(updated.Todo.Tags) != 0 {
+		t.Errorf("tags must be cleared, given = %v", updated.Todo.Tags)
+	}
+
+	read, err := client.ReadTODO(ctx, &todopb.ReadTODORequest{})
+	if err != nil {
+		t.Fatal("failed to read todos, err =", err)
+	}
+	if len(read.Todos) != 1 || read.Todos[0].Id != created.Todo.Id {
+		t.Errorf("unexpected todos, given = %v", read.Todos)
+	}
+
+	if _, err := client.DeleteTODO(ctx, &todopb.DeleteTODORequest{Ids: []int64{created.Todo.Id}}); err != nil {
+		t.Fatal("failed to delete todo, err =", err)
+	}
+
+	testcases := map[string]struct {
+		call func() error
+		want codes.Code
+	}{
+		"CreateWithoutSubject": {
+			call: func() error { _, err := client.CreateTODO(ctx, &todopb.CreateTODORequest{}); return err },
+			want: codes.InvalidArgument,
+		},
+		"UpdateNotFound": {
+			call: func() error {
+				_, err := client.UpdateTODO(ctx, &todopb.UpdateTODORequest{Id: created.Todo.Id, Subject: "subject"})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		"DeleteNotFound": {
+			call: func() error {
+				_, err := client.DeleteTODO(ctx, &todopb.DeleteTODORequest{Ids: []int64{created.Todo.Id}})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		"InvalidTagMatch": {
+			call: func() error { _, err := client.ReadTODO(ctx, &todopb.ReadTODORequest{TagMatch: "none"}); return err },
+			want: codes.InvalidArgument,
+		},
+	}
+	for name, tc := range testcases {
+		if got := status.Code(tc.call()); got != tc.want {
+			t.Errorf("%s: unexpected code, want = %s, given = %s", name, tc.want, got)
+		}
+	}
+}
+
+func TestWatchTODOs(t *testing.T) {
+	t.Parallel()
+
+	client, svc := newClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchTODOs(ctx, &todopb.WatchTODOsRequest{})
+	if err != nil {
+		t.Fatal("failed to watch todos, err =", err)
+	}
+	// ヘッダーが届いた後の変更は必ず配信される
+	if _, err := stream.Header(); err != nil {
+		t.Fatal("failed to receive header, err =", err)
+	}
+
+	// gRPC 以外での変更も届く
+	parent, err := svc.CreateTODO(ctx, "parent", "")
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	child, err := client.CreateTODO(ctx, &todopb.CreateTODORequest{Subject: "child", ParentId: parent.ID})
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	if err := svc.DeleteTODO(ctx, []int64{parent.ID}); err != nil {
+		t.Fatal("failed to delete todo, err =", err)
+	}
+
+	want := []struct {
+		typ todopb.TODOEvent_Type
+		id  int64
+	}{
+		{todopb.TODOEvent_TYPE_CREATED, parent.ID},
+		{todopb.TODOEvent_TYPE_CREATED, child.Todo.Id},
+	}
+	for _, w := range want {
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatal("failed to receive event, err =", err)
+		}
+		if event.Type != w.typ || event.Todo.GetId() != w.id {
+			t.Errorf("unexpected event, given = %v", event)
+		}
+	}
+
+	// サブタスクも一緒に削除されたことが伝わる
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatal("failed to receive event, err =", err)
+	}
+	if event.Type != todopb.TODOEvent_TYPE_DELETED || len(event.Ids) != 2 {
+		t.Errorf("unexpected event, given = %v", event)
+	}
+
+	cancel()
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Errorf("stream must be canceled, given = %v", err)
+	}
+}
+
+func TestWatchTODOs_Close(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "rpc_close_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	events := service.NewTODOEvents()
+	server := rpc.NewServer(service.NewTODOService(todoDB, service.WithEvents(events)), nil)
+	client := serve(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := client.WatchTODOs(ctx, &todopb.WatchTODOsRequest{})
+	if err != nil {
+		t.Fatal("failed to watch todos, err =", err)
+	}
+	if _, err := stream.Header(); err != nil {
+		t.Fatal("failed to receive header, err =", err)
+	}
+
+	// 閉じるとストリームが終わり、GracefulStop は待たされない
+	events.Close()
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("stream must be unavailable, given = %v", err)
+	}
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Error("GracefulStop must not wait for the closed stream")
+	}
+}
+
+func TestWatchTODOs_Changes(t *testing.T) {
+	t.Parallel()
+
+	client, svc := newClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchTODOs(ctx, &todopb.WatchTODOsRequest{})
+	if err != nil {
+		t.Fatal("failed to watch todos, err =", err)
+	}
+	if _, err := stream.Header(); err != nil {
+		t.Fatal("failed to receive header, err =", err)
+	}
+
+	type event struct {
+		typ     todopb.TODOEvent_Type
+		subject string
+	}
+	// expect は step の操作で届いたイベントを、次の操作の前にすべて確かめる
+	expect := func(step string, want ...event) []*todopb.TODO {
+		t.Helper()
+		var todos []*todopb.TODO
+		for _, w := range want {
+			given, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("%s: failed to receive event, err = %v", step, err)
+			}
+			if given.Type != w.typ || given.Todo.GetSubject() != w.subject {
+				t.Errorf("%s: unexpected event, given = %v, expected = %v", step, given, w)
+			}
+			todos = append(todos, given.Todo)
+		}
+		return todos
+	}
+	created := func(subject string) event { return event{todopb.TODOEvent_TYPE_CREATED, subject} }
+	updated := func(subject string) event { return event{todopb.TODOEvent_TYPE_UPDATED, subject} }
+
+	dueAt := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	parent, err := svc.CreateTODO(ctx, "parent", "", service.WithDueAt(dueAt), service.WithRRule("FREQ=WEEKLY"))
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	first, err := svc.CreateTODO(ctx, "first", "", service.WithParent(parent.ID))
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	second, err := svc.CreateTODO(ctx, "second", "", service.WithParent(parent.ID))
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	expect("create", created("parent"), created("first"), created("second"))
+
+	if err := svc.ReorderChildren(ctx, parent.ID, []int64{second.ID, first.ID}); err != nil {
+		t.Fatal("failed to reorder todos, err =", err)
+	}
+	expect("reorder children", updated("second"), updated("first"))
+
+	if _, err := svc.MoveTODO(ctx, first.ID, parent.ID, 0); err != nil {
+		t.Fatal("failed to move todo, err =", err)
+	}
+	expect("move", updated("first"))
+
+	// 最後のサブタスクの完了で親が完了し、親の次の回がサブタスクと共に作成される
+	if _, err := svc.UpdateTODO(ctx, first.ID, "first", "", service.WithCompleted(true)); err != nil {
+		t.Fatal("failed to complete todo, err =", err)
+	}
+	expect("complete one subtask", updated("first"))
+	if _, err := svc.UpdateTODO(ctx, second.ID, "second", "", service.WithCompleted(true)); err != nil {
+		t.Fatal("failed to complete todo, err =", err)
+	}
+	todos := expect("complete last subtask", updated("second"), updated("parent"), created("parent"), created("second"), created("first"))
+	if todos[1].GetId() != parent.ID || todos[1].CompletedAt == nil || todos[1].Rrule != "" {
+		t.Errorf("parent must be completed by rollup, given = %v", todos[1])
+	}
+	if next := todos[2]; next.GetId() == parent.ID || !next.DueAt.AsTime().Equal(dueAt.AddDate(0, 0, 7)) || next.Rrule != "FREQ=WEEKLY" {
+		t.Errorf("next occurrence must be created, given = %v", next)
+	}
+
+	// サブタスクを未完了に戻すと親も未完了に戻る
+	if _, err := svc.UpdateTODO(ctx, first.ID, "first", "", service.WithCompleted(false)); err != nil {
+		t.Fatal("failed to reopen todo, err =", err)
+	}
+	expect("reopen subtask", updated("first"), updated("parent"))
+
+	rows := []*model.TODO{{ID: 1, Subject: "imported parent"}, {ID: 2, Subject: "imported child", ParentID: int64Ptr(1)}}
+	if _, err := svc.ImportTODO(ctx, func() (*model.TODO, error) {
+		if len(rows) == 0 {
+			return nil, io.EOF
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return row, nil
+	}, false); err != nil {
+		t.Fatal("failed to import todos, err =", err)
+	}
+	expect("import", created("imported parent"), created("imported child"))
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,5 @@
+// Package todopb contains the protobuf messages and the gRPC service of the TODO API
+// generated from todo.proto.
+package todopb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative todo.proto
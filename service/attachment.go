@@ -0,0 +1,367 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// A BlobStore stores the contents of attachments by their SHA-256 keys.
+// Putting the same content again must return the same key without storing it twice.
+type BlobStore interface {
+	Put(ctx context.Context, r io.Reader) (key string, size int64, err error)
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// DefaultMaxAttachmentSize is the default limit of the size of an attachment in bytes.
+const DefaultMaxAttachmentSize = 10 << 20
+
+// DefaultAllowedAttachmentTypes are the media types accepted as attachments by default.
+var DefaultAllowedAttachmentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp",
+	"text/plain", "application/pdf", "application/zip", "application/x-gzip",
+}
+
+// An AttachmentService implements CRUD of Attachment entities.
+type AttachmentService struct {
+	db    *sql.DB
+	blobs BlobStore
+
+	// MaxSize is the limit of the size of an attachment in bytes.
+	MaxSize int64
+	// AllowedTypes are the media types accepted as attachments.
+	// A type ending with "/*" such as "image/*" accepts all of its subtypes.
+	AllowedTypes []string
+}
+
+// NewAttachmentService returns new AttachmentService storing contents in blobs.
+func NewAttachmentService(db *sql.DB, blobs BlobStore) *AttachmentService {
+	return &AttachmentService{
+		db:           db,
+		blobs:        blobs,
+		MaxSize:      DefaultMaxAttachmentSize,
+		AllowedTypes: DefaultAllowedAttachmentTypes,
+	}
+}
+
+// attachmentColumns は scanAttachment で読み取るカラムの一覧
+const attachmentColumns = `id, todo_id, filename, content_type, size, sha256, created_at`
+
+// scanAttachment は attachmentColumns の順に並んだ行を添付ファイルとして読み取る
+func scanAttachment(row rowScanner) (*model.Attachment, error) {
+	var attachment model.Attachment
+	if err := row.Scan(&attachment.ID, &attachment.TODOID, &attachment.Filename, &attachment.ContentType,
+		&attachment.Size, &attachment.SHA256, &attachment.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// errAttachmentTooLarge は添付ファイルが MaxSize を超えた場合に読み取りを打ち切るためのエラー
+var errAttachmentTooLarge = errors.New("attachment is too large")
+
+// CreateAttachment attaches the content read from r to the TODO as filename.
+// The media type is detected from the content, not from the filename.
+func (s *AttachmentService) CreateAttachment(ctx context.Context, todoID int64, filename string, r io.Reader) (*model.Attachment, error) {
+	const (
+		insert  = `INSERT INTO attachments(todo_id, filename, content_type, size, sha256) VALUES(?, ?, ?, ?, ?)`
+		confirm = `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = ?`
+	)
+
+	filename, err := cleanFilename(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先頭の512バイトから内容の種類を判定する
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+	if n == 0 {
+		return nil, &model.ErrInvalidArgument{Field: "file", Reason: "must not be empty"}
+	}
+
+	contentType := http.DetectContentType(head)
+	if !s.allowed(contentType) {
+		return nil, &model.ErrInvalidArgument{Field: "file", Reason: fmt.Sprintf("content type %s is not allowed", contentType)}
+	}
+
+	// 書き込みロックを取得してから保存する。
+	// DeleteTODO などが同じ内容を参照されていないと判断して削除するのと入れ違いにならないようにするため。
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkExists(ctx, tx, todoID); err != nil {
+		return nil, err
+	}
+
+	body := &maxSizeReader{r: io.MultiReader(bytes.NewReader(head), r), remaining: s.MaxSize}
+	key, size, err := s.blobs.Put(ctx, body)
+	if errors.Is(err, errAttachmentTooLarge) {
+		return nil, &model.ErrInvalidArgument{Field: "file", Reason: fmt.Sprintf("must not exceed %d bytes", s.MaxSize)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, insert, todoID, filename, contentType, size, key)
+	if err != nil {
+		tx.Rollback()
+		// 保存した内容を参照する行は作られなかったので、他から参照されていなければ削除する
+		s.removeOrphanBlobs(ctx, []string{key})
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve attachment id: %w", err)
+	}
+
+	attachment, err := scanAttachment(tx.QueryRowContext(ctx, confirm, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve attachment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// ReadAttachments reads the attachments of the TODO on DB.
+func (s *AttachmentService) ReadAttachments(ctx context.Context, todoID int64) ([]*model.Attachment, error) {
+	const read = `SELECT ` + attachmentColumns + ` FROM attachments WHERE todo_id = ? ORDER BY id`
+
+	if err := checkExists(ctx, s.db, todoID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, read, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []*model.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// OpenAttachment reads the attachment of the TODO and opens its content.
+// The caller must close the returned content.
+func (s *AttachmentService) OpenAttachment(ctx context.Context, todoID, id int64) (*model.Attachment, io.ReadSeekCloser, error) {
+	const read = `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = ? AND todo_id = ?`
+
+	attachment, err := scanAttachment(s.db.QueryRowContext(ctx, read, id, todoID))
+	if err == sql.ErrNoRows {
+		return nil, nil, &model.ErrNotFound{Resource: "Attachment", ID: id}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.blobs.Open(ctx, attachment.SHA256)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, &model.ErrNotFound{Resource: "Attachment", ID: id}
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	return attachment, content, nil
+}
+
+// DeleteAttachments deletes the attachments of the TODO on DB by ids.
+// Contents no longer attached to any TODO are deleted from the BlobStore.
+func (s *AttachmentService) DeleteAttachments(ctx context.Context, todoID int64, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	const (
+		readKeys = `SELECT DISTINCT sha256 FROM attachments WHERE todo_id = ? AND id IN (%s)`
+		remove   = `DELETE FROM attachments WHERE todo_id = ? AND id IN (%s)`
+	)
+	placeholder := placeholders(len(ids))
+
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, todoID)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	keys, err := readStrings(ctx, tx, fmt.Sprintf(readKeys, placeholder), args...)
+	if err != nil {
+		return fmt.Errorf("failed to read attachments: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(remove, placeholder), args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachments: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if affected == 0 {
+		return &model.ErrNotFound{Resource: "Attachment"}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// 行の削除がコミットされてから内容を削除する
+	s.removeOrphanBlobs(ctx, keys)
+	return nil
+}
+
+// removeOrphanBlobs は removeOrphanBlobs を呼び、失敗した場合はログに残す
+func (s *AttachmentService) removeOrphanBlobs(ctx context.Context, keys []string) {
+	if err := removeOrphanBlobs(ctx, s.db, s.blobs, keys); err != nil {
+		logging.Warn(ctx, "failed to remove orphan attachment contents", "err", err)
+	}
+}
+
+// allowed は contentType が AllowedTypes に含まれるかを返す
+func (s *AttachmentService) allowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range s.AllowedTypes {
+		if t == mediaType || (strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(t, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanFilename はクライアントが送ったファイル名からディレクトリ部分を取り除く
+func cleanFilename(filename string) (string, error) {
+	filename = path.Base(strings.ReplaceAll(filename, `\`, "/"))
+	if filename == "." || filename == "/" || filename == ".." {
+		return "", &model.ErrInvalidArgument{Field: "filename", Reason: "must not be empty"}
+	}
+	if !utf8.ValidString(filename) || len(filename) > 255 {
+		return "", &model.ErrInvalidArgument{Field: "filename", Reason: "must be valid UTF-8 of at most 255 bytes"}
+	}
+	return filename, nil
+}
+
+// maxSizeReader は remaining バイトを超えて読み取ろうとすると errAttachmentTooLarge を返す io.Reader
+type maxSizeReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (r *maxSizeReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n, errAttachmentTooLarge
+	}
+	return n, err
+}
+
+// attachmentKeys は ids のTODOとそのサブタスクに添付されたファイルのキーを読み取る
+func attachmentKeys(ctx context.Context, q queryer, ids []int64) ([]string, error) {
+	const read = `WITH RECURSIVE subtree(id) AS (
+		SELECT id FROM todos WHERE id IN (%s)
+		UNION
+		SELECT t.id FROM todos t JOIN subtree s ON t.parent_id = s.id
+	) SELECT DISTINCT sha256 FROM attachments WHERE todo_id IN (SELECT id FROM subtree)`
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	keys, err := readStrings(ctx, q, fmt.Sprintf(read, placeholders(len(ids))), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachments: %w", err)
+	}
+	return keys, nil
+}
+
+// removeOrphanBlobs は keys のうち、どの添付ファイルからも参照されていない内容を BlobStore から削除する。
+// 参照していた行の削除をコミットした後に呼ぶ。コミット前に削除すると、ロールバックした場合に
+// 行だけが残って内容が失われるため。削除できずに内容が残るのは構わない。
+// 書き込みロックを取得してから参照を数えるので、同じ内容のアップロードとは入れ違いにならない。
+func removeOrphanBlobs(ctx context.Context, db *sql.DB, blobs BlobStore, keys []string) error {
+	const count = `SELECT COUNT(*) FROM attachments WHERE sha256 = ?`
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, key := range keys {
+		var refs int
+		if err := tx.QueryRowContext(ctx, count, key).Scan(&refs); err != nil {
+			return fmt.Errorf("failed to count attachment references: %w", err)
+		}
+		if refs > 0 {
+			continue
+		}
+		if err := blobs.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// readStrings は1カラムの文字列を返すクエリを実行し、文字列のスライスとして読み取る
+func readStrings(ctx context.Context, q queryer, query string, args ...interface{}) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
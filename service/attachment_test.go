@@ -0,0 +1,225 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/blob"
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestAttachmentCleanup(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	todoDB, err := db.NewDB(filepath.Join(dir, "attachment_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	blobs, err := blob.NewLocalStore(filepath.Join(dir, "blobs"))
+	if err != nil {
+		t.Fatal("failed to create blob store, err =", err)
+	}
+
+	ctx := context.Background()
+	todos := service.NewTODOService(todoDB, service.WithBlobStore(blobs))
+	attachments := service.NewAttachmentService(todoDB, blobs)
+
+	parent, err := todos.CreateTODO(ctx, "parent", "")
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	child, err := todos.CreateTODO(ctx, "child", "", service.WithParent(parent.ID))
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	other, err := todos.CreateTODO(ctx, "other", "")
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+
+	attach := func(todoID int64, content string) *model.Attachment {
+		t.Helper()
+		attachment, err := attachments.CreateAttachment(ctx, todoID, "dir/app.log", strings.NewReader(content))
+		if err != nil {
+			t.Fatal("failed to create attachment, err =", err)
+		}
+		return attachment
+	}
+
+	shared := attach(child.ID, "shared log")
+	only := attach(child.ID, "only log")
+	kept := attach(other.ID, "shared log")
+
+	if shared.SHA256 != kept.SHA256 {
+		t.Error("same contents have different keys")
+	}
+	if shared.Filename != "app.log" || !strings.HasPrefix(shared.ContentType, "text/plain") {
+		t.Errorf("unexpected attachment, given = %+v", shared)
+	}
+
+	// 親TODOを削除すると、サブタスクの添付ファイルのうち他から参照されていない内容だけが消える
+	if err := todos.DeleteTODO(ctx, []int64{parent.ID}); err != nil {
+		t.Fatal("failed to delete todo, err =", err)
+	}
+	if _, err := blobs.Open(ctx, only.SHA256); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("orphan content is not deleted, err = %v", err)
+	}
+
+	_, content, err := attachments.OpenAttachment(ctx, other.ID, kept.ID)
+	if err != nil {
+		t.Fatal("shared content is deleted, err =", err)
+	}
+	got, err := io.ReadAll(content)
+	content.Close()
+	if err != nil || string(got) != "shared log" {
+		t.Errorf("unexpected content, given = %q, err = %v", got, err)
+	}
+
+	if err := attachments.DeleteAttachments(ctx, other.ID, []int64{kept.ID}); err != nil {
+		t.Fatal("failed to delete attachment, err =", err)
+	}
+	if _, err := blobs.Open(ctx, kept.SHA256); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("content is not deleted, err = %v", err)
+	}
+}
+
+func TestAttachmentLimits(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	todoDB, err := db.NewDB(filepath.Join(dir, "attachment_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	blobs, err := blob.NewLocalStore(filepath.Join(dir, "blobs"))
+	if err != nil {
+		t.Fatal("failed to create blob store, err =", err)
+	}
+
+	ctx := context.Background()
+	todo, err := service.NewTODOService(todoDB).CreateTODO(ctx, "subject", "")
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+
+	attachments := service.NewAttachmentService(todoDB, blobs)
+	attachments.MaxSize = 1024
+	attachments.AllowedTypes = []string{"image/*"}
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 100)...)
+
+	cases := map[string]struct {
+		content []byte
+		field   string
+	}{
+		"Allowed type":    {content: png},
+		"Empty":           {content: nil, field: "file"},
+		"Not allowed":     {content: []byte("plain text"), field: "file"},
+		"Too large":       {content: append(png, make([]byte, 1024)...), field: "file"},
+		"Exactly maximum": {content: append(png, make([]byte, 1024-len(png))...)},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			_, err := attachments.CreateAttachment(ctx, todo.ID, "image.png", bytes.NewReader(c.content))
+			var invalid *model.ErrInvalidArgument
+			if c.field == "" {
+				if err != nil {
+					t.Fatal("unexpected error, err =", err)
+				}
+				return
+			}
+			if !errors.As(err, &invalid) || invalid.Field != c.field {
+				t.Fatalf("unexpected error, given = %v, expected invalid %s", err, c.field)
+			}
+		})
+	}
+
+	// 上限を超えたファイルの内容は残らない
+	files, err := filepath.Glob(filepath.Join(dir, "blobs", "*", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("unexpected stored files, given = %v", files)
+	}
+}
+
+func TestAttachmentCleanupAfterCommit(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	todoDB, err := db.NewDB(filepath.Join(dir, "attachment_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	local, err := blob.NewLocalStore(filepath.Join(dir, "blobs"))
+	if err != nil {
+		t.Fatal("failed to create blob store, err =", err)
+	}
+	// 内容を削除する時点で、参照していた行の削除が他の接続から見えている（コミット済みである）こと
+	var deleted int
+	blobs := &checkingBlobStore{BlobStore: local, delete: func(key string) {
+		var refs int
+		if err := todoDB.QueryRow(`SELECT COUNT(*) FROM attachments WHERE sha256 = ?`, key).Scan(&refs); err != nil {
+			t.Error("failed to count references, err =", err)
+		}
+		if refs != 0 {
+			t.Errorf("content is deleted before the rows are committed, references = %d", refs)
+		}
+		deleted++
+	}}
+
+	ctx := context.Background()
+	todos := service.NewTODOService(todoDB, service.WithBlobStore(blobs))
+	attachments := service.NewAttachmentService(todoDB, blobs)
+
+	todo, err := todos.CreateTODO(ctx, "subject", "")
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	first, err := attachments.CreateAttachment(ctx, todo.ID, "first.log", strings.NewReader("first"))
+	if err != nil {
+		t.Fatal("failed to create attachment, err =", err)
+	}
+	if _, err := attachments.CreateAttachment(ctx, todo.ID, "second.log", strings.NewReader("second")); err != nil {
+		t.Fatal("failed to create attachment, err =", err)
+	}
+
+	if err := attachments.DeleteAttachments(ctx, todo.ID, []int64{first.ID}); err != nil {
+		t.Fatal("failed to delete attachment, err =", err)
+	}
+	if err := todos.DeleteTODO(ctx, []int64{todo.ID}); err != nil {
+		t.Fatal("failed to delete todo, err =", err)
+	}
+	if deleted != 2 {
+		t.Errorf("unexpected number of deleted contents, given = %d, expected = 2", deleted)
+	}
+}
+
+// checkingBlobStore は内容を削除する前に delete を呼ぶ BlobStore
+type checkingBlobStore struct {
+	service.BlobStore
+	delete func(key string)
+}
+
+func (s *checkingBlobStore) Delete(ctx context.Context, key string) error {
+	s.delete(key)
+	return s.BlobStore.Delete(ctx, key)
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// A CalendarFeedService implements CRUD of CalendarFeed entities.
+type CalendarFeedService struct {
+	db *sql.DB
+}
+
+// NewCalendarFeedService returns new CalendarFeedService.
+func NewCalendarFeedService(db *sql.DB) *CalendarFeedService {
+	return &CalendarFeedService{
+		db: db,
+	}
+}
+
+// A FeedOption restricts the feeds read and revoked by ReadFeeds and DeleteFeeds.
+type FeedOption func(*feedOptions)
+
+type feedOptions struct {
+	name *string
+}
+
+// FeedsOf restricts the feeds to those issued to the user of name. The feeds of the other
+// users are treated as if they do not exist.
+func FeedsOf(name string) FeedOption {
+	return func(o *feedOptions) {
+		o.name = &name
+	}
+}
+
+// CreateFeed issues a new feed for the user of name and returns it with its secret token.
+// The token cannot be read again because only its hash is saved.
+func (s *CalendarFeedService) CreateFeed(ctx context.Context, name string) (*model.CalendarFeed, string, error) {
+	const (
+		insert  = `INSERT INTO calendar_feeds(name, token_hash) VALUES(?, ?)`
+		confirm = `SELECT id, name, created_at FROM calendar_feeds WHERE id = ?`
+	)
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", &model.ErrInvalidArgument{Field: "name", Reason: "must not be empty"}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(secret)
+
+	res, err := s.db.ExecContext(ctx, insert, name, hashFeedToken(token))
+	if err != nil {
+		return nil, "", err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to retrieve feed id: %w", err)
+	}
+
+	var feed model.CalendarFeed
+	if err := s.db.QueryRowContext(ctx, confirm, id).Scan(&feed.ID, &feed.Name, &feed.CreatedAt); err != nil {
+		return nil, "", fmt.Errorf("failed to retrieve feed: %w", err)
+	}
+	return &feed, token, nil
+}
+
+// ReadFeeds reads all issued feeds without their tokens.
+func (s *CalendarFeedService) ReadFeeds(ctx context.Context, opts ...FeedOption) ([]*model.CalendarFeed, error) {
+	var o feedOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	query := `SELECT id, name, created_at FROM calendar_feeds`
+	var args []interface{}
+	if o.name != nil {
+		query += ` WHERE name = ?`
+		args = append(args, *o.name)
+	}
+	query += ` ORDER BY id`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feeds := []*model.CalendarFeed{}
+	for rows.Next() {
+		var feed model.CalendarFeed
+		if err := rows.Scan(&feed.ID, &feed.Name, &feed.CreatedAt); err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, &feed)
+	}
+	return feeds, rows.Err()
+}
+
+// DeleteFeeds revokes the feeds by ids.
+func (s *CalendarFeedService) DeleteFeeds(ctx context.Context, ids []int64, opts ...FeedOption) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var o feedOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	query := fmt.Sprintf(`DELETE FROM calendar_feeds WHERE id IN (%s)`, placeholders(len(ids)))
+
+	args := make([]interface{}, 0, len(ids)+1)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	if o.name != nil {
+		query += ` AND name = ?`
+		args = append(args, *o.name)
+	}
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete feeds: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if affected == 0 {
+		return &model.ErrNotFound{Resource: "CalendarFeed"}
+	}
+
+	return nil
+}
+
+// FeedByToken reads the feed of the token, or returns model.ErrNotFound if the token is not issued.
+func (s *CalendarFeedService) FeedByToken(ctx context.Context, token string) (*model.CalendarFeed, error) {
+	const read = `SELECT id, name, created_at FROM calendar_feeds WHERE token_hash = ?`
+
+	var feed model.CalendarFeed
+	err := s.db.QueryRowContext(ctx, read, hashFeedToken(token)).Scan(&feed.ID, &feed.Name, &feed.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, &model.ErrNotFound{Resource: "CalendarFeed"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// hashFeedToken はDBに保存するトークンのハッシュを返す
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// A CommentService implements CRUD of Comment entities.
+type CommentService struct {
+	db *sql.DB
+}
+
+// NewCommentService returns new CommentService.
+func NewCommentService(db *sql.DB) *CommentService {
+	return &CommentService{
+		db: db,
+	}
+}
+
+// A CommentOption restricts the comments modified by UpdateComment and DeleteComments.
+type CommentOption func(*commentOptions)
+
+type commentOptions struct {
+	author *string
+}
+
+// ByAuthor allows modifying only the comments written by author. The others are rejected
+// with *model.ErrForbidden.
+func ByAuthor(author string) CommentOption {
+	return func(o *commentOptions) {
+		o.author = &author
+	}
+}
+
+// checkAuthor は o.author の指定がある場合に、ids のコメントがすべてその利用者のものか確かめる
+func checkAuthor(ctx context.Context, q queryer, o commentOptions, todoID int64, ids []int64) error {
+	if o.author == nil {
+		return nil
+	}
+
+	query := fmt.Sprintf(`SELECT id FROM comments WHERE todo_id = ? AND author <> ? AND id IN (%s) LIMIT 1`, placeholders(len(ids)))
+	args := make([]interface{}, 0, len(ids)+2)
+	args = append(args, todoID, *o.author)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	var id int64
+	switch err := q.QueryRowContext(ctx, query, args...).Scan(&id); err {
+	case nil:
+		return &model.ErrForbidden{Resource: "Comment", ID: id}
+	case sql.ErrNoRows:
+		return nil
+	default:
+		return fmt.Errorf("failed to check author of comments: %w", err)
+	}
+}
+
+// commentColumns は scanComment で読み取るカラムの一覧
+const commentColumns = `id, todo_id, author, body, created_at, updated_at`
+
+// scanComment は commentColumns の順に並んだ行をコメントとして読み取る
+func scanComment(row rowScanner) (*model.Comment, error) {
+	var comment model.Comment
+	if err := row.Scan(&comment.ID, &comment.TODOID, &comment.Author, &comment.Body, &comment.CreatedAt, &comment.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// CreateComment posts a comment on the TODO by author.
+func (s *CommentService) CreateComment(ctx context.Context, todoID int64, author, body string) (*model.Comment, error) {
+	const (
+		insert  = `INSERT INTO comments(todo_id, author, body) VALUES(?, ?, ?)`
+		confirm = `SELECT ` + commentColumns + ` FROM comments WHERE id = ?`
+	)
+
+	author, body = strings.TrimSpace(author), strings.TrimSpace(body)
+	if author == "" {
+		return nil, &model.ErrInvalidArgument{Field: "author", Reason: "must not be empty"}
+	}
+	if body == "" {
+		return nil, &model.ErrInvalidArgument{Field: "body", Reason: "must not be empty"}
+	}
+
+	if err := checkExists(ctx, s.db, todoID); err != nil {
+		return nil, err
+	}
+
+	res, err := s.db.ExecContext(ctx, insert, todoID, author, body)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve comment id: %w", err)
+	}
+
+	comment, err := scanComment(s.db.QueryRowContext(ctx, confirm, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve comment: %w", err)
+	}
+	return comment, nil
+}
+
+// ReadComments reads the comments of the TODO in the order they were posted.
+// Like ReadTODO, it returns at most size comments after the comment of prevID.
+func (s *CommentService) ReadComments(ctx context.Context, todoID, prevID, size int64) ([]*model.Comment, error) {
+	const read = `SELECT ` + commentColumns + ` FROM comments WHERE todo_id = ? AND id > ? ORDER BY id LIMIT ?`
+
+	if err := checkExists(ctx, s.db, todoID); err != nil {
+		return nil, err
+	}
+
+	comments := []*model.Comment{}
+	if size <= 0 {
+		return comments, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, read, todoID, prevID, size)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// ReadCommentsOf reads all the comments of the TODOs of todoIDs at once,
+// ordered by their TODO IDs and then in the order they were posted.
+func (s *CommentService) ReadCommentsOf(ctx context.Context, todoIDs []int64) ([]*model.Comment, error) {
+	comments := []*model.Comment{}
+	if len(todoIDs) == 0 {
+		return comments, nil
+	}
+
+	query := `SELECT ` + commentColumns + ` FROM comments WHERE todo_id IN (` + placeholders(len(todoIDs)) + `) ORDER BY todo_id, id`
+	args := make([]interface{}, len(todoIDs))
+	for i, id := range todoIDs {
+		args[i] = id
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// UpdateComment edits the body of the comment of the TODO. The author is not changed.
+func (s *CommentService) UpdateComment(ctx context.Context, todoID, id int64, body string, opts ...CommentOption) (*model.Comment, error) {
+	const (
+		update  = `UPDATE comments SET body = ? WHERE id = ? AND todo_id = ?`
+		confirm = `SELECT ` + commentColumns + ` FROM comments WHERE id = ?`
+	)
+
+	var o commentOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, &model.ErrInvalidArgument{Field: "body", Reason: "must not be empty"}
+	}
+
+	// 投稿者の確認と編集の間に他の変更が入らないよう、同じトランザクションで行う
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkAuthor(ctx, tx, o, todoID, []int64{id}); err != nil {
+		return nil, err
+	}
+
+	res, err := tx.ExecContext(ctx, update, body, id, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if affected == 0 {
+		return nil, &model.ErrNotFound{Resource: "Comment", ID: id}
+	}
+
+	comment, err := scanComment(tx.QueryRowContext(ctx, confirm, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve updated comment: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// DeleteComments deletes the comments of the TODO on DB by ids.
+func (s *CommentService) DeleteComments(ctx context.Context, todoID int64, ids []int64, opts ...CommentOption) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var o commentOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	query := fmt.Sprintf(`DELETE FROM comments WHERE todo_id = ? AND id IN (%s)`, placeholders(len(ids)))
+
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, todoID)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 他人のコメントが1件でも含まれる場合は、どのコメントも削除しない
+	if err := checkAuthor(ctx, tx, o, todoID, ids); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete comments: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if affected == 0 {
+		return &model.ErrNotFound{Resource: "Comment"}
+	}
+
+	return tx.Commit()
+}
+
+// loadCommentCounts は todos のコメントの数を1回のクエリでまとめて読み込む
+func loadCommentCounts(ctx context.Context, q queryer, todos []*model.TODO) error {
+	const read = `SELECT todo_id, COUNT(*) FROM comments WHERE todo_id IN (%s) GROUP BY todo_id`
+
+	if len(todos) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.TODO, len(todos))
+	args := make([]interface{}, len(todos))
+	for i, todo := range todos {
+		byID[todo.ID] = todo
+		args[i] = todo.ID
+	}
+
+	rows, err := q.QueryContext(ctx, fmt.Sprintf(read, placeholders(len(todos))), args...)
+	if err != nil {
+		return fmt.Errorf("failed to count comments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID, count int64
+		if err := rows.Scan(&todoID, &count); err != nil {
+			return err
+		}
+		if todo, ok := byID[todoID]; ok {
+			todo.CommentCount = count
+		}
+	}
+
+	return rows.Err()
+}
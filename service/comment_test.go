@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestReadComments(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "comment_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	todos := service.NewTODOService(todoDB)
+	comments := service.NewCommentService(todoDB)
+
+	todo, err := todos.CreateTODO(ctx, "subject", "")
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	for _, body := range []string{"first", "second", "third"} {
+		if _, err := comments.CreateComment(ctx, todo.ID, "author", body); err != nil {
+			t.Fatal("failed to create comment, err =", err)
+		}
+	}
+
+	// 前のページの最後のコメントのIDから続きを読み取る
+	var (
+		prevID int64
+		bodies []string
+	)
+	for {
+		page, err := comments.ReadComments(ctx, todo.ID, prevID, 2)
+		if err != nil {
+			t.Fatal("failed to read comments, err =", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, comment := range page {
+			bodies = append(bodies, comment.Body)
+		}
+		prevID = page[len(page)-1].ID
+	}
+	if len(bodies) != 3 || bodies[0] != "first" || bodies[2] != "third" {
+		t.Errorf("unexpected comments, given = %v", bodies)
+	}
+
+	read, err := todos.ReadTODO(ctx, 0, 10)
+	if err != nil {
+		t.Fatal("failed to read todos, err =", err)
+	}
+	if read[0].CommentCount != 3 {
+		t.Errorf("unexpected comment count, given = %d, expected = %d", read[0].CommentCount, 3)
+	}
+}
+
+func TestComment_ByAuthor(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "comment_author_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	todos := service.NewTODOService(todoDB)
+	comments := service.NewCommentService(todoDB)
+
+	todo, err := todos.CreateTODO(ctx, "subject", "")
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	create := func(author string) int64 {
+		t.Helper()
+		comment, err := comments.CreateComment(ctx, todo.ID, author, "body")
+		if err != nil {
+			t.Fatal("failed to create comment, err =", err)
+		}
+		return comment.ID
+	}
+	alice1, alice2, bob := create("alice"), create("alice"), create("bob")
+
+	// 削除するケースがあるので、順に実行する
+	cases := []struct {
+		name      string
+		update    int64
+		delete    []int64
+		opts      []service.CommentOption
+		forbidden bool
+	}{
+		{name: "Update without author", update: bob},
+		{name: "Update by author", update: alice1, opts: []service.CommentOption{service.ByAuthor("alice")}},
+		{name: "Update by other", update: bob, opts: []service.CommentOption{service.ByAuthor("alice")}, forbidden: true},
+		{name: "Delete by other", delete: []int64{bob}, opts: []service.CommentOption{service.ByAuthor("alice")}, forbidden: true},
+		{name: "Delete including other", delete: []int64{alice1, bob}, opts: []service.CommentOption{service.ByAuthor("alice")}, forbidden: true},
+		{name: "Delete by author", delete: []int64{alice1, alice2}, opts: []service.CommentOption{service.ByAuthor("alice")}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var err error
+			if c.delete == nil {
+				_, err = comments.UpdateComment(ctx, todo.ID, c.update, "edited", c.opts...)
+			} else {
+				err = comments.DeleteComments(ctx, todo.ID, c.delete, c.opts...)
+			}
+
+			var forbidden *model.ErrForbidden
+			if c.forbidden != errors.As(err, &forbidden) {
+				t.Fatalf("unexpected error, given = %v, forbidden expected = %t", err, c.forbidden)
+			}
+			if !c.forbidden && err != nil {
+				t.Fatal("unexpected error, err =", err)
+			}
+		})
+	}
+
+	// 拒否された削除ではどのコメントも削除されない
+	read, err := comments.ReadComments(ctx, todo.ID, 0, 10)
+	if err != nil {
+		t.Fatal("failed to read comments, err =", err)
+	}
+	if len(read) != 1 || read[0].ID != bob {
+		t.Errorf("only the comment of the other must remain, given = %+v", read)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/markdown"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// descriptionCacheSize は変換した HTML を保持しておくTODOの最大数
+const descriptionCacheSize = 1000
+
+// descriptionCache は説明を HTML に変換した結果をTODOごとに保持する LRU キャッシュ。
+// 説明を変更すると updated_at も変わるので、同じ updated_at の間は変換結果を使い回す
+type descriptionCache struct {
+	renderer *markdown.Renderer
+
+	mu      sync.Mutex
+	entries map[int64]*list.Element
+	lru     *list.List // 先頭ほど最近使われたもの
+}
+
+type descriptionEntry struct {
+	id          int64
+	updatedAt   time.Time
+	description string // updated_at は秒単位なので、同じ秒の変更を見分けるために元の文字列も比べる
+	html        string
+}
+
+func newDescriptionCache() *descriptionCache {
+	return &descriptionCache{
+		renderer: markdown.NewRenderer(),
+		entries:  map[int64]*list.Element{},
+		lru:      list.New(),
+	}
+}
+
+// RenderDescriptionHTML sets DescriptionHTML of the TODOs to their descriptions
+// rendered from CommonMark into sanitized HTML, like WithDescriptionHTML.
+func (s *TODOService) RenderDescriptionHTML(todos []*model.TODO) error {
+	return s.descriptions.render(todos)
+}
+
+// render はTODOの説明を HTML に変換して DescriptionHTML に設定する
+func (c *descriptionCache) render(todos []*model.TODO) error {
+	for _, todo := range todos {
+		if html, ok := c.get(todo); ok {
+			todo.DescriptionHTML = html
+			continue
+		}
+		html, err := c.renderer.Render(todo.Description)
+		if err != nil {
+			return err
+		}
+		todo.DescriptionHTML = html
+		c.put(todo, html)
+	}
+	return nil
+}
+
+func (c *descriptionCache) get(todo *model.TODO) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[todo.ID]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*descriptionEntry)
+	if !entry.updatedAt.Equal(todo.UpdatedAt) || entry.description != todo.Description {
+		return "", false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.html, true
+}
+
+func (c *descriptionCache) put(todo *model.TODO, html string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &descriptionEntry{id: todo.ID, updatedAt: todo.UpdatedAt, description: todo.Description, html: html}
+	// 古い updated_at の変換結果は置き換える
+	if elem, ok := c.entries[todo.ID]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[todo.ID] = c.lru.PushFront(entry)
+	if c.lru.Len() > descriptionCacheSize {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*descriptionEntry).id)
+	}
+}
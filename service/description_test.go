@@ -0,0 +1,58 @@
+package service_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestReadTODOWithDescriptionHTML(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "description_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := service.NewTODOService(todoDB)
+
+	todo, err := svc.CreateTODO(ctx, "subject", "- [x] **done**")
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+
+	read := func() string {
+		todos, err := svc.ReadTODO(ctx, 0, 10, service.WithDescriptionHTML())
+		if err != nil {
+			t.Fatal("failed to read todos, err =", err)
+		}
+		return todos[0].DescriptionHTML
+	}
+
+	if got := read(); !strings.Contains(got, `type="checkbox"`) || !strings.Contains(got, "<strong>done</strong>") {
+		t.Errorf("unexpected description_html, given = %q", got)
+	}
+
+	// 同じ秒のうちに変更しても古い変換結果を返さない
+	if _, err := svc.UpdateTODO(ctx, todo.ID, "subject", "<script>alert(1)</script>\n\n*changed*"); err != nil {
+		t.Fatal("failed to update todo, err =", err)
+	}
+	if got := read(); strings.Contains(got, "<script") || !strings.Contains(got, "<em>changed</em>") {
+		t.Errorf("unexpected description_html, given = %q", got)
+	}
+
+	// 指定しない場合は HTML を返さない
+	todos, err := svc.ReadTODO(ctx, 0, 10)
+	if err != nil {
+		t.Fatal("failed to read todos, err =", err)
+	}
+	if todos[0].DescriptionHTML != "" {
+		t.Errorf("description_html must be empty, given = %q", todos[0].DescriptionHTML)
+	}
+}
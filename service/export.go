@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// importBatchSize は ImportTODO で一度に読み込んでから保存する行数
+const importBatchSize = 500
+
+// maxImportErrors を超える数の行が不正な場合は、残りの行を確認せずに中断する
+const maxImportErrors = 100
+
+// ExportTODO calls fn for every TODO with its tags, reading them one by one from DB.
+// Parents are exported before their subtasks, and TODOs of the same depth are in the list order.
+func (s *TODOService) ExportTODO(ctx context.Context, fn func(*model.TODO) error) error {
+	ctx, span := tracer.Start(ctx, "TODOService.ExportTODO")
+	defer span.End()
+
+	// 行ごとにタグを読み込むとクエリが増えるので、制御文字の US（0x1F）でつなげて同じ行で読み取る
+	const read = `WITH RECURSIVE tree(id, depth) AS (
+		SELECT id, 0 FROM todos WHERE parent_id IS NULL
+		UNION ALL
+		SELECT t.id, tree.depth + 1 FROM todos t JOIN tree ON t.parent_id = tree.id
+	) SELECT ` + todoColumns + `,
+		(SELECT COALESCE(GROUP_CONCAT(name, char(31)), '') FROM (SELECT tg.name FROM todo_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tt.todo_id = todos.id ORDER BY tg.name))
+	FROM tree JOIN todos USING(id) ORDER BY tree.depth, todos.position, todos.id DESC`
+
+	rows, err := s.db.QueryContext(ctx, read)
+	if err != nil {
+		return fmt.Errorf("failed to read todos: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			todo        model.TODO
+			parentID    sql.NullInt64
+			completedAt sql.NullTime
+			dueAt       sql.NullTime
+			tags        string
+		)
+		if err := rows.Scan(&todo.ID, &todo.Subject, &todo.Description, &todo.CreatedAt, &todo.UpdatedAt,
+			&parentID, &completedAt, &dueAt, &todo.RRule, &todo.Timezone, &tags); err != nil {
+			return err
+		}
+		if parentID.Valid {
+			todo.ParentID = &parentID.Int64
+		}
+		if completedAt.Valid {
+			todo.CompletedAt = &completedAt.Time
+		}
+		if dueAt.Valid {
+			todo.DueAt = &dueAt.Time
+		}
+		if tags != "" {
+			todo.Tags = strings.Split(tags, "\x1f")
+		}
+
+		if err := fn(&todo); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ImportTODO creates TODOs read by next until it returns io.EOF, all in one transaction.
+// If next returns *model.ErrInvalidArgument, the row is reported as invalid and the
+// import continues with the next row; other errors abort the import.
+//
+// IDs of the rows are only used to resolve parent_id between the rows, and the created
+// TODOs get new IDs. The TODOs are placed at the top of the list in the order of the rows.
+// Nothing is saved if any row is invalid or dryRun is true.
+func (s *TODOService) ImportTODO(ctx context.Context, next func() (*model.TODO, error), dryRun bool) (*model.ImportTODOResponse, error) {
+	ctx, span := tracer.Start(ctx, "TODOService.ImportTODO")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	im, err := newImporter(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ImportTODOResponse{DryRun: dryRun, Errors: []*model.ImportTODOError{}}
+	batch := make([]*model.TODO, 0, importBatchSize)
+	rowErrors := map[int]error{}
+	row := 0
+
+	// 読み込んだ行を importBatchSize 行ずつまとめて保存する
+	flush := func() error {
+		positions, err := im.positions(len(batch))
+		if err != nil {
+			return err
+		}
+		first := row - len(batch) + 1
+		for i, todo := range batch {
+			if err, ok := rowErrors[first+i]; ok {
+				result.Errors = append(result.Errors, &model.ImportTODOError{Row: first + i, Error: err.Error()})
+				continue
+			}
+			err := im.create(ctx, todo, positions[i])
+			var invalid *model.ErrInvalidArgument
+			if errors.As(err, &invalid) {
+				result.Errors = append(result.Errors, &model.ImportTODOError{Row: first + i, Error: err.Error()})
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("row %d: %w", first+i, err)
+			}
+			result.Imported++
+		}
+		batch = batch[:0]
+		rowErrors = map[int]error{}
+		return nil
+	}
+
+	for len(result.Errors)+len(rowErrors) < maxImportErrors {
+		todo, err := next()
+		if err == io.EOF {
+			break
+		}
+		row++
+
+		var invalid *model.ErrInvalidArgument
+		if errors.As(err, &invalid) {
+			rowErrors[row] = err
+			todo = nil
+		} else if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+
+		batch = append(batch, todo)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if len(result.Errors) > 0 {
+		result.Imported = 0
+		return result, nil
+	}
+
+	if err := im.finish(ctx); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.publishChanges(ctx, &im.changes)
+	return result, nil
+}
+
+// importer は ImportTODO で行をTODOとして保存する
+type importer struct {
+	tx *sql.Tx
+
+	ids       map[int64]int64 // 行のIDから作成したTODOのIDへの対応
+	position  string          // 直前に振った並び順のキー
+	first     string          // インポート前の先頭のTODOの並び順のキー
+	completed []importedTime  // 最後に設定する完了日時
+	created   []importedTime  // 最後に設定する作成日時と更新日時
+	changes   todoChanges     // コミット後に watcher へ伝える作成したTODO
+}
+
+type importedTime struct {
+	id                   int64
+	createdAt, updatedAt time.Time
+	completedAt          time.Time
+}
+
+func newImporter(ctx context.Context, tx *sql.Tx) (*importer, error) {
+	const read = `SELECT COALESCE(MIN(position), ''), COUNT(*) FROM todos`
+
+	var (
+		first string
+		count int
+	)
+	if err := tx.QueryRowContext(ctx, read).Scan(&first, &count); err != nil {
+		return nil, fmt.Errorf("failed to read positions: %w", err)
+	}
+	// キーが振られていないTODOがあると前に並べられないので、先に振り直す
+	if first == "" && count > 0 {
+		if err := rebalancePositions(ctx, tx); err != nil {
+			return nil, err
+		}
+		if err := tx.QueryRowContext(ctx, read).Scan(&first, &count); err != nil {
+			return nil, fmt.Errorf("failed to read positions: %w", err)
+		}
+	}
+
+	return &importer{tx: tx, ids: map[int64]int64{}, first: first}, nil
+}
+
+// positions は次の n 行の並び順のキーを、直前に振ったキーとインポート前の先頭のキーの間にまとめて振る
+func (im *importer) positions(n int) ([]string, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	keys, err := positionsBetween(im.position, im.first, n)
+	if err != nil {
+		return nil, err
+	}
+	im.position = keys[n-1]
+	return keys, nil
+}
+
+// create は1行分のTODOを position の位置に作成する。不正な行の場合は途中まで保存した内容を取り消す
+func (im *importer) create(ctx context.Context, todo *model.TODO, position string) (err error) {
+	if _, err := im.tx.ExecContext(ctx, `SAVEPOINT import_row`); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			im.tx.ExecContext(ctx, `ROLLBACK TO import_row`)
+		}
+		im.tx.ExecContext(ctx, `RELEASE import_row`)
+	}()
+
+	if todo.Subject == "" {
+		return &model.ErrInvalidArgument{Field: "subject", Reason: "must not be empty"}
+	}
+	if _, ok := im.ids[todo.ID]; ok && todo.ID != 0 {
+		return &model.ErrInvalidArgument{Field: "id", Reason: fmt.Sprintf("id %d is duplicated", todo.ID)}
+	}
+
+	o := todoOptions{tags: todo.Tags, setTags: len(todo.Tags) > 0, dueAt: todo.DueAt}
+	if todo.ParentID != nil {
+		// 親は先に現れた行のみ参照できる
+		parentID, ok := im.ids[*todo.ParentID]
+		if !ok {
+			return &model.ErrInvalidArgument{Field: "parent_id", Reason: fmt.Sprintf("row of id %d must appear before this row", *todo.ParentID)}
+		}
+		o.parentID = &parentID
+	}
+	if todo.RRule != "" || todo.Timezone != "" {
+		o.rrule, o.timezone = &todo.RRule, &todo.Timezone
+	}
+
+	o.position = &position
+
+	id, err := createTODO(ctx, im.tx, todo.Subject, todo.Description, &o, &im.changes)
+	if err != nil {
+		return err
+	}
+
+	if todo.ID != 0 {
+		im.ids[todo.ID] = id
+	}
+
+	// 完了日時はサブタスクの完了状態の集計で変わらないよう、すべての行を作成してから設定する
+	if todo.CompletedAt != nil {
+		im.completed = append(im.completed, importedTime{id: id, completedAt: *todo.CompletedAt})
+	}
+	if !todo.CreatedAt.IsZero() || !todo.UpdatedAt.IsZero() {
+		im.created = append(im.created, importedTime{id: id, createdAt: todo.CreatedAt, updatedAt: todo.UpdatedAt})
+	}
+	return nil
+}
+
+// finish は行に指定された日時を設定し、長くなった並び順のキーを振り直す
+func (im *importer) finish(ctx context.Context) error {
+	const (
+		complete = `UPDATE todos SET completed_at = ? WHERE id = ?`
+		// 更新日時は完了日時の設定で変わるので後から設定する
+		created = `UPDATE todos SET created_at = COALESCE(?, created_at), updated_at = COALESCE(?, updated_at) WHERE id = ?`
+		check   = `SELECT COUNT(*) FROM todos WHERE LENGTH(position) > ?`
+	)
+
+	for _, t := range im.completed {
+		if _, err := im.tx.ExecContext(ctx, complete, t.completedAt.UTC(), t.id); err != nil {
+			return fmt.Errorf("failed to update completion: %w", err)
+		}
+	}
+	for _, t := range im.created {
+		createdAt := sql.NullTime{Time: t.createdAt.UTC(), Valid: !t.createdAt.IsZero()}
+		updatedAt := sql.NullTime{Time: t.updatedAt.UTC(), Valid: !t.updatedAt.IsZero()}
+		if _, err := im.tx.ExecContext(ctx, created, createdAt, updatedAt, t.id); err != nil {
+			return fmt.Errorf("failed to update timestamps: %w", err)
+		}
+	}
+
+	var count int
+	if err := im.tx.QueryRowContext(ctx, check, maxPositionLength).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check positions: %w", err)
+	}
+	if count > 0 {
+		return rebalancePositions(ctx, im.tx)
+	}
+	return nil
+}
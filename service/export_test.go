@@ -0,0 +1,184 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestExportImportTODO(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	src, err := db.NewDB(filepath.Join(dir, "src.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { src.Close() })
+
+	svc := service.NewTODOService(src)
+	parent, err := svc.CreateTODO(ctx, "parent", "description", service.WithTags([]string{"b", "a"}))
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	if _, err := svc.CreateTODO(ctx, "child", "", service.WithParent(parent.ID)); err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	if _, err := svc.CreateTODO(ctx, "sibling", ""); err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	if _, err := svc.UpdateTODO(ctx, parent.ID, "parent", "description", service.WithCompleted(true)); err != nil {
+		t.Fatal("failed to complete todo, err =", err)
+	}
+
+	var exported []*model.TODO
+	if err := svc.ExportTODO(ctx, func(todo *model.TODO) error {
+		exported = append(exported, todo)
+		return nil
+	}); err != nil {
+		t.Fatal("failed to export, err =", err)
+	}
+	if len(exported) != 3 || exported[2].Subject != "child" {
+		t.Fatalf("subtasks must follow their parents, given = %+v", exported)
+	}
+
+	dst, err := db.NewDB(filepath.Join(dir, "dst.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { dst.Close() })
+
+	rows := func() func() (*model.TODO, error) {
+		i := 0
+		return func() (*model.TODO, error) {
+			if i == len(exported) {
+				return nil, io.EOF
+			}
+			i++
+			return exported[i-1], nil
+		}
+	}
+
+	imported := service.NewTODOService(dst)
+	result, err := imported.ImportTODO(ctx, rows(), true)
+	if err != nil {
+		t.Fatal("failed to import, err =", err)
+	}
+	if result.Imported != 3 || len(result.Errors) != 0 {
+		t.Fatalf("unexpected dry run result, given = %+v", result)
+	}
+	if todos, _ := imported.ReadTODO(ctx, 0, 10); len(todos) != 0 {
+		t.Fatal("dry run must not create todos")
+	}
+
+	if _, err := imported.ImportTODO(ctx, rows(), false); err != nil {
+		t.Fatal("failed to import, err =", err)
+	}
+
+	todos, err := imported.ReadTODO(ctx, 0, 10)
+	if err != nil {
+		t.Fatal("failed to read todos, err =", err)
+	}
+	if len(todos) != 3 {
+		t.Fatalf("unexpected todos, given = %d", len(todos))
+	}
+	for i, todo := range todos {
+		want := exported[i]
+		if todo.Subject != want.Subject || !todo.CreatedAt.Equal(want.CreatedAt) || (todo.CompletedAt == nil) != (want.CompletedAt == nil) {
+			t.Errorf("unexpected todo, given = %+v, expected = %+v", todo, want)
+		}
+	}
+	if todos[1].Subject != "parent" || len(todos[1].Tags) != 2 {
+		t.Errorf("tags are not imported, given = %+v", todos[1])
+	}
+	if todos[2].ParentID == nil || *todos[2].ParentID != todos[1].ID {
+		t.Errorf("parent is not resolved, given = %+v", todos[2])
+	}
+}
+
+func TestImportTODOInvalidRows(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "import_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	missing := int64(99)
+	due := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	rows := []*model.TODO{
+		{ID: 1, Subject: "valid"},
+		{ID: 2, Subject: ""},
+		{ID: 3, Subject: "orphan", ParentID: &missing},
+		{ID: 1, Subject: "duplicated"},
+		{ID: 4, Subject: "recurring", DueAt: &due, RRule: "FREQ=NEVER"},
+	}
+
+	i := 0
+	svc := service.NewTODOService(todoDB)
+	result, err := svc.ImportTODO(context.Background(), func() (*model.TODO, error) {
+		if i == len(rows) {
+			return nil, io.EOF
+		}
+		i++
+		return rows[i-1], nil
+	}, false)
+	if err != nil {
+		t.Fatal("failed to import, err =", err)
+	}
+
+	if result.Imported != 0 || len(result.Errors) != 4 {
+		t.Fatalf("unexpected result, given = %+v", result)
+	}
+	for j, row := range []int{2, 3, 4, 5} {
+		if result.Errors[j].Row != row {
+			t.Errorf("unexpected error row, given = %d, expected = %d", result.Errors[j].Row, row)
+		}
+	}
+	if todos, _ := svc.ReadTODO(context.Background(), 0, 10); len(todos) != 0 {
+		t.Error("nothing must be created when some rows are invalid")
+	}
+}
+
+func TestExportTODODoesNotBlockWriters(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "export_lock_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := service.NewTODOService(todoDB)
+	for _, subject := range []string{"first", "second"} {
+		if _, err := svc.CreateTODO(ctx, subject, ""); err != nil {
+			t.Fatal("failed to create todo, err =", err)
+		}
+	}
+
+	// 遅いクライアントへの書き出しを模して、最初の行を書き出している間に TODO を作成する
+	created := false
+	err = svc.ExportTODO(ctx, func(todo *model.TODO) error {
+		if created {
+			return nil
+		}
+		created = true
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+		_, err := svc.CreateTODO(ctx, "created during export", "")
+		return err
+	})
+	if err != nil {
+		t.Fatal("writing during export must not fail, err =", err)
+	}
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// 並び順のキーは positionDigits の文字からなる 0 以上 1 未満の62進小数の小数部分を表す。
+// 文字列のバイト順がそのまま小数の大小と一致するので、SQLite の TEXT の比較で並び替えられる。
+// 末尾が '0' のキーは同じ値を表す別のキーができてしまうため作らない。
+const positionDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// maxPositionLength を超える長さのキーができた場合に RebalancePositions でキーを振り直す
+const maxPositionLength = 24
+
+// errPositionOrder は前後のキーの大小関係が正しくない場合のエラー
+var errPositionOrder = errors.New("position keys are not in order")
+
+// positionBetween は a と b の間に並ぶキーを返す。a が空文字列の場合は先頭、b が空文字列の場合は末尾を表す。
+func positionBetween(a, b string) (string, error) {
+	if b != "" && a >= b {
+		return "", errPositionOrder
+	}
+	if strings.HasSuffix(a, "0") || strings.HasSuffix(b, "0") {
+		return "", errPositionOrder
+	}
+	return positionMidpoint(a, b), nil
+}
+
+// positionMidpoint は positionBetween の本体。b が空文字列の場合は 1 を表す。
+func positionMidpoint(a, b string) string {
+	// 共通の接頭辞はそのまま残し、残りの部分の中間を求める
+	if b != "" {
+		n := 0
+		for n < len(b) && positionDigitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + positionMidpoint(rest, b[n:])
+		}
+	}
+
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(positionDigits, a[0])
+	}
+	digitB := len(positionDigits)
+	if b != "" {
+		digitB = strings.IndexByte(positionDigits, b[0])
+	}
+
+	// 1桁目の間に別の数字がある場合はその1桁で表せる
+	if digitB-digitA > 1 {
+		return string(positionDigits[(digitA+digitB+1)/2])
+	}
+
+	// 1桁目が隣り合う場合は、b を1桁目まで切り詰めるか、a の2桁目以降と末尾の間を求める
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(positionDigits[digitA]) + positionMidpoint(rest, "")
+}
+
+// positionDigitAt は a の i 文字目を返す。a が短い場合は '0' とみなす。
+func positionDigitAt(a string, i int) byte {
+	if i < len(a) {
+		return a[i]
+	}
+	return positionDigits[0]
+}
+
+// positionsBetween は a と b の間に並ぶ n 個のキーを返す。順に間を詰めていくとキーが1つごとに長くなるので、
+// 中央から二分して振り、キーの長さの増え方を n の対数に抑える。a と b の意味は positionBetween と同じ。
+func positionsBetween(a, b string, n int) ([]string, error) {
+	keys := make([]string, n)
+	var fill func(lo, hi int, a, b string) error
+	fill = func(lo, hi int, a, b string) error {
+		if lo >= hi {
+			return nil
+		}
+		mid := (lo + hi) / 2
+		key, err := positionBetween(a, b)
+		if err != nil {
+			return err
+		}
+		keys[mid] = key
+		if err := fill(lo, mid, a, key); err != nil {
+			return err
+		}
+		return fill(mid+1, hi, key, b)
+	}
+	if err := fill(0, n, a, b); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// positionsEvenly は n 個のキーを等間隔に生成する
+func positionsEvenly(n int) []string {
+	base := uint64(len(positionDigits))
+
+	// 隣り合うキーの間に少なくとも base 個分の余裕ができる桁数にする
+	width, space := 1, base
+	for space < uint64(n+1)*base {
+		width++
+		space *= base
+	}
+
+	step := space / uint64(n+1)
+	keys := make([]string, n)
+	for i := range keys {
+		v := step * uint64(i+1)
+		key := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			key[j] = positionDigits[v%base]
+			v /= base
+		}
+		keys[i] = strings.TrimRight(string(key), "0")
+	}
+	return keys
+}
+
+// RebalancePositions reassigns evenly spaced position keys to all TODOs keeping their
+// order, when some keys have grown longer than maxPositionLength or are not assigned yet.
+// It reports whether the keys were reassigned.
+func (s *TODOService) RebalancePositions(ctx context.Context) (bool, error) {
+	const check = `SELECT COUNT(*) FROM todos WHERE position = '' OR LENGTH(position) > ?`
+
+	ctx, span := tracer.Start(ctx, "TODOService.RebalancePositions")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRowContext(ctx, check, maxPositionLength).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check positions: %w", err)
+	}
+	if count == 0 {
+		return false, nil
+	}
+
+	if err := rebalancePositions(ctx, tx); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// rebalancePositions はすべてのTODOに現在の並び順どおりのキーを振り直す
+func rebalancePositions(ctx context.Context, q queryer) error {
+	const (
+		read   = `SELECT id FROM todos ORDER BY position, id DESC`
+		update = `UPDATE todos SET position = ? WHERE id = ?`
+	)
+
+	ids, err := readIDs(ctx, q, read)
+	if err != nil {
+		return fmt.Errorf("failed to read positions: %w", err)
+	}
+
+	for i, key := range positionsEvenly(len(ids)) {
+		if _, err := q.ExecContext(ctx, update, key, ids[i]); err != nil {
+			return fmt.Errorf("failed to update position: %w", err)
+		}
+	}
+	return nil
+}
+
+// firstPosition は一覧の先頭に追加するTODOのキーを返す。
+// キーが振られていないTODOがある場合は、それらと同じく空文字列にして ID の降順で先頭に並べる。
+func firstPosition(ctx context.Context, q queryer) (string, error) {
+	const read = `SELECT COALESCE(MIN(position), '') FROM todos`
+
+	var first string
+	if err := q.QueryRowContext(ctx, read).Scan(&first); err != nil {
+		return "", fmt.Errorf("failed to read positions: %w", err)
+	}
+	if first == "" {
+		return "", nil
+	}
+	return positionBetween("", first)
+}
+
+// MoveTODO moves the TODO of id right before the TODO of beforeID, or right after
+// the TODO of afterID. Exactly one of beforeID and afterID must be non-zero.
+// Only the position of the moved TODO is updated.
+func (s *TODOService) MoveTODO(ctx context.Context, id, beforeID, afterID int64) (*model.TODO, error) {
+	ctx, span := tracer.Start(ctx, "TODOService.MoveTODO")
+	defer span.End()
+
+	if (beforeID == 0) == (afterID == 0) {
+		return nil, &model.ErrInvalidArgument{Field: "before_id/after_id", Reason: "exactly one anchor is required"}
+	}
+	if beforeID == id || afterID == id {
+		return nil, &model.ErrInvalidArgument{Field: "before_id/after_id", Reason: "anchor must be another TODO"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkExists(ctx, tx, id); err != nil {
+		return nil, err
+	}
+
+	key, err := positionAround(ctx, tx, id, beforeID, afterID)
+	if errors.Is(err, errPositionOrder) {
+		// キーが振られていない、または重複している場合は振り直してから求める
+		if err := rebalancePositions(ctx, tx); err != nil {
+			return nil, err
+		}
+		key, err = positionAround(ctx, tx, id, beforeID, afterID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	const update = `UPDATE todos SET position = ? WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, update, key, id); err != nil {
+		return nil, fmt.Errorf("failed to update position: %w", err)
+	}
+
+	todo, err := readTODOByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.events.publish(&model.TODOEvent{Type: model.TODOEventUpdated, TODO: todo})
+	return todo, nil
+}
+
+// positionAround は id のTODOを除いた並びで、beforeID の直前または afterID の直後に入るキーを求める
+func positionAround(ctx context.Context, q queryer, id, beforeID, afterID int64) (string, error) {
+	const (
+		readPosition = `SELECT position FROM todos WHERE id = ?`
+		// 並び順は position の昇順、同じキーの場合は id の降順
+		readPrev = `SELECT position FROM todos WHERE id <> ? AND (position < ? OR (position = ? AND id > ?)) ORDER BY position DESC, id ASC LIMIT 1`
+		readNext = `SELECT position FROM todos WHERE id <> ? AND (position > ? OR (position = ? AND id < ?)) ORDER BY position ASC, id DESC LIMIT 1`
+	)
+
+	anchorID := beforeID
+	if afterID != 0 {
+		anchorID = afterID
+	}
+
+	var anchor string
+	err := q.QueryRowContext(ctx, readPosition, anchorID).Scan(&anchor)
+	if err == sql.ErrNoRows {
+		return "", &model.ErrInvalidArgument{Field: "before_id/after_id", Reason: (&model.ErrNotFound{Resource: "TODO", ID: anchorID}).Error()}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// アンカーの隣のTODOのキーを読み取る。隣がない場合は空文字列のまま（先頭または末尾）
+	var neighbor string
+	if beforeID != 0 {
+		err = q.QueryRowContext(ctx, readPrev, id, anchor, anchor, anchorID).Scan(&neighbor)
+	} else {
+		err = q.QueryRowContext(ctx, readNext, id, anchor, anchor, anchorID).Scan(&neighbor)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	if anchor == "" {
+		return "", errPositionOrder
+	}
+	if beforeID != 0 {
+		if err == nil && neighbor == "" {
+			return "", errPositionOrder
+		}
+		return positionBetween(neighbor, anchor)
+	}
+	return positionBetween(anchor, neighbor)
+}
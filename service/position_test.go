@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestPositionBetween(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		a, b string
+		err  error
+	}{
+		"First key":              {a: "", b: ""},
+		"Before first":           {a: "", b: "V"},
+		"After last":             {a: "V", b: ""},
+		"Adjacent digits":        {a: "1", b: "2"},
+		"Common prefix":          {a: "V1", b: "V2"},
+		"Shorter a":              {a: "V", b: "V01"},
+		"Before smallest digit":  {a: "", b: "1"},
+		"After largest digit":    {a: "z", b: ""},
+		"Not in order":           {a: "V", b: "A", err: errPositionOrder},
+		"Same key":               {a: "V", b: "V", err: errPositionOrder},
+		"Trailing zero is error": {a: "V0", b: "W", err: errPositionOrder},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := positionBetween(c.a, c.b)
+			if err != c.err {
+				t.Fatalf("unexpected error, given = %v, expected = %v", err, c.err)
+			}
+			if err != nil {
+				return
+			}
+			if got <= c.a || (c.b != "" && got >= c.b) {
+				t.Errorf("key is not between, given = %q, a = %q, b = %q", got, c.a, c.b)
+			}
+			if strings.HasSuffix(got, "0") {
+				t.Errorf("key has trailing zero, given = %q", got)
+			}
+		})
+	}
+}
+
+func TestPositionBetweenRepeatedly(t *testing.T) {
+	t.Parallel()
+
+	// 先頭への追加、末尾への追加、同じ位置への挿入を繰り返しても順序が保たれること
+	keys := []string{}
+	insert := func(i int) {
+		a, b := "", ""
+		if i > 0 {
+			a = keys[i-1]
+		}
+		if i < len(keys) {
+			b = keys[i]
+		}
+		key, err := positionBetween(a, b)
+		if err != nil {
+			t.Fatalf("unexpected error, given = %v, a = %q, b = %q", err, a, b)
+		}
+		keys = append(keys[:i], append([]string{key}, keys[i:]...)...)
+	}
+
+	for i := 0; i < 200; i++ {
+		insert(0)
+		insert(len(keys))
+		insert(len(keys) / 2)
+	}
+
+	if !sort.StringsAreSorted(keys) {
+		t.Fatal("keys are not sorted")
+	}
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] == keys[i] {
+			t.Fatalf("duplicated key, given = %q", keys[i])
+		}
+	}
+}
+
+func TestPositionsBetween(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		a, b string
+		n    int
+	}{
+		"Empty":        {n: 0},
+		"Whole range":  {n: 1000},
+		"Before first": {b: "V", n: 500},
+		"After last":   {a: "z", n: 500},
+		"Narrow gap":   {a: "V", b: "V1", n: 500},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			keys, err := positionsBetween(c.a, c.b, c.n)
+			if err != nil {
+				t.Fatal("unexpected error, err =", err)
+			}
+			if len(keys) != c.n {
+				t.Fatalf("unexpected length, given = %d, expected = %d", len(keys), c.n)
+			}
+			prev := c.a
+			for _, key := range keys {
+				if key <= prev || (c.b != "" && key >= c.b) || strings.HasSuffix(key, "0") {
+					t.Fatalf("invalid key, given = %q, prev = %q", key, prev)
+				}
+				// 二分して振るので、キーは前後のキーより数文字長くなるだけ
+				if len(key) > len(c.a)+len(c.b)+4 {
+					t.Fatalf("key is too long, given = %q", key)
+				}
+				prev = key
+			}
+		})
+	}
+
+	if _, err := positionsBetween("b", "a", 1); !errors.Is(err, errPositionOrder) {
+		t.Errorf("unexpected error, given = %v", err)
+	}
+}
+
+func TestPositionsEvenly(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []int{0, 1, 61, 62, 1000, 100000} {
+		keys := positionsEvenly(n)
+		if len(keys) != n {
+			t.Fatalf("unexpected length, given = %d, expected = %d", len(keys), n)
+		}
+		for i, key := range keys {
+			if key == "" || strings.HasSuffix(key, "0") {
+				t.Fatalf("invalid key, given = %q", key)
+			}
+			if i > 0 && keys[i-1] >= key {
+				t.Fatalf("keys are not in order, given = %q, %q", keys[i-1], key)
+			}
+			// 振り直したキーの間にはさらにキーを挿入できること
+			if i > 0 {
+				if _, err := positionBetween(keys[i-1], key); err != nil {
+					t.Fatalf("unexpected error, given = %v", err)
+				}
+			}
+		}
+	}
+}
+
+func TestMoveTODO(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "move_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := NewTODOService(todoDB)
+	ids := map[string]int64{}
+	for _, subject := range []string{"a", "b", "c", "d"} {
+		todo, err := svc.CreateTODO(ctx, subject, "")
+		if err != nil {
+			t.Fatal("failed to create todo, err =", err)
+		}
+		ids[subject] = todo.ID
+	}
+
+	// 前のケースで並び替えた結果から順に移動する。新しいTODOは先頭に並ぶので d, c, b, a から始まる
+	cases := []struct {
+		name              string
+		id                int64
+		beforeID, afterID int64
+		err               error
+		subjects          []string
+	}{
+		{name: "Before first", id: ids["a"], beforeID: ids["d"], subjects: []string{"a", "d", "c", "b"}},
+		{name: "After last", id: ids["d"], afterID: ids["b"], subjects: []string{"a", "c", "b", "d"}},
+		{name: "Before middle", id: ids["d"], beforeID: ids["b"], subjects: []string{"a", "c", "d", "b"}},
+		{name: "After middle", id: ids["a"], afterID: ids["c"], subjects: []string{"c", "a", "d", "b"}},
+		{name: "Same place", id: ids["a"], afterID: ids["c"], subjects: []string{"c", "a", "d", "b"}},
+		{name: "No anchor", id: ids["a"], err: &model.ErrInvalidArgument{}},
+		{name: "Both anchors", id: ids["a"], beforeID: ids["b"], afterID: ids["c"], err: &model.ErrInvalidArgument{}},
+		{name: "Self anchor", id: ids["a"], beforeID: ids["a"], err: &model.ErrInvalidArgument{}},
+		{name: "Anchor not found", id: ids["a"], beforeID: 9999, err: &model.ErrInvalidArgument{}},
+		{name: "TODO not found", id: 9999, beforeID: ids["a"], err: &model.ErrNotFound{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := svc.MoveTODO(ctx, c.id, c.beforeID, c.afterID)
+			switch c.err.(type) {
+			case nil:
+				if err != nil {
+					t.Fatal("unexpected error, err =", err)
+				}
+			case *model.ErrInvalidArgument:
+				var invalid *model.ErrInvalidArgument
+				if !errors.As(err, &invalid) {
+					t.Fatalf("unexpected error, given = %v, expected = %T", err, c.err)
+				}
+				return
+			case *model.ErrNotFound:
+				var notFound *model.ErrNotFound
+				if !errors.As(err, &notFound) {
+					t.Fatalf("unexpected error, given = %v, expected = %T", err, c.err)
+				}
+				return
+			}
+
+			if subjects := readSubjects(t, svc); !reflect.DeepEqual(subjects, c.subjects) {
+				t.Errorf("unexpected order, given = %v, expected = %v", subjects, c.subjects)
+			}
+		})
+	}
+}
+
+func TestRebalancePositions(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "rebalance_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := NewTODOService(todoDB)
+	ids := make([]int64, 3)
+	for i := range ids {
+		todo, err := svc.CreateTODO(ctx, "subject", "")
+		if err != nil {
+			t.Fatal("failed to create todo, err =", err)
+		}
+		ids[i] = todo.ID
+	}
+
+	// 空のテーブルに作成したTODOにはキーが振られていない
+	for i, expected := range []bool{true, false} {
+		rebalanced, err := svc.RebalancePositions(ctx)
+		if err != nil {
+			t.Fatal("failed to rebalance, err =", err)
+		}
+		if rebalanced != expected {
+			t.Errorf("unexpected rebalance of call %d, given = %t, expected = %t", i+1, rebalanced, expected)
+		}
+	}
+
+	// 同じ隙間への移動を繰り返すとキーが長くなる
+	first := ids[2]
+	for i := 0; i < 300; i++ {
+		if _, err := svc.MoveTODO(ctx, ids[i%2], 0, first); err != nil {
+			t.Fatal("failed to move todo, err =", err)
+		}
+	}
+	if maxPositionLengthOf(t, svc) <= maxPositionLength {
+		t.Fatal("keys must grow by moves")
+	}
+
+	before := readIDsInOrder(t, svc)
+	rebalanced, err := svc.RebalancePositions(ctx)
+	if err != nil {
+		t.Fatal("failed to rebalance, err =", err)
+	}
+	if !rebalanced {
+		t.Error("long keys must be reassigned")
+	}
+	if length := maxPositionLengthOf(t, svc); length > maxPositionLength {
+		t.Errorf("keys must be shortened, given = %d", length)
+	}
+	if after := readIDsInOrder(t, svc); !reflect.DeepEqual(after, before) {
+		t.Errorf("order must be kept, given = %v, expected = %v", after, before)
+	}
+}
+
+// readSubjects は一覧の並び順でTODOの件名を読み取る
+func readSubjects(t *testing.T, svc *TODOService) []string {
+	t.Helper()
+	todos, err := svc.ReadTODO(context.Background(), 0, 100)
+	if err != nil {
+		t.Fatal("failed to read todos, err =", err)
+	}
+	subjects := []string{}
+	for _, todo := range todos {
+		subjects = append(subjects, todo.Subject)
+	}
+	return subjects
+}
+
+// readIDsInOrder は一覧の並び順でTODOのIDを読み取る
+func readIDsInOrder(t *testing.T, svc *TODOService) []int64 {
+	t.Helper()
+	todos, err := svc.ReadTODO(context.Background(), 0, 100)
+	if err != nil {
+		t.Fatal("failed to read todos, err =", err)
+	}
+	ids := []int64{}
+	for _, todo := range todos {
+		ids = append(ids, todo.ID)
+	}
+	return ids
+}
+
+// maxPositionLengthOf は最も長い並び順のキーの長さを返す
+func maxPositionLengthOf(t *testing.T, svc *TODOService) int {
+	t.Helper()
+	var length int
+	if err := svc.db.QueryRow(`SELECT MAX(LENGTH(position)) FROM todos`).Scan(&length); err != nil {
+		t.Fatal("failed to read positions, err =", err)
+	}
+	return length
+}
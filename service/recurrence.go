@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/teambition/rrule-go"
+)
+
+// maxOccurrences は ReadOccurrences で一度に返す繰り返しの最大数
+const maxOccurrences = 100
+
+// maxRRuleIterations は繰り返しの規則を起点からたどる回数の上限。
+// FREQ=SECONDLY などの規則で起点から長い期間が過ぎていても、計算が終わるようにする
+const maxRRuleIterations = 100000
+
+// ReadOccurrences reads the due times of the next count occurrences of the recurring TODO,
+// starting from its current due time.
+func (s *TODOService) ReadOccurrences(ctx context.Context, id int64, count int) ([]time.Time, error) {
+	ctx, span := tracer.Start(ctx, "TODOService.ReadOccurrences")
+	defer span.End()
+
+	if count <= 0 || count > maxOccurrences {
+		return nil, &model.ErrInvalidArgument{Field: "count", Reason: fmt.Sprintf("must be between 1 and %d", maxOccurrences)}
+	}
+
+	rec, err := readRecurrence(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := []time.Time{}
+	if !rec.dueAt.Valid {
+		return occurrences, nil
+	}
+	if rec.rule == "" {
+		// 繰り返さないTODOは現在の期限のみ
+		return append(occurrences, rec.dueAt.Time), nil
+	}
+
+	r, loc, err := parseRRule(rec.rule, rec.start.Time, rec.timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := occurrencesAfter(r, rec.dueAt.Time.In(loc), true, count)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range next {
+		occurrences = append(occurrences, t.UTC())
+	}
+	return occurrences, nil
+}
+
+// occurrencesAfter は after より後（inc の場合は after を含む）の繰り返しを最大 count 個返す。
+// 起点から maxRRuleIterations 回たどっても見つからない場合はエラーを返す
+func occurrencesAfter(r *rrule.RRule, after time.Time, inc bool, count int) ([]time.Time, error) {
+	var occurrences []time.Time
+	next := r.Iterator()
+	for i := 0; len(occurrences) < count; i++ {
+		if i == maxRRuleIterations {
+			return nil, &model.ErrInvalidArgument{Field: "rrule", Reason: fmt.Sprintf("more than %d occurrences from the start to compute", maxRRuleIterations)}
+		}
+		t, ok := next()
+		if !ok {
+			break
+		}
+		if t.Before(after) || (!inc && t.Equal(after)) {
+			continue
+		}
+		occurrences = append(occurrences, t)
+	}
+	return occurrences, nil
+}
+
+// recurrence はTODOの期限と繰り返しの設定
+type recurrence struct {
+	dueAt    sql.NullTime
+	rule     string
+	start    sql.NullTime
+	timezone string
+}
+
+// readRecurrence は指定したIDのTODOの期限と繰り返しの設定を読み取る
+func readRecurrence(ctx context.Context, q queryer, id int64) (*recurrence, error) {
+	const read = `SELECT due_at, rrule, rrule_start, timezone FROM todos WHERE id = ?`
+
+	var rec recurrence
+	err := q.QueryRowContext(ctx, read, id).Scan(&rec.dueAt, &rec.rule, &rec.start, &rec.timezone)
+	if err == sql.ErrNoRows {
+		return nil, &model.ErrNotFound{Resource: "TODO", ID: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// writeRecurrence は指定したIDのTODOの期限と繰り返しの設定を保存する
+func writeRecurrence(ctx context.Context, q queryer, id int64, rec *recurrence) error {
+	const update = `UPDATE todos SET due_at = ?, rrule = ?, rrule_start = ?, timezone = ? WHERE id = ?`
+
+	// SQLite には UTC で保存する
+	dueAt, start := rec.dueAt, rec.start
+	dueAt.Time, start.Time = dueAt.Time.UTC(), start.Time.UTC()
+
+	if _, err := q.ExecContext(ctx, update, dueAt, rec.rule, start, rec.timezone, id); err != nil {
+		return fmt.Errorf("failed to update recurrence: %w", err)
+	}
+	return nil
+}
+
+// setRecurrence は o で指定された期限と繰り返しの設定を検証してTODOに反映する
+func setRecurrence(ctx context.Context, q queryer, id int64, o *todoOptions) error {
+	if o.dueAt == nil && o.rrule == nil && o.timezone == nil {
+		return nil
+	}
+
+	rec, err := readRecurrence(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	// 期限または規則が変わった場合は、新しい期限を繰り返しの起点にする
+	restart := !rec.start.Valid
+	if o.dueAt != nil {
+		rec.dueAt = sql.NullTime{Time: *o.dueAt, Valid: !o.dueAt.IsZero()}
+		restart = true
+	}
+	if o.rrule != nil {
+		rule := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(*o.rrule), "RRULE:"))
+		restart = restart || rule != rec.rule
+		rec.rule = rule
+	}
+	if o.timezone != nil {
+		rec.timezone = strings.TrimSpace(*o.timezone)
+	}
+
+	if _, err := loadLocation(rec.timezone); err != nil {
+		return err
+	}
+
+	// 期限が変わった場合は新しい期限に合わせて通知し直す
+	if o.dueAt != nil {
+		if err := resetReminders(ctx, q, id); err != nil {
+			return err
+		}
+	}
+
+	if rec.rule == "" {
+		rec.start = sql.NullTime{}
+		return writeRecurrence(ctx, q, id, rec)
+	}
+
+	if !rec.dueAt.Valid {
+		return &model.ErrInvalidArgument{Field: "rrule", Reason: "due_at is required for a recurring TODO"}
+	}
+	if restart {
+		rec.start = rec.dueAt
+	}
+	if _, _, err := parseRRule(rec.rule, rec.start.Time, rec.timezone); err != nil {
+		return err
+	}
+
+	return writeRecurrence(ctx, q, id, rec)
+}
+
+// createNextOccurrence は繰り返しのTODOの次の回を、件名・説明・タグ・親・サブタスクを引き継いで作成する。
+// 繰り返しの設定は次の回に移し、完了したTODOからは取り除く。どちらのTODOも changes に控える。
+func createNextOccurrence(ctx context.Context, q queryer, id int64, changes *todoChanges) error {
+	rec, err := readRecurrence(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	if rec.rule == "" || !rec.dueAt.Valid {
+		return nil
+	}
+
+	r, loc, err := parseRRule(rec.rule, rec.start.Time, rec.timezone)
+	if err != nil {
+		return err
+	}
+
+	next, err := occurrencesAfter(r, rec.dueAt.Time.In(loc), false, 1)
+	if err != nil {
+		return err
+	}
+
+	if err := writeRecurrence(ctx, q, id, &recurrence{dueAt: rec.dueAt, timezone: rec.timezone}); err != nil {
+		return err
+	}
+	changes.update(id)
+
+	// COUNT や UNTIL に達した場合は次の回を作らない
+	if len(next) == 0 {
+		return nil
+	}
+
+	todo, err := readTODOByID(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	o := todoOptions{tags: todo.Tags, setTags: len(todo.Tags) > 0, parentID: todo.ParentID}
+	nextID, err := createTODO(ctx, q, todo.Subject, todo.Description, &o, changes)
+	if err != nil {
+		return fmt.Errorf("failed to create next occurrence: %w", err)
+	}
+
+	if err := copyReminders(ctx, q, id, nextID); err != nil {
+		return err
+	}
+
+	// チェックリストとして使えるよう、サブタスクも未完了に戻して引き継ぐ
+	if err := copySubtasks(ctx, q, id, nextID, changes); err != nil {
+		return err
+	}
+
+	rec.dueAt = sql.NullTime{Time: next[0], Valid: true}
+	return writeRecurrence(ctx, q, nextID, rec)
+}
+
+// copySubtasks は from のサブタスクを孫まで、件名・説明・タグだけを引き継いで未完了のまま to の下に同じ順序で作成する。
+// 期限や繰り返し、リマインダーは回ごとに決めるものなので引き継がない。作成したTODOは changes に控える。
+func copySubtasks(ctx context.Context, q queryer, from, to int64, changes *todoChanges) error {
+	const readChildren = `SELECT id FROM todos WHERE parent_id = ? ORDER BY sort_order, id`
+
+	ids, err := readIDs(ctx, q, readChildren, from)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		child, err := readTODOByID(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		o := todoOptions{tags: child.Tags, setTags: len(child.Tags) > 0, parentID: &to}
+		copyID, err := createTODO(ctx, q, child.Subject, child.Description, &o, changes)
+		if err != nil {
+			return fmt.Errorf("failed to copy subtask: %w", err)
+		}
+		if err := copySubtasks(ctx, q, id, copyID, changes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isCompleted は指定したIDのTODOが完了しているかを返す
+func isCompleted(ctx context.Context, q queryer, id int64) (bool, error) {
+	const read = `SELECT completed_at IS NOT NULL FROM todos WHERE id = ?`
+
+	var completed bool
+	err := q.QueryRowContext(ctx, read, id).Scan(&completed)
+	if err == sql.ErrNoRows {
+		return false, &model.ErrNotFound{Resource: "TODO", ID: id}
+	}
+	return completed, err
+}
+
+// parseRRule は RRULE を start を起点として timezone のタイムゾーンで解釈する
+func parseRRule(rule string, start time.Time, timezone string) (*rrule.RRule, *time.Location, error) {
+	loc, err := loadLocation(timezone)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opt, err := rrule.StrToROptionInLocation(rule, loc)
+	if err != nil {
+		return nil, nil, &model.ErrInvalidArgument{Field: "rrule", Reason: err.Error()}
+	}
+	// 曜日や時刻はタイムゾーンでの表現で計算されるので、起点もタイムゾーンに合わせる
+	opt.Dtstart = start.In(loc)
+
+	r, err := rrule.NewRRule(*opt)
+	if err != nil {
+		return nil, nil, &model.ErrInvalidArgument{Field: "rrule", Reason: err.Error()}
+	}
+	return r, loc, nil
+}
+
+// loadLocation はタイムゾーン名からタイムゾーンを読み込む。空の場合は main で設定した time.Local を返す。
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, &model.ErrInvalidArgument{Field: "timezone", Reason: err.Error()}
+	}
+	return loc, nil
+}
@@ -0,0 +1,289 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestUpdateTODO_NextOccurrence(t *testing.T) {
+	t.Parallel()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal("failed to load location, err =", err)
+	}
+
+	cases := map[string]struct {
+		dueAt     time.Time
+		rrule     string
+		timezone  string
+		nexts     []time.Time // 完了するたびに作成される次の回の期限
+		exhausted bool        // nexts の最後の回で COUNT や UNTIL に達する
+	}{
+		"Weekly": {
+			dueAt: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC),
+			rrule: "FREQ=WEEKLY",
+			nexts: []time.Time{
+				time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 19, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		// 夏時間が始まっても現地時刻の 9 時のまま
+		"Daily across DST": {
+			dueAt:    time.Date(2026, 3, 7, 9, 0, 0, 0, newYork),
+			rrule:    "FREQ=DAILY",
+			timezone: "America/New_York",
+			nexts: []time.Time{
+				time.Date(2026, 3, 8, 13, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 9, 13, 0, 0, 0, time.UTC),
+			},
+		},
+		// 曜日はタイムゾーンでの日付で決まる（UTC では日曜日の 23 時）
+		"Weekday in time zone": {
+			dueAt:    time.Date(2026, 1, 4, 23, 0, 0, 0, time.UTC),
+			rrule:    "FREQ=WEEKLY;BYDAY=MO,TH",
+			timezone: "Asia/Tokyo",
+			nexts: []time.Time{
+				time.Date(2026, 1, 7, 23, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 11, 23, 0, 0, 0, time.UTC),
+			},
+		},
+		"COUNT exhausted": {
+			dueAt: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC),
+			rrule: "FREQ=DAILY;COUNT=3",
+			nexts: []time.Time{
+				time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 3, 9, 0, 0, 0, time.UTC),
+			},
+			exhausted: true,
+		},
+		"UNTIL exhausted": {
+			dueAt: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC),
+			rrule: "FREQ=MONTHLY;UNTIL=20260301T000000Z",
+			nexts: []time.Time{
+				time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC),
+			},
+			exhausted: true,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "recurrence_test.db"))
+			if err != nil {
+				t.Fatal("failed to create db, err =", err)
+			}
+			t.Cleanup(func() { todoDB.Close() })
+
+			ctx := context.Background()
+			svc := service.NewTODOService(todoDB)
+			todo, err := svc.CreateTODO(ctx, "subject", "description", service.WithTags([]string{"chore"}),
+				service.WithDueAt(c.dueAt), service.WithRRule(c.rrule), service.WithTimezone(c.timezone))
+			if err != nil {
+				t.Fatal("failed to create todo, err =", err)
+			}
+
+			// COUNT や UNTIL に達した場合は、最後の回を完了しても次の回は作成されない
+			n := len(c.nexts)
+			if c.exhausted {
+				n++
+			}
+			for i := 0; i < n; i++ {
+				completed, err := svc.UpdateTODO(ctx, todo.ID, todo.Subject, todo.Description, service.WithCompleted(true))
+				if err != nil {
+					t.Fatal("failed to complete todo, err =", err)
+				}
+				if completed.RRule != "" {
+					t.Errorf("recurrence must be moved to the next occurrence, given = %q", completed.RRule)
+				}
+
+				next, err := svc.ReadTODO(ctx, 0, 1)
+				if err != nil {
+					t.Fatal("failed to read todos, err =", err)
+				}
+				if i == len(c.nexts) {
+					if next[0].ID != todo.ID {
+						t.Errorf("no occurrence must be created after the last one, given = %+v", next[0])
+					}
+					return
+				}
+
+				todo = next[0]
+				if todo.ID == completed.ID || todo.DueAt == nil || !todo.DueAt.Equal(c.nexts[i]) {
+					t.Fatalf("unexpected occurrence %d, given = %+v, expected due = %s", i+1, todo, c.nexts[i])
+				}
+				if todo.CompletedAt != nil || todo.RRule != c.rrule || todo.Timezone != c.timezone || len(todo.Tags) != 1 {
+					t.Errorf("occurrence must inherit the recurrence and tags, given = %+v", todo)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateTODO_NextOccurrenceByRollup(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "recurrence_rollup_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := service.NewTODOService(todoDB)
+	dueAt := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	parent, err := svc.CreateTODO(ctx, "parent", "", service.WithDueAt(dueAt), service.WithRRule("FREQ=WEEKLY"))
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	child, err := svc.CreateTODO(ctx, "child", "", service.WithParent(parent.ID))
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+
+	// 最後のサブタスクを完了すると親が完了し、親の次の回が作成される
+	if _, err := svc.UpdateTODO(ctx, child.ID, child.Subject, "", service.WithCompleted(true)); err != nil {
+		t.Fatal("failed to complete todo, err =", err)
+	}
+
+	todos, err := svc.ReadTODO(ctx, 0, 10)
+	if err != nil {
+		t.Fatal("failed to read todos, err =", err)
+	}
+	var next *model.TODO
+	for _, todo := range todos {
+		switch {
+		case todo.ID == parent.ID:
+			if todo.CompletedAt == nil || todo.RRule != "" {
+				t.Errorf("parent must be completed and stop recurring, given = %+v", todo)
+			}
+		case todo.Subject == "parent":
+			next = todo
+		}
+	}
+	if next == nil {
+		t.Fatalf("next occurrence must be created, given = %+v", todos)
+	}
+	if expected := dueAt.AddDate(0, 0, 7); next.DueAt == nil || !next.DueAt.Equal(expected) || next.RRule != "FREQ=WEEKLY" {
+		t.Errorf("unexpected occurrence, given = %+v, expected due = %s", next, expected)
+	}
+}
+
+func TestUpdateTODO_NextOccurrenceSubtasks(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "recurrence_subtasks_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := service.NewTODOService(todoDB)
+	parent, err := svc.CreateTODO(ctx, "checklist", "", service.WithDueAt(time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)), service.WithRRule("FREQ=WEEKLY"))
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	first, err := svc.CreateTODO(ctx, "first", "description", service.WithParent(parent.ID), service.WithTags([]string{"a"}))
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	if _, err := svc.CreateTODO(ctx, "second", "", service.WithParent(parent.ID), service.WithCompleted(true)); err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+	if _, err := svc.CreateTODO(ctx, "nested", "", service.WithParent(first.ID)); err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+
+	if _, err := svc.UpdateTODO(ctx, parent.ID, parent.Subject, parent.Description, service.WithCompleted(true)); err != nil {
+		t.Fatal("failed to complete todo, err =", err)
+	}
+	todos, err := svc.ReadTODO(ctx, 0, 10)
+	if err != nil {
+		t.Fatal("failed to read todos, err =", err)
+	}
+	var next *model.TODO
+	for _, todo := range todos {
+		if todo.ID != parent.ID && todo.Subject == "checklist" {
+			next = todo
+		}
+	}
+	if next == nil {
+		t.Fatalf("next occurrence must be created, given = %+v", todos)
+	}
+
+	// サブタスクは同じ順序で、孫まで未完了に戻して引き継ぐ
+	children, err := svc.ReadChildren(ctx, next.ID)
+	if err != nil {
+		t.Fatal("failed to read children, err =", err)
+	}
+	if len(children) != 2 || children[0].Subject != "first" || children[1].Subject != "second" {
+		t.Fatalf("unexpected subtasks, given = %+v", children)
+	}
+	for _, child := range children {
+		if child.ID == first.ID || child.CompletedAt != nil {
+			t.Errorf("subtask must be copied uncompleted, given = %+v", child)
+		}
+	}
+	if children[0].Description != "description" || len(children[0].Tags) != 1 {
+		t.Errorf("subtask must inherit the description and tags, given = %+v", children[0])
+	}
+	nested, err := svc.ReadChildren(ctx, children[0].ID)
+	if err != nil {
+		t.Fatal("failed to read children, err =", err)
+	}
+	if len(nested) != 1 || nested[0].Subject != "nested" || nested[0].CompletedAt != nil {
+		t.Errorf("unexpected nested subtasks, given = %+v", nested)
+	}
+
+	// 完了した回のサブタスクはそのまま残る
+	if children, err := svc.ReadChildren(ctx, parent.ID); err != nil || len(children) != 2 {
+		t.Errorf("subtasks of the completed occurrence must be kept, given = %+v, err = %v", children, err)
+	}
+}
+
+func TestReadOccurrences_IterationLimit(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "occurrences_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := service.NewTODOService(todoDB)
+	todo, err := svc.CreateTODO(ctx, "subject", "", service.WithDueAt(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)),
+		service.WithRRule("FREQ=SECONDLY"))
+	if err != nil {
+		t.Fatal("failed to create todo, err =", err)
+	}
+
+	occurrences, err := svc.ReadOccurrences(ctx, todo.ID, 3)
+	if err != nil {
+		t.Fatal("failed to read occurrences, err =", err)
+	}
+	if len(occurrences) != 3 || !occurrences[2].Equal(time.Date(2026, 1, 1, 0, 0, 2, 0, time.UTC)) {
+		t.Errorf("unexpected occurrences, given = %v", occurrences)
+	}
+
+	// 起点から長い期間が過ぎた回を求める場合は、たどる回数の上限でエラーにする
+	if _, err := todoDB.ExecContext(ctx, `UPDATE todos SET due_at = ? WHERE id = ?`, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), todo.ID); err != nil {
+		t.Fatal("failed to update due time, err =", err)
+	}
+	_, err = svc.ReadOccurrences(ctx, todo.ID, 3)
+	var invalid *model.ErrInvalidArgument
+	if !errors.As(err, &invalid) {
+		t.Errorf("unexpected error, given = %v, expected = %T", err, invalid)
+	}
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// A ReminderService implements CRUD of Reminder entities and records their delivery.
+type ReminderService struct {
+	db *sql.DB
+}
+
+// NewReminderService returns new ReminderService.
+func NewReminderService(db *sql.DB) *ReminderService {
+	return &ReminderService{
+		db: db,
+	}
+}
+
+// reminderColumns は scanReminder で読み取るカラムの一覧。reminders を r、todos を t として結合して使う
+const reminderColumns = `r.id, r.todo_id, r.before_seconds, r.status, r.attempts, r.last_error, r.next_attempt_at, r.sent_at, r.created_at, t.due_at`
+
+// scanReminder は reminderColumns の順に並んだ行をリマインダーとして読み取る
+func scanReminder(row rowScanner) (*model.Reminder, error) {
+	var (
+		reminder      model.Reminder
+		nextAttemptAt sql.NullTime
+		sentAt        sql.NullTime
+		dueAt         sql.NullTime
+	)
+	if err := row.Scan(&reminder.ID, &reminder.TODOID, &reminder.BeforeSeconds, &reminder.Status, &reminder.Attempts,
+		&reminder.LastError, &nextAttemptAt, &sentAt, &reminder.CreatedAt, &dueAt); err != nil {
+		return nil, err
+	}
+	if nextAttemptAt.Valid {
+		reminder.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if sentAt.Valid {
+		reminder.SentAt = &sentAt.Time
+	}
+	// 通知する日時はTODOの期限から計算する
+	if dueAt.Valid {
+		remindAt := dueAt.Time.Add(-time.Duration(reminder.BeforeSeconds) * time.Second)
+		reminder.RemindAt = &remindAt
+	}
+	return &reminder, nil
+}
+
+// CreateReminder creates a reminder of the TODO on DB.
+func (s *ReminderService) CreateReminder(ctx context.Context, todoID, beforeSeconds int64) (*model.Reminder, error) {
+	const (
+		insert  = `INSERT INTO reminders(todo_id, before_seconds) VALUES(?, ?)`
+		confirm = `SELECT ` + reminderColumns + ` FROM reminders r JOIN todos t ON t.id = r.todo_id WHERE r.id = ?`
+	)
+
+	if beforeSeconds < 0 {
+		return nil, &model.ErrInvalidArgument{Field: "before_seconds", Reason: "must not be negative"}
+	}
+
+	if err := checkExists(ctx, s.db, todoID); err != nil {
+		return nil, err
+	}
+
+	res, err := s.db.ExecContext(ctx, insert, todoID, beforeSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve reminder id: %w", err)
+	}
+
+	reminder, err := scanReminder(s.db.QueryRowContext(ctx, confirm, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve reminder: %w", err)
+	}
+	return reminder, nil
+}
+
+// ReadReminders reads the reminders of the TODO on DB.
+func (s *ReminderService) ReadReminders(ctx context.Context, todoID int64) ([]*model.Reminder, error) {
+	const read = `SELECT ` + reminderColumns + ` FROM reminders r JOIN todos t ON t.id = r.todo_id WHERE r.todo_id = ? ORDER BY r.before_seconds DESC, r.id`
+
+	if err := checkExists(ctx, s.db, todoID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, read, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []*model.Reminder{}
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+	return reminders, rows.Err()
+}
+
+// DeleteReminders deletes the reminders of the TODO on DB by ids.
+func (s *ReminderService) DeleteReminders(ctx context.Context, todoID int64, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`DELETE FROM reminders WHERE todo_id = ? AND id IN (%s)`, placeholders(len(ids)))
+
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, todoID)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete reminders: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if affected == 0 {
+		return &model.ErrNotFound{Resource: "Reminder"}
+	}
+
+	return nil
+}
+
+// PendingReminders reads the reminders waiting for delivery together with their TODOs.
+// Reminders of completed TODOs and TODOs without a due time are not included.
+func (s *ReminderService) PendingReminders(ctx context.Context) ([]*model.ReminderNotification, error) {
+	const (
+		readReminders = `SELECT ` + reminderColumns + ` FROM reminders r JOIN todos t ON t.id = r.todo_id
+			WHERE r.status = 'pending' AND t.completed_at IS NULL AND t.due_at IS NOT NULL`
+		readTODOs = `SELECT ` + todoColumns + ` FROM todos WHERE id IN (%s)`
+	)
+
+	rows, err := s.db.QueryContext(ctx, readReminders)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		reminders []*model.Reminder
+		todoIDs   []interface{}
+		seen      = map[int64]bool{}
+	)
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+		if !seen[reminder.TODOID] {
+			seen[reminder.TODOID] = true
+			todoIDs = append(todoIDs, reminder.TODOID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	notifications := []*model.ReminderNotification{}
+	if len(reminders) == 0 {
+		return notifications, nil
+	}
+
+	// TODOはリマインダーごとではなく1回のクエリでまとめて読み込む
+	todoRows, err := s.db.QueryContext(ctx, fmt.Sprintf(readTODOs, placeholders(len(todoIDs))), todoIDs...)
+	if err != nil {
+		return nil, err
+	}
+	defer todoRows.Close()
+
+	todos := map[int64]*model.TODO{}
+	var todoList []*model.TODO
+	for todoRows.Next() {
+		todo, err := scanTODO(todoRows)
+		if err != nil {
+			return nil, err
+		}
+		todos[todo.ID] = todo
+		todoList = append(todoList, todo)
+	}
+	if err := todoRows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadRelations(ctx, s.db, todoList); err != nil {
+		return nil, err
+	}
+
+	for _, reminder := range reminders {
+		if todo, ok := todos[reminder.TODOID]; ok {
+			notifications = append(notifications, &model.ReminderNotification{Reminder: *reminder, TODO: *todo})
+		}
+	}
+	return notifications, nil
+}
+
+// MarkReminderSent records that the reminder was delivered at sentAt.
+func (s *ReminderService) MarkReminderSent(ctx context.Context, id int64, sentAt time.Time) error {
+	const update = `UPDATE reminders SET status = 'sent', sent_at = ?, next_attempt_at = NULL WHERE id = ?`
+
+	if _, err := s.db.ExecContext(ctx, update, sentAt.UTC(), id); err != nil {
+		return fmt.Errorf("failed to mark reminder as sent: %w", err)
+	}
+	return nil
+}
+
+// MarkReminderFailed records that the delivery of the reminder failed by cause.
+// The reminder is retried at retryAt, or given up if retryAt is the zero time.
+func (s *ReminderService) MarkReminderFailed(ctx context.Context, id int64, cause error, retryAt time.Time) error {
+	const update = `UPDATE reminders SET status = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = ? WHERE id = ?`
+
+	status := model.ReminderStatusPending
+	nextAttemptAt := sql.NullTime{Time: retryAt.UTC(), Valid: !retryAt.IsZero()}
+	if retryAt.IsZero() {
+		status = model.ReminderStatusFailed
+	}
+
+	if _, err := s.db.ExecContext(ctx, update, status, cause.Error(), nextAttemptAt, id); err != nil {
+		return fmt.Errorf("failed to mark reminder as failed: %w", err)
+	}
+	return nil
+}
+
+// resetReminders はTODOの期限が変わった場合に、リマインダーを再び通知待ちにする
+func resetReminders(ctx context.Context, q queryer, todoID int64) error {
+	const reset = `UPDATE reminders SET status = 'pending', attempts = 0, last_error = '', next_attempt_at = NULL, sent_at = NULL WHERE todo_id = ?`
+
+	if _, err := q.ExecContext(ctx, reset, todoID); err != nil {
+		return fmt.Errorf("failed to reset reminders: %w", err)
+	}
+	return nil
+}
+
+// copyReminders は繰り返しのTODOの次の回に同じリマインダーを設定する
+func copyReminders(ctx context.Context, q queryer, fromID, toID int64) error {
+	const duplicate = `INSERT INTO reminders(todo_id, before_seconds) SELECT ?, before_seconds FROM reminders WHERE todo_id = ?`
+
+	if _, err := q.ExecContext(ctx, duplicate, toID, fromID); err != nil {
+		return fmt.Errorf("failed to copy reminders: %w", err)
+	}
+	return nil
+}
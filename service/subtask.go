@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// ReadChildren reads the subtasks of the TODO in their order.
+// Of opts, only WithDescriptionHTML is applied because all subtasks are returned.
+func (s *TODOService) ReadChildren(ctx context.Context, parentID int64, opts ...ReadOption) ([]*model.TODO, error) {
+	const read = `SELECT ` + todoColumns + ` FROM todos WHERE parent_id = ? ORDER BY sort_order, id`
+
+	ctx, span := tracer.Start(ctx, "TODOService.ReadChildren")
+	defer span.End()
+
+	if err := checkExists(ctx, s.db, parentID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, read, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []*model.TODO{}
+	for rows.Next() {
+		todo, err := scanTODO(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.finishRead(ctx, todos, opts); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// ReadChildrenOf reads the subtasks of all the TODOs of parentIDs at once,
+// ordered by their parent IDs and then in the order of the subtasks.
+// Of opts, only WithDescriptionHTML is applied like ReadChildren.
+func (s *TODOService) ReadChildrenOf(ctx context.Context, parentIDs []int64, opts ...ReadOption) ([]*model.TODO, error) {
+	ctx, span := tracer.Start(ctx, "TODOService.ReadChildrenOf")
+	defer span.End()
+
+	todos := []*model.TODO{}
+	if len(parentIDs) == 0 {
+		return todos, nil
+	}
+
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE parent_id IN (` + placeholders(len(parentIDs)) + `) ORDER BY parent_id, sort_order, id`
+	args := make([]interface{}, len(parentIDs))
+	for i, id := range parentIDs {
+		args[i] = id
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		todo, err := scanTODO(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.finishRead(ctx, todos, opts); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// ReorderChildren sorts the subtasks of the TODO in the order of ids.
+// ids must contain every subtask of the TODO exactly once.
+func (s *TODOService) ReorderChildren(ctx context.Context, parentID int64, ids []int64) error {
+	const (
+		readChildren = `SELECT id FROM todos WHERE parent_id = ?`
+		reorder      = `UPDATE todos SET sort_order = ? WHERE id = ?`
+	)
+
+	ctx, span := tracer.Start(ctx, "TODOService.ReorderChildren")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkExists(ctx, tx, parentID); err != nil {
+		return err
+	}
+
+	children, err := readIDs(ctx, tx, readChildren, parentID)
+	if err != nil {
+		return err
+	}
+
+	// 並び替え後も同じサブタスクの集合になっていることを確認する
+	remaining := make(map[int64]bool, len(children))
+	for _, id := range children {
+		remaining[id] = true
+	}
+	for _, id := range ids {
+		if !remaining[id] {
+			return &model.ErrInvalidArgument{Field: "ids", Reason: fmt.Sprintf("%d is not a subtask or is duplicated", id)}
+		}
+		delete(remaining, id)
+	}
+	if len(remaining) > 0 {
+		return &model.ErrInvalidArgument{Field: "ids", Reason: "all subtasks must be listed"}
+	}
+
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx, reorder, i+1, id); err != nil {
+			return fmt.Errorf("failed to reorder todos: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// 並び順は伝えないが、watcher が読み直せるよう、並び替えたサブタスクを新しい順に伝える
+	var changes todoChanges
+	changes.update(ids...)
+	s.publishChanges(ctx, &changes)
+	return nil
+}
+
+// checkExists は指定したIDのTODOが存在しない場合に ErrNotFound を返す
+func checkExists(ctx context.Context, q queryer, id int64) error {
+	const exists = `SELECT 1 FROM todos WHERE id = ?`
+
+	var found int
+	err := q.QueryRowContext(ctx, exists, id).Scan(&found)
+	if err == sql.ErrNoRows {
+		return &model.ErrNotFound{Resource: "TODO", ID: id}
+	}
+	return err
+}
+
+// checkParent は id のTODOを parentID のTODOの下に置けるかを確認する。
+// 親が存在しない場合や、親が自分自身または自分のサブタスクである（循環する）場合はエラーを返す。
+// 新規作成の場合は id に0を指定する。
+func checkParent(ctx context.Context, q queryer, id, parentID int64) error {
+	// parentID から祖先を順にたどり、id が含まれていれば循環する
+	const ancestors = `WITH RECURSIVE ancestors(id) AS (
+		SELECT ?
+		UNION
+		SELECT t.parent_id FROM todos t JOIN ancestors a ON t.id = a.id WHERE t.parent_id IS NOT NULL
+	) SELECT COUNT(*) FROM ancestors WHERE id = ?`
+
+	if err := checkExists(ctx, q, parentID); err != nil {
+		return &model.ErrInvalidArgument{Field: "parent_id", Reason: err.Error()}
+	}
+
+	if id == 0 {
+		return nil
+	}
+
+	var cycles int
+	if err := q.QueryRowContext(ctx, ancestors, parentID, id).Scan(&cycles); err != nil {
+		return fmt.Errorf("failed to check ancestors: %w", err)
+	}
+	if cycles > 0 {
+		return &model.ErrInvalidArgument{Field: "parent_id", Reason: fmt.Sprintf("TODO %d is TODO %d itself or one of its subtasks", parentID, id)}
+	}
+
+	return nil
+}
+
+// readParentID は指定したIDのTODOの親TODOのIDを読み取る
+func readParentID(ctx context.Context, q queryer, id int64) (sql.NullInt64, error) {
+	const read = `SELECT parent_id FROM todos WHERE id = ?`
+
+	var parentID sql.NullInt64
+	err := q.QueryRowContext(ctx, read, id).Scan(&parentID)
+	if err == sql.ErrNoRows {
+		return parentID, &model.ErrNotFound{Resource: "TODO", ID: id}
+	}
+	return parentID, err
+}
+
+// moveTODO は id のTODOを parentID のTODOのサブタスクの末尾に移動する。parentID が0の場合は親から外す。
+func moveTODO(ctx context.Context, q queryer, id, parentID int64) error {
+	const (
+		detach = `UPDATE todos SET parent_id = NULL, sort_order = 0 WHERE id = ?`
+		attach = `UPDATE todos SET parent_id = ?, sort_order = (SELECT COALESCE(MAX(sort_order), 0) + 1 FROM todos WHERE parent_id = ?) WHERE id = ?`
+	)
+
+	if parentID == 0 {
+		_, err := q.ExecContext(ctx, detach, id)
+		return err
+	}
+
+	if err := checkParent(ctx, q, id, parentID); err != nil {
+		return err
+	}
+
+	_, err := q.ExecContext(ctx, attach, parentID, parentID, id)
+	return err
+}
+
+// setCompleted はTODOの完了状態を変更する。完了にする場合はすべてのサブタスクも完了にする。
+func setCompleted(ctx context.Context, q queryer, id int64, completed bool, changes *todoChanges) error {
+	const (
+		readSubtree = `WITH RECURSIVE subtree(id) AS (
+			SELECT ?
+			UNION ALL
+			SELECT t.id FROM todos t JOIN subtree s ON t.parent_id = s.id
+		) SELECT id FROM todos WHERE id IN (SELECT id FROM subtree) AND completed_at IS NULL`
+		complete = `WITH RECURSIVE subtree(id) AS (
+			SELECT ?
+			UNION ALL
+			SELECT t.id FROM todos t JOIN subtree s ON t.parent_id = s.id
+		) UPDATE todos SET completed_at = DATETIME('now') WHERE id IN (SELECT id FROM subtree) AND completed_at IS NULL`
+		reopen = `UPDATE todos SET completed_at = NULL WHERE id = ? AND completed_at IS NOT NULL`
+	)
+
+	if !completed {
+		res, err := q.ExecContext(ctx, reopen, id)
+		if err != nil {
+			return fmt.Errorf("failed to update completion: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to check affected rows: %w", err)
+		}
+		if affected > 0 {
+			changes.update(id)
+		}
+		return nil
+	}
+
+	// 完了にするサブタスクも watcher に伝えるため、未完了のものを控えてから更新する
+	ids, err := readIDs(ctx, q, readSubtree, id)
+	if err != nil {
+		return fmt.Errorf("failed to read subtasks: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, complete, id); err != nil {
+		return fmt.Errorf("failed to update completion: %w", err)
+	}
+	changes.update(ids...)
+	return nil
+}
+
+// rollupCompletion はサブタスクの完了状態を親TODOへ順に反映する。
+// すべてのサブタスクが完了していれば親を完了にし、未完了のものがあれば親を未完了に戻す。
+// 完了状態が変わった親と、作成した次の回は changes に控える。
+func rollupCompletion(ctx context.Context, q queryer, parentID int64, changes *todoChanges) error {
+	const (
+		count    = `SELECT COUNT(*), COUNT(completed_at) FROM todos WHERE parent_id = ?`
+		complete = `UPDATE todos SET completed_at = DATETIME('now') WHERE id = ? AND completed_at IS NULL`
+		reopen   = `UPDATE todos SET completed_at = NULL WHERE id = ? AND completed_at IS NOT NULL`
+	)
+
+	for {
+		var total, completed int
+		if err := q.QueryRowContext(ctx, count, parentID).Scan(&total, &completed); err != nil {
+			return fmt.Errorf("failed to count subtasks: %w", err)
+		}
+		// サブタスクがない場合は親の完了状態をそのままにする
+		if total == 0 {
+			return nil
+		}
+
+		query := reopen
+		if total == completed {
+			query = complete
+		}
+		res, err := q.ExecContext(ctx, query, parentID)
+		if err != nil {
+			return fmt.Errorf("failed to roll up completion: %w", err)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to check affected rows: %w", err)
+		}
+		if affected > 0 {
+			changes.update(parentID)
+			// サブタスクによって完了した繰り返しのTODOも、直接完了した場合と同じく次の回を作成する
+			if total == completed {
+				if err := createNextOccurrence(ctx, q, parentID, changes); err != nil {
+					return err
+				}
+			}
+		}
+
+		next, err := readParentID(ctx, q, parentID)
+		if err != nil {
+			var notFound *model.ErrNotFound
+			if errors.As(err, &notFound) {
+				return nil // 親TODOも同時に削除された場合
+			}
+			return err
+		}
+		if !next.Valid {
+			return nil
+		}
+		parentID = next.Int64
+	}
+}
@@ -0,0 +1,248 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestUpdateTODO_ParentCycle(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "cycle_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := service.NewTODOService(todoDB)
+	create := func(subject string, opts ...service.TODOOption) *model.TODO {
+		t.Helper()
+		todo, err := svc.CreateTODO(ctx, subject, "", opts...)
+		if err != nil {
+			t.Fatal("failed to create todo, err =", err)
+		}
+		return todo
+	}
+	root := create("root")
+	child := create("child", service.WithParent(root.ID))
+	grandchild := create("grandchild", service.WithParent(child.ID))
+	other := create("other")
+
+	// 親子関係を変えるケースがあるので、順に実行する
+	cases := []struct {
+		name         string
+		id, parentID int64 // id が0の場合は作成する
+		invalid      bool
+	}{
+		{name: "Self", id: root.ID, parentID: root.ID, invalid: true},
+		{name: "Child", id: root.ID, parentID: child.ID, invalid: true},
+		{name: "Grandchild", id: root.ID, parentID: grandchild.ID, invalid: true},
+		{name: "Grandchild of child", id: child.ID, parentID: grandchild.ID, invalid: true},
+		{name: "Parent not found", id: other.ID, parentID: 9999, invalid: true},
+		{name: "Create under missing", parentID: 9999, invalid: true},
+		{name: "Same parent", id: grandchild.ID, parentID: child.ID},
+		{name: "Unrelated TODO", id: other.ID, parentID: grandchild.ID},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var err error
+			if c.id == 0 {
+				_, err = svc.CreateTODO(ctx, "subject", "", service.WithParent(c.parentID))
+			} else {
+				_, err = svc.UpdateTODO(ctx, c.id, "subject", "", service.WithParent(c.parentID))
+			}
+
+			var invalid *model.ErrInvalidArgument
+			if c.invalid != errors.As(err, &invalid) {
+				t.Fatalf("unexpected error, given = %v, invalid argument expected = %t", err, c.invalid)
+			}
+			if !c.invalid && err != nil {
+				t.Fatal("unexpected error, err =", err)
+			}
+		})
+	}
+
+	// 循環を拒否した後も親子関係は変わっていないこと
+	todos, err := svc.ReadTODOsByIDs(ctx, []int64{root.ID, child.ID, grandchild.ID})
+	if err != nil {
+		t.Fatal("failed to read todos, err =", err)
+	}
+	if todos[0].ParentID != nil || *todos[1].ParentID != root.ID || *todos[2].ParentID != child.ID {
+		t.Errorf("parents must be unchanged, given = %+v", todos)
+	}
+}
+
+func TestUpdateTODO_CompletionRollup(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "rollup_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := service.NewTODOService(todoDB)
+	create := func(subject string, opts ...service.TODOOption) *model.TODO {
+		t.Helper()
+		todo, err := svc.CreateTODO(ctx, subject, "", opts...)
+		if err != nil {
+			t.Fatal("failed to create todo, err =", err)
+		}
+		return todo
+	}
+	complete := func(todo *model.TODO, completed bool) {
+		t.Helper()
+		if _, err := svc.UpdateTODO(ctx, todo.ID, todo.Subject, "", service.WithCompleted(completed)); err != nil {
+			t.Fatal("failed to update todo, err =", err)
+		}
+	}
+	// expect は TODO の完了状態を親から順に確認する
+	expect := func(step string, todos []*model.TODO, completed ...bool) {
+		t.Helper()
+		ids := make([]int64, len(todos))
+		for i, todo := range todos {
+			ids[i] = todo.ID
+		}
+		read, err := svc.ReadTODOsByIDs(ctx, ids)
+		if err != nil {
+			t.Fatal("failed to read todos, err =", err)
+		}
+		given := make([]bool, len(read))
+		for i, todo := range read {
+			given[i] = todo.CompletedAt != nil
+		}
+		if !reflect.DeepEqual(given, completed) {
+			t.Errorf("%s: unexpected completion, given = %v, expected = %v", step, given, completed)
+		}
+	}
+
+	root := create("root")
+	parent := create("parent", service.WithParent(root.ID))
+	a := create("a", service.WithParent(parent.ID))
+	b := create("b", service.WithParent(parent.ID))
+	all := []*model.TODO{root, parent, a, b}
+
+	complete(a, true)
+	expect("one of two subtasks completed", all, false, false, true, false)
+
+	// 最後のサブタスクが完了すると親と祖先も完了になる
+	complete(b, true)
+	expect("all subtasks completed", all, true, true, true, true)
+
+	// サブタスクを未完了に戻すと親と祖先も未完了に戻る
+	complete(a, false)
+	expect("subtask reopened", all, false, false, false, true)
+
+	// 親を完了にするとサブタスクもすべて完了になる
+	complete(parent, true)
+	expect("parent completed", all, true, true, true, true)
+
+	// 未完了のサブタスクが増えると親と祖先は未完了に戻る
+	c := create("c", service.WithParent(parent.ID))
+	expect("subtask added", append(all, c), false, false, true, true, false)
+
+	// 未完了のサブタスクが消えると親と祖先は再び完了になる
+	if err := svc.DeleteTODO(ctx, []int64{c.ID}); err != nil {
+		t.Fatal("failed to delete todo, err =", err)
+	}
+	expect("subtask deleted", all, true, true, true, true)
+
+	// 未完了のサブタスクを別の親に移すと、移動元は完了、移動先は未完了になる
+	complete(b, false)
+	other := create("other")
+	complete(other, true)
+	if _, err := svc.UpdateTODO(ctx, b.ID, b.Subject, "", service.WithParent(other.ID)); err != nil {
+		t.Fatal("failed to move todo, err =", err)
+	}
+	expect("subtask moved", []*model.TODO{root, parent, other}, true, true, false)
+}
+
+func TestReorderChildren(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "reorder_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := service.NewTODOService(todoDB)
+	create := func(subject string, opts ...service.TODOOption) *model.TODO {
+		t.Helper()
+		todo, err := svc.CreateTODO(ctx, subject, "", opts...)
+		if err != nil {
+			t.Fatal("failed to create todo, err =", err)
+		}
+		return todo
+	}
+	parent := create("parent")
+	first := create("a", service.WithParent(parent.ID))
+	second := create("b", service.WithParent(parent.ID))
+	third := create("c", service.WithParent(parent.ID))
+	other := create("other")
+	nephew := create("nephew", service.WithParent(other.ID))
+
+	// 失敗したケースで順序が変わらないことを確かめるため、並び替えを成功させた後に順に実行する
+	cases := []struct {
+		name     string
+		parentID int64
+		ids      []int64
+		err      error
+	}{
+		{name: "Reversed", parentID: parent.ID, ids: []int64{third.ID, second.ID, first.ID}},
+		{name: "Partial", parentID: parent.ID, ids: []int64{third.ID, first.ID}, err: &model.ErrInvalidArgument{}},
+		{name: "Duplicated", parentID: parent.ID, ids: []int64{third.ID, first.ID, first.ID, second.ID}, err: &model.ErrInvalidArgument{}},
+		{name: "Other subtask", parentID: parent.ID, ids: []int64{first.ID, second.ID, third.ID, nephew.ID}, err: &model.ErrInvalidArgument{}},
+		{name: "Unknown ID", parentID: parent.ID, ids: []int64{first.ID, second.ID, third.ID, 9999}, err: &model.ErrInvalidArgument{}},
+		{name: "Empty", parentID: parent.ID, ids: []int64{}, err: &model.ErrInvalidArgument{}},
+		{name: "Parent not found", parentID: 9999, ids: []int64{}, err: &model.ErrNotFound{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := svc.ReorderChildren(ctx, c.parentID, c.ids)
+			switch c.err.(type) {
+			case nil:
+				if err != nil {
+					t.Fatal("unexpected error, err =", err)
+				}
+			case *model.ErrInvalidArgument:
+				var invalid *model.ErrInvalidArgument
+				if !errors.As(err, &invalid) {
+					t.Fatalf("unexpected error, given = %v, expected = %T", err, c.err)
+				}
+			case *model.ErrNotFound:
+				var notFound *model.ErrNotFound
+				if !errors.As(err, &notFound) {
+					t.Fatalf("unexpected error, given = %v, expected = %T", err, c.err)
+				}
+			}
+
+			if c.parentID != parent.ID {
+				return
+			}
+			children, err := svc.ReadChildren(ctx, parent.ID)
+			if err != nil {
+				t.Fatal("failed to read children, err =", err)
+			}
+			subjects := []string{}
+			for _, child := range children {
+				subjects = append(subjects, child.Subject)
+			}
+			if expected := []string{"c", "b", "a"}; !reflect.DeepEqual(subjects, expected) {
+				t.Errorf("unexpected order, given = %v, expected = %v", subjects, expected)
+			}
+		})
+	}
+}
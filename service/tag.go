@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// A TagService implements reading of Tag entities.
+type TagService struct {
+	db *sql.DB
+}
+
+// NewTagService returns new TagService.
+func NewTagService(db *sql.DB) *TagService {
+	return &TagService{
+		db: db,
+	}
+}
+
+// ReadTags reads all tags on DB with the number of TODOs using each of them.
+func (s *TagService) ReadTags(ctx context.Context) ([]*model.Tag, error) {
+	const read = `SELECT t.id, t.name, COUNT(tt.todo_id) FROM tags t LEFT JOIN todo_tags tt ON tt.tag_id = t.id GROUP BY t.id ORDER BY t.name`
+
+	rows, err := s.db.QueryContext(ctx, read)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*model.Tag{}
+	for rows.Next() {
+		var tag model.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, &tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// normalizeTags は前後の空白を取り除き、空文字列と重複を除いたタグ名を返す
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// setTODOTags はTODOに付与されたタグを tags で置き換える。存在しないタグは作成する。
+func setTODOTags(ctx context.Context, q queryer, todoID int64, tags []string) error {
+	const (
+		clear      = `DELETE FROM todo_tags WHERE todo_id = ?`
+		insertTag  = `INSERT OR IGNORE INTO tags(name) VALUES(?)`
+		attachTags = `INSERT INTO todo_tags(todo_id, tag_id) SELECT ?, id FROM tags WHERE name IN (%s)`
+	)
+
+	if _, err := q.ExecContext(ctx, clear, todoID); err != nil {
+		return fmt.Errorf("failed to clear tags: %w", err)
+	}
+
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(tags)+1)
+	args = append(args, todoID)
+	for _, tag := range tags {
+		if _, err := q.ExecContext(ctx, insertTag, tag); err != nil {
+			return fmt.Errorf("failed to create tag: %w", err)
+		}
+		args = append(args, tag)
+	}
+
+	if _, err := q.ExecContext(ctx, fmt.Sprintf(attachTags, placeholders(len(tags))), args...); err != nil {
+		return fmt.Errorf("failed to attach tags: %w", err)
+	}
+
+	return nil
+}
+
+// loadTags は todos に付与されたタグを1回のクエリでまとめて読み込む
+func loadTags(ctx context.Context, q queryer, todos []*model.TODO) error {
+	const read = `SELECT tt.todo_id, t.name FROM todo_tags tt JOIN tags t ON t.id = tt.tag_id WHERE tt.todo_id IN (%s) ORDER BY t.name`
+
+	if len(todos) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.TODO, len(todos))
+	args := make([]interface{}, len(todos))
+	for i, todo := range todos {
+		byID[todo.ID] = todo
+		args[i] = todo.ID
+	}
+
+	rows, err := q.QueryContext(ctx, fmt.Sprintf(read, placeholders(len(todos))), args...)
+	if err != nil {
+		return fmt.Errorf("failed to read tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			todoID int64
+			name   string
+		)
+		if err := rows.Scan(&todoID, &name); err != nil {
+			return err
+		}
+		if todo, ok := byID[todoID]; ok {
+			todo.Tags = append(todo.Tags, name)
+		}
+	}
+
+	return rows.Err()
+}
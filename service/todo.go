@@ -4,78 +4,342 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
 
 	"github.com/TechBowl-japan/go-stations/model"
 )
 
+// tracer は TODOService のスパンを作る。otel.SetTracerProvider で設定したものを使う
+var tracer = otel.Tracer("github.com/TechBowl-japan/go-stations/service")
+
 // A TODOService implements CRUD of TODO entities.
 type TODOService struct {
-	db *sql.DB
+	db           *sql.DB
+	blobs        BlobStore
+	descriptions *descriptionCache
+	events       *TODOEvents
+	logger       *slog.Logger
+}
+
+// A TODOServiceOption configures TODOService on NewTODOService.
+type TODOServiceOption func(*TODOService)
+
+// WithBlobStore makes DeleteTODO delete the contents of the attachments of the
+// deleted TODOs from blobs.
+func WithBlobStore(blobs BlobStore) TODOServiceOption {
+	return func(s *TODOService) {
+		s.blobs = blobs
+	}
+}
+
+// WithLogger makes TODOService log with logger instead of slog.Default.
+func WithLogger(logger *slog.Logger) TODOServiceOption {
+	return func(s *TODOService) {
+		s.logger = logger
+	}
 }
 
 // NewTODOService returns new TODOService.
-func NewTODOService(db *sql.DB) *TODOService {
-	return &TODOService{
-		db: db,
+func NewTODOService(db *sql.DB, opts ...TODOServiceOption) *TODOService {
+	s := &TODOService{
+		db:           db,
+		descriptions: newDescriptionCache(),
+		events:       NewTODOEvents(),
+		logger:       slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// A TODOOption sets optional attributes of a TODO on CreateTODO and UpdateTODO.
+type TODOOption func(*todoOptions)
+
+type todoOptions struct {
+	tags      []string
+	setTags   bool
+	parentID  *int64
+	completed *bool
+	dueAt     *time.Time
+	rrule     *string
+	timezone  *string
+	position  *string // ImportTODO でのみ指定する並び順のキー
+
+	keepSubject     bool // UpdateTODO で件名を変更しない
+	keepDescription bool // UpdateTODO で説明を変更しない
+}
+
+// WithTags replaces the tags of the TODO. A nil slice leaves the tags unchanged.
+func WithTags(tags []string) TODOOption {
+	return func(o *todoOptions) {
+		if tags == nil {
+			return
+		}
+		o.tags = tags
+		o.setTags = true
+	}
+}
+
+// WithParent moves the TODO under the TODO of parentID. 0 makes it a top-level TODO.
+func WithParent(parentID int64) TODOOption {
+	return func(o *todoOptions) {
+		o.parentID = &parentID
+	}
+}
+
+// WithCompleted marks the TODO as completed or not completed.
+// Completing a TODO also completes all of its subtasks.
+func WithCompleted(completed bool) TODOOption {
+	return func(o *todoOptions) {
+		o.completed = &completed
+	}
+}
+
+// WithDueAt sets the due time of the TODO. The zero time removes the due time.
+func WithDueAt(dueAt time.Time) TODOOption {
+	return func(o *todoOptions) {
+		o.dueAt = &dueAt
+	}
+}
+
+// WithRRule makes the TODO recur by the RFC 5545 RRULE starting from its due time.
+// An empty rule stops the recurrence.
+func WithRRule(rule string) TODOOption {
+	return func(o *todoOptions) {
+		o.rrule = &rule
+	}
+}
+
+// WithTimezone sets the IANA time zone used to compute the recurrence.
+// An empty name means the server's local time zone.
+func WithTimezone(name string) TODOOption {
+	return func(o *todoOptions) {
+		o.timezone = &name
 	}
 }
 
+// KeepSubject makes UpdateTODO leave the subject unchanged, ignoring the subject given to it.
+func KeepSubject() TODOOption {
+	return func(o *todoOptions) {
+		o.keepSubject = true
+	}
+}
+
+// KeepDescription makes UpdateTODO leave the description unchanged, ignoring the description
+// given to it. Unlike reading the description and writing it back, it does not revert the
+// description updated concurrently.
+func KeepDescription() TODOOption {
+	return func(o *todoOptions) {
+		o.keepDescription = true
+	}
+}
+
+// A ReadOption narrows down the TODOs returned by ReadTODO.
+type ReadOption func(*readOptions)
+
+type readOptions struct {
+	tags            []string
+	matchAll        bool
+	ids             []int64
+	descriptionHTML bool
+}
+
+// WithIDFilter keeps only TODOs of the given ids. Empty ids keep all TODOs.
+func WithIDFilter(ids []int64) ReadOption {
+	return func(o *readOptions) {
+		o.ids = ids
+	}
+}
+
+// WithTagFilter keeps only TODOs having any (model.TagMatchAny) or all
+// (model.TagMatchAll) of the given tags.
+func WithTagFilter(tags []string, match string) ReadOption {
+	return func(o *readOptions) {
+		o.tags = normalizeTags(tags)
+		o.matchAll = match == model.TagMatchAll
+	}
+}
+
+// WithDescriptionHTML sets DescriptionHTML of the TODOs to their descriptions
+// rendered from CommonMark into sanitized HTML.
+func WithDescriptionHTML() ReadOption {
+	return func(o *readOptions) {
+		o.descriptionHTML = true
+	}
+}
+
+// queryer は *sql.DB と *sql.Tx のどちらでも実行できるようにするためのインターフェース
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 // CreateTODO creates a TODO on DB.
-func (s *TODOService) CreateTODO(ctx context.Context, subject, description string) (*model.TODO, error) {
+func (s *TODOService) CreateTODO(ctx context.Context, subject, description string, opts ...TODOOption) (*model.TODO, error) {
+	ctx, span := tracer.Start(ctx, "TODOService.CreateTODO")
+	defer span.End()
+
+	var o todoOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var changes todoChanges
+	id, err := createTODO(ctx, tx, subject, description, &o, &changes)
+	if err != nil {
+		return nil, err
+	}
+
+	// 保存したTODOを読み取り
+	todo, err := readTODOByID(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve todo: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// 親TODOが未完了に戻った場合などは、作成したTODOに続けて伝える
+	s.publishChanges(ctx, &changes)
+	return todo, nil
+}
+
+// createTODO はTODOをDBに保存し、そのIDを返す。作成や完了状態の集計で変更したTODOは changes に控える
+func createTODO(ctx context.Context, q queryer, subject, description string, o *todoOptions, changes *todoChanges) (int64, error) {
 	const (
-		insert  = `INSERT INTO todos(subject, description) VALUES(?, ?)`
-		confirm = `SELECT id, subject, description, created_at, updated_at FROM todos WHERE id = ?`
+		insert = `INSERT INTO todos(subject, description, parent_id, sort_order, position)
+			VALUES(?, ?, ?, (SELECT COALESCE(MAX(sort_order), 0) + 1 FROM todos WHERE parent_id IS ?), ?)`
 	)
 
+	// 親TODOが指定された場合は存在を確認する
+	var parentID sql.NullInt64
+	if o.parentID != nil && *o.parentID != 0 {
+		if err := checkParent(ctx, q, 0, *o.parentID); err != nil {
+			return 0, err
+		}
+		parentID = sql.NullInt64{Int64: *o.parentID, Valid: true}
+	}
+
+	// 新しいTODOは一覧の先頭に並べる
+	var position string
+	if o.position != nil {
+		position = *o.position
+	} else {
+		var err error
+		if position, err = firstPosition(ctx, q); err != nil {
+			return 0, err
+		}
+	}
+
 	// TODOをDBに保存
-	res, err := s.db.ExecContext(ctx, insert, subject, description)
+	res, err := q.ExecContext(ctx, insert, subject, description, parentID, parentID, position)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
 	// 保存したTODOのIDを取得
 	id, err := res.LastInsertId()
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve todo id: %w", err)
+		return 0, fmt.Errorf("failed to retrieve todo id: %w", err)
 	}
+	changes.create(id)
 
-	// 保存したTODOを読み取り
-	var todo model.TODO
-	err = s.db.QueryRowContext(ctx, confirm, id).Scan(&todo.ID, &todo.Subject, &todo.Description, &todo.CreatedAt, &todo.UpdatedAt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve todo: %w", err)
+	if o.setTags {
+		if err := setTODOTags(ctx, q, id, o.tags); err != nil {
+			return 0, err
+		}
 	}
 
-	return &todo, nil
+	if err := setRecurrence(ctx, q, id, o); err != nil {
+		return 0, err
+	}
+
+	if o.completed != nil {
+		if err := setCompleted(ctx, q, id, *o.completed, changes); err != nil {
+			return 0, err
+		}
+	}
+
+	// 未完了のサブタスクが増えたので親の完了状態を更新する
+	if parentID.Valid {
+		if err := rollupCompletion(ctx, q, parentID.Int64, changes); err != nil {
+			return 0, err
+		}
+	}
+
+	return id, nil
 }
 
 // ReadTODO reads TODOs on DB.
-func (s *TODOService) ReadTODO(ctx context.Context, prevID, size int64) ([]*model.TODO, error) {
+func (s *TODOService) ReadTODO(ctx context.Context, prevID, size int64, opts ...ReadOption) ([]*model.TODO, error) {
+	ctx, span := tracer.Start(ctx, "TODOService.ReadTODO")
+	defer span.End()
+
 	// サイズが0の場合は空のスライスを返す
 	if size <= 0 {
 		return []*model.TODO{}, nil
 	}
 
-	const (
-		read       = `SELECT id, subject, description, created_at, updated_at FROM todos ORDER BY id DESC LIMIT ?`
-		readWithID = `SELECT id, subject, description, created_at, updated_at FROM todos WHERE id < ? ORDER BY id DESC LIMIT ?`
-	)
-
-	var rows *sql.Rows
-	var err error
+	var o readOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
 
+	// 条件に応じてWHERE句を組み立てる
+	var (
+		conds []string
+		args  []interface{}
+	)
 	if prevID > 0 {
-		log.Printf("Executing クエリ with prevID: %v, size: %v\n", prevID, size)
-		rows, err = s.db.QueryContext(ctx, readWithID, prevID, size)
-	} else {
-		log.Printf("Executing query with size: %v\n", size)
-		rows, err = s.db.QueryContext(ctx, read, size)
+		// 並び順（position の昇順、同じキーの場合は id の降順）で prevID のTODOより後ろにあるもの。
+		// ページの間に prevID のTODOが削除された場合は、並び替えていないTODOの順序と同じ id の降順で続ける
+		conds = append(conds, `(CASE WHEN EXISTS (SELECT 1 FROM todos WHERE id = ?)
+			THEN position > (SELECT position FROM todos WHERE id = ?) OR (position = (SELECT position FROM todos WHERE id = ?) AND id < ?)
+			ELSE id < ? END)`)
+		args = append(args, prevID, prevID, prevID, prevID, prevID)
+	}
+	if len(o.tags) > 0 {
+		cond := `id IN (SELECT tt.todo_id FROM todo_tags tt JOIN tags t ON t.id = tt.tag_id WHERE t.name IN (` + placeholders(len(o.tags)) + `)`
+		for _, tag := range o.tags {
+			args = append(args, tag)
+		}
+		if o.matchAll {
+			cond += ` GROUP BY tt.todo_id HAVING COUNT(DISTINCT t.id) = ?`
+			args = append(args, len(o.tags))
+		}
+		conds = append(conds, cond+`)`)
 	}
+	if len(o.ids) > 0 {
+		conds = append(conds, `id IN (`+placeholders(len(o.ids))+`)`)
+		for _, id := range o.ids {
+			args = append(args, id)
+		}
+	}
+
+	query := `SELECT ` + todoColumns + ` FROM todos`
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, ` AND `)
+	}
+	query += ` ORDER BY position, id DESC LIMIT ?`
+	args = append(args, size)
 
+	s.logger.DebugContext(ctx, "reading todos", "prev_id", prevID, "size", size, "tags", o.tags, "ids", o.ids)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		log.Printf("Query execution error: %v\n", err)
+		s.logger.ErrorContext(ctx, "failed to query todos", "err", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -83,40 +347,138 @@ func (s *TODOService) ReadTODO(ctx context.Context, prevID, size int64) ([]*mode
 	var todos []*model.TODO
 
 	for rows.Next() {
-		var todo model.TODO
-		if err := rows.Scan(&todo.ID, &todo.Subject, &todo.Description, &todo.CreatedAt, &todo.UpdatedAt); err != nil {
-			log.Printf("エラー scanning row: %v\n", err)
+		todo, err := scanTODO(rows)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan todo", "err", err)
 			return nil, err
 		}
-		log.Printf("スキャン TODO: ID=%d, Subject=%s\n", todo.ID, todo.Subject)
-		todos = append(todos, &todo)
+		todos = append(todos, todo)
 	}
 
 	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating rows: %v\n", err)
+		s.logger.ErrorContext(ctx, "failed to iterate todos", "err", err)
 		return nil, err
 	}
 
 	if todos == nil { // nilの返却を避けるために空のスライスにする。
-		log.Printf("todosはnilです。")
 		todos = []*model.TODO{}
 	}
+
+	// タグなどはTODOごとではなく1回のクエリでまとめて読み込む
+	if err := s.finishRead(ctx, todos, opts); err != nil {
+		return nil, err
+	}
 	return todos, nil
 }
 
+// ReadTODOsByIDs reads the TODOs of ids at once, in the order of their IDs.
+// IDs of no TODO are ignored.
+func (s *TODOService) ReadTODOsByIDs(ctx context.Context, ids []int64, opts ...ReadOption) ([]*model.TODO, error) {
+	ctx, span := tracer.Start(ctx, "TODOService.ReadTODOsByIDs")
+	defer span.End()
+
+	todos := []*model.TODO{}
+	if len(ids) == 0 {
+		return todos, nil
+	}
+
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE id IN (` + placeholders(len(ids)) + `) ORDER BY id`
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		todo, err := scanTODO(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.finishRead(ctx, todos, opts); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// CountTODOs returns the number of all the TODOs and that of the completed ones.
+func (s *TODOService) CountTODOs(ctx context.Context) (total, completed int64, err error) {
+	const count = `SELECT COUNT(*), COUNT(completed_at) FROM todos`
+
+	if err := s.db.QueryRowContext(ctx, count).Scan(&total, &completed); err != nil {
+		return 0, 0, fmt.Errorf("failed to count todos: %w", err)
+	}
+	return total, completed, nil
+}
+
+// finishRead は読み取ったTODOにタグなどを読み込み、opts で要求された値を設定する
+func (s *TODOService) finishRead(ctx context.Context, todos []*model.TODO, opts []ReadOption) error {
+	var o readOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := loadRelations(ctx, s.db, todos); err != nil {
+		return err
+	}
+	if o.descriptionHTML {
+		return s.RenderDescriptionHTML(todos)
+	}
+	return nil
+}
+
 // UpdateTODO updates the TODO on DB.
-func (s *TODOService) UpdateTODO(ctx context.Context, id int64, subject, description string) (*model.TODO, error) {
+func (s *TODOService) UpdateTODO(ctx context.Context, id int64, subject, description string, opts ...TODOOption) (*model.TODO, error) {
+	ctx, span := tracer.Start(ctx, "TODOService.UpdateTODO")
+	defer span.End()
+
 	/*if id <= 0 {
 		return nil, &model.ErrNotFound{}
 	}*/
 
-	const (
-		update  = `UPDATE todos SET subject = ?, description = ? WHERE id = ?`
-		confirm = `SELECT id, subject, description, created_at, updated_at FROM todos WHERE id = ?` // idを含めるように変更した
-	)
+	var o todoOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// 変更しない項目は同じトランザクションの中で今の値のまま設定し、更新日時のトリガーは常に動かす
+	subjectValue, descriptionValue := `?`, `?`
+	var args []interface{}
+	if o.keepSubject {
+		subjectValue = `subject`
+	} else {
+		args = append(args, subject)
+	}
+	if o.keepDescription {
+		descriptionValue = `description`
+	} else {
+		args = append(args, description)
+	}
+	args = append(args, id)
+	update := `UPDATE todos SET subject = ` + subjectValue + `, description = ` + descriptionValue + ` WHERE id = ?`
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 更新したTODOに続けて、完了状態の集計などで変わったTODOを伝える
+	var changes todoChanges
+	changes.update(id)
 
 	// Execute the update query
-	res, err := s.db.ExecContext(ctx, update, subject, description, id)
+	res, err := tx.ExecContext(ctx, update, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -130,8 +492,59 @@ func (s *TODOService) UpdateTODO(ctx context.Context, id int64, subject, descrip
 		return nil, &model.ErrNotFound{} // 更新された行がない場合は、ErrNotFoundエラーを返す
 	}
 
-	var todo model.TODO
-	err = s.db.QueryRowContext(ctx, confirm, id).Scan(&todo.ID, &todo.Subject, &todo.Description, &todo.CreatedAt, &todo.UpdatedAt)
+	if o.setTags {
+		if err := setTODOTags(ctx, tx, id, o.tags); err != nil {
+			return nil, err
+		}
+	}
+
+	// 完了状態の集計が必要な親TODOのID
+	var rollups []int64
+
+	oldParentID, err := readParentID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if oldParentID.Valid {
+		rollups = append(rollups, oldParentID.Int64)
+	}
+
+	if o.parentID != nil && *o.parentID != oldParentID.Int64 {
+		if err := moveTODO(ctx, tx, id, *o.parentID); err != nil {
+			return nil, err
+		}
+		if *o.parentID != 0 {
+			rollups = append(rollups, *o.parentID)
+		}
+	}
+
+	if err := setRecurrence(ctx, tx, id, &o); err != nil {
+		return nil, err
+	}
+
+	if o.completed != nil {
+		completedBefore, err := isCompleted(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := setCompleted(ctx, tx, id, *o.completed, &changes); err != nil {
+			return nil, err
+		}
+		// 繰り返しのTODOが完了した場合は次の回を作成する
+		if *o.completed && !completedBefore {
+			if err := createNextOccurrence(ctx, tx, id, &changes); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, parentID := range rollups {
+		if err := rollupCompletion(ctx, tx, parentID, &changes); err != nil {
+			return nil, err
+		}
+	}
+
+	todo, err := readTODOByID(ctx, tx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// IDに対応するTODOが見つからない場合は、ErrNotFoundエラーを具体的な情報と共に返す
@@ -140,19 +553,35 @@ func (s *TODOService) UpdateTODO(ctx context.Context, id int64, subject, descrip
 		return nil, fmt.Errorf("failed to retrieve updated todo: %w", err)
 	}
 
-	return &todo, nil
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.publishChanges(ctx, &changes)
+	return todo, nil
 }
 
 // DeleteTODO deletes TODOs on DB by ids.
 func (s *TODOService) DeleteTODO(ctx context.Context, ids []int64) error {
+	ctx, span := tracer.Start(ctx, "TODOService.DeleteTODO")
+	defer span.End()
+
 	// idsが空のスライスの場合は何もせずに終了
 	if len(ids) == 0 {
 		return nil
 	}
 
 	// 削除クエリのWHERE句で使用するプレースホルダーを生成
-	placeholder := strings.Repeat("?,", len(ids)-1) + "?"
-	query := fmt.Sprintf(`DELETE FROM todos WHERE id IN (%s)`, placeholder)
+	const (
+		readParents = `SELECT DISTINCT parent_id FROM todos WHERE id IN (%s) AND parent_id IS NOT NULL`
+		readSubtree = `WITH RECURSIVE tree(id) AS (
+			SELECT id FROM todos WHERE id IN (%s)
+			UNION ALL
+			SELECT t.id FROM todos t JOIN tree ON t.parent_id = tree.id
+		) SELECT id FROM tree`
+		remove = `DELETE FROM todos WHERE id IN (%s)`
+	)
+	placeholder := placeholders(len(ids))
 
 	// int64のスライスをinterface{}のスライスに変換
 	args := make([]interface{}, len(ids))
@@ -160,8 +589,34 @@ func (s *TODOService) DeleteTODO(ctx context.Context, ids []int64) error {
 		args[i] = id
 	}
 
-	// 削除クエリの実行
-	res, err := s.db.ExecContext(ctx, query, args...)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 削除後に完了状態を集計し直すため、親TODOのIDを控えておく
+	parentIDs, err := readIDs(ctx, tx, fmt.Sprintf(readParents, placeholder), args...)
+	if err != nil {
+		return fmt.Errorf("failed to read parent todos: %w", err)
+	}
+
+	// watcher に伝えるため、一緒に削除されるサブタスクのIDも控えておく
+	deletedIDs, err := readIDs(ctx, tx, fmt.Sprintf(readSubtree, placeholder), args...)
+	if err != nil {
+		return fmt.Errorf("failed to read deleted todos: %w", err)
+	}
+
+	// 削除後に参照されなくなった添付ファイルを消すため、サブタスクの分も含めてキーを控えておく
+	var blobKeys []string
+	if s.blobs != nil {
+		if blobKeys, err = attachmentKeys(ctx, tx, ids); err != nil {
+			return err
+		}
+	}
+
+	// 削除クエリの実行（サブタスク、todo_tags や attachments の行は ON DELETE CASCADE で削除される）
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(remove, placeholder), args...)
 	if err != nil {
 		return fmt.Errorf("failed to delete todos: %w", err)
 	}
@@ -175,5 +630,106 @@ func (s *TODOService) DeleteTODO(ctx context.Context, ids []int64) error {
 		return &model.ErrNotFound{}
 	}
 
+	var changes todoChanges
+	for _, parentID := range parentIDs {
+		if err := rollupCompletion(ctx, tx, parentID, &changes); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// 行の削除がコミットされてから、参照されなくなった添付ファイルの内容を削除する
+	if s.blobs != nil {
+		if err := removeOrphanBlobs(ctx, s.db, s.blobs, blobKeys); err != nil {
+			s.logger.WarnContext(ctx, "failed to remove orphan attachment contents", "err", err)
+		}
+	}
+
+	s.events.publish(&model.TODOEvent{Type: model.TODOEventDeleted, IDs: deletedIDs})
+	// 未完了のサブタスクが削除されて完了した親TODOなどを伝える
+	s.publishChanges(ctx, &changes)
 	return nil
 }
+
+// todoColumns は scanTODO で読み取るカラムの一覧
+const todoColumns = `id, subject, description, created_at, updated_at, parent_id, completed_at, due_at, rrule, timezone`
+
+// rowScanner は *sql.Row と *sql.Rows のどちらからでも読み取れるようにするためのインターフェース
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTODO は todoColumns の順に並んだ行をTODOとして読み取る
+func scanTODO(row rowScanner) (*model.TODO, error) {
+	var (
+		todo        model.TODO
+		parentID    sql.NullInt64
+		completedAt sql.NullTime
+		dueAt       sql.NullTime
+	)
+	if err := row.Scan(&todo.ID, &todo.Subject, &todo.Description, &todo.CreatedAt, &todo.UpdatedAt,
+		&parentID, &completedAt, &dueAt, &todo.RRule, &todo.Timezone); err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		todo.ParentID = &parentID.Int64
+	}
+	if completedAt.Valid {
+		todo.CompletedAt = &completedAt.Time
+	}
+	if dueAt.Valid {
+		todo.DueAt = &dueAt.Time
+	}
+	return &todo, nil
+}
+
+// readTODOByID は指定したIDのTODOをタグなどと共に読み取る
+func readTODOByID(ctx context.Context, q queryer, id int64) (*model.TODO, error) {
+	const confirm = `SELECT ` + todoColumns + ` FROM todos WHERE id = ?`
+
+	todo, err := scanTODO(q.QueryRowContext(ctx, confirm, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadRelations(ctx, q, []*model.TODO{todo}); err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// loadRelations は todos のタグとコメントの数をまとめて読み込む
+func loadRelations(ctx context.Context, q queryer, todos []*model.TODO) error {
+	if err := loadTags(ctx, q, todos); err != nil {
+		return err
+	}
+	return loadCommentCounts(ctx, q, todos)
+}
+
+// readIDs は1カラムのIDを返すクエリを実行し、IDのスライスとして読み取る
+func readIDs(ctx context.Context, q queryer, query string, args ...interface{}) ([]int64, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// placeholders は IN 句などで使う n 個のプレースホルダーを生成する
+func placeholders(n int) string {
+	return strings.Repeat("?,", n-1) + "?"
+}
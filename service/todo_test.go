@@ -0,0 +1,292 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestReadTODO_TagFilter(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "tag_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := service.NewTODOService(todoDB)
+	for _, c := range []struct {
+		subject string
+		tags    []string
+	}{
+		{subject: "none"},
+		{subject: "work", tags: []string{"work"}},
+		{subject: "home", tags: []string{"home"}},
+		// 前後の空白、空文字列、重複は取り除かれる
+		{subject: "both", tags: []string{" work ", "home", "", "work"}},
+	} {
+		if _, err := svc.CreateTODO(ctx, c.subject, "", service.WithTags(c.tags)); err != nil {
+			t.Fatal("failed to create todo, err =", err)
+		}
+	}
+
+	cases := map[string]struct {
+		tags     []string
+		match    string
+		ids      []int64
+		subjects []string
+	}{
+		"No filter":          {subjects: []string{"both", "home", "work", "none"}},
+		"Any of one":         {tags: []string{"work"}, match: model.TagMatchAny, subjects: []string{"both", "work"}},
+		"Any of two":         {tags: []string{"work", "home"}, match: model.TagMatchAny, subjects: []string{"both", "home", "work"}},
+		"All of two":         {tags: []string{"work", "home"}, match: model.TagMatchAll, subjects: []string{"both"}},
+		"All with duplicate": {tags: []string{"work", " work"}, match: model.TagMatchAll, subjects: []string{"both", "work"}},
+		"Unknown tag":        {tags: []string{"unknown"}, match: model.TagMatchAny, subjects: []string{}},
+		"All with unknown":   {tags: []string{"work", "unknown"}, match: model.TagMatchAll, subjects: []string{}},
+		"Only blank tags":    {tags: []string{" ", ""}, match: model.TagMatchAll, subjects: []string{"both", "home", "work", "none"}},
+		// IDは作成順に1から振られる
+		"IDs":          {ids: []int64{2, 4}, subjects: []string{"both", "work"}},
+		"IDs and tags": {tags: []string{"home"}, match: model.TagMatchAny, ids: []int64{2, 3}, subjects: []string{"home"}},
+		"Unknown ID":   {ids: []int64{100}, subjects: []string{}},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts []service.ReadOption
+			if c.tags != nil {
+				opts = append(opts, service.WithTagFilter(c.tags, c.match))
+			}
+			if c.ids != nil {
+				opts = append(opts, service.WithIDFilter(c.ids))
+			}
+			todos, err := svc.ReadTODO(ctx, 0, 10, opts...)
+			if err != nil {
+				t.Fatal("failed to read todos, err =", err)
+			}
+			subjects := []string{}
+			for _, todo := range todos {
+				subjects = append(subjects, todo.Subject)
+			}
+			if !reflect.DeepEqual(subjects, c.subjects) {
+				t.Errorf("unexpected todos, given = %v, expected = %v", subjects, c.subjects)
+			}
+		})
+	}
+
+	todos, err := svc.ReadTODO(ctx, 0, 1)
+	if err != nil {
+		t.Fatal("failed to read todos, err =", err)
+	}
+	if expected := []string{"home", "work"}; len(todos) != 1 || !reflect.DeepEqual(todos[0].Tags, expected) {
+		t.Errorf("tags must be normalized and sorted, given = %+v, expected = %v", todos, expected)
+	}
+}
+
+func TestReadTODO_QueryCount(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "query_count_test.db")
+	migrated, err := db.NewDB(path)
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	migrated.Close()
+
+	// 実行したクエリを数えるため、マイグレーション済みのファイルを数える接続で開き直す
+	connector := &countingConnector{dsn: path + "?_foreign_keys=on"}
+	todoDB := sql.OpenDB(connector)
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := service.NewTODOService(todoDB)
+
+	queries := func(n int) int64 {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			if _, err := svc.CreateTODO(ctx, "subject", "", service.WithTags([]string{"a", "b"})); err != nil {
+				t.Fatal("failed to create todo, err =", err)
+			}
+		}
+		before := atomic.LoadInt64(&connector.queries)
+		todos, err := svc.ReadTODO(ctx, 0, 100, service.WithTagFilter([]string{"a", "b"}, model.TagMatchAll))
+		if err != nil {
+			t.Fatal("failed to read todos, err =", err)
+		}
+		for _, todo := range todos {
+			if len(todo.Tags) != 2 {
+				t.Fatalf("tags must be loaded, given = %+v", todo)
+			}
+		}
+		return atomic.LoadInt64(&connector.queries) - before
+	}
+
+	// TODOの数によらず、タグなどは1回のクエリでまとめて読み込む
+	few := queries(1)
+	many := queries(20)
+	if few == 0 {
+		t.Fatal("queries must be counted")
+	}
+	if few != many {
+		t.Errorf("number of queries must not depend on number of todos, given = %d for 1 todo, %d for 21 todos", few, many)
+	}
+}
+
+// countingConnector は実行したクエリの数を数える driver.Connector
+type countingConnector struct {
+	dsn     string
+	queries int64
+}
+
+func (c *countingConnector) Connect(context.Context) (driver.Conn, error) {
+	conn, err := c.Driver().Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{Conn: conn, queries: &c.queries}, nil
+}
+
+func (c *countingConnector) Driver() driver.Driver {
+	return &sqlite3.SQLiteDriver{}
+}
+
+// countingConn はクエリを実行するたびに queries を増やす
+type countingConn struct {
+	driver.Conn
+	queries *int64
+}
+
+func (c *countingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	atomic.AddInt64(c.queries, 1)
+	return c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+}
+
+func (c *countingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	atomic.AddInt64(c.queries, 1)
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+}
+
+func (c *countingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func TestReadTODO_Pagination(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "pagination_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := service.NewTODOService(todoDB)
+	ids := map[string]int64{}
+	for _, subject := range []string{"1", "2", "3", "4", "5", "6"} {
+		todo, err := svc.CreateTODO(ctx, subject, "")
+		if err != nil {
+			t.Fatal("failed to create todo, err =", err)
+		}
+		ids[subject] = todo.ID
+	}
+	// page は prevID の後ろの2件を読み取る
+	page := func(step string, prevID int64, expected ...string) {
+		t.Helper()
+		todos, err := svc.ReadTODO(ctx, prevID, 2)
+		if err != nil {
+			t.Fatal("failed to read todos, err =", err)
+		}
+		subjects := []string{}
+		for _, todo := range todos {
+			subjects = append(subjects, todo.Subject)
+		}
+		if expected == nil {
+			expected = []string{}
+		}
+		if !reflect.DeepEqual(subjects, expected) {
+			t.Errorf("%s: unexpected page, given = %v, expected = %v", step, subjects, expected)
+		}
+	}
+	move := func(subject string, beforeID, afterID int64) {
+		t.Helper()
+		if _, err := svc.MoveTODO(ctx, ids[subject], beforeID, afterID); err != nil {
+			t.Fatal("failed to move todo, err =", err)
+		}
+	}
+
+	page("first page", 0, "6", "5")
+	page("second page", ids["5"], "4", "3")
+	page("last page", ids["1"])
+
+	// 前のページの最後のTODOが削除されても、続きのページを読み取れる
+	if err := svc.DeleteTODO(ctx, []int64{ids["5"]}); err != nil {
+		t.Fatal("failed to delete todo, err =", err)
+	}
+	page("after deleted cursor", ids["5"], "4", "3")
+
+	// 前のページの最後のTODOが移動された場合は、移動先の後ろから続ける（6, 3, 2, 4, 1）
+	move("4", 0, ids["2"])
+	page("after moved cursor", ids["4"], "1")
+
+	// 別のTODOが前のページに移動されても、続きのページは空にならない（1, 6, 3, 2, 4）
+	move("1", ids["6"], 0)
+	page("first page after moves", 0, "1", "6")
+	page("after todo moved to previous page", ids["3"], "2", "4")
+}
+
+func TestUpdateTODO_Keep(t *testing.T) {
+	t.Parallel()
+
+	todoDB, err := db.NewDB(filepath.Join(t.TempDir(), "keep_test.db"))
+	if err != nil {
+		t.Fatal("failed to create db, err =", err)
+	}
+	t.Cleanup(func() { todoDB.Close() })
+
+	ctx := context.Background()
+	svc := service.NewTODOService(todoDB)
+
+	cases := map[string]struct {
+		opts        []service.TODOOption
+		subject     string
+		description string
+	}{
+		"Replace both":     {subject: "new subject", description: "new description"},
+		"Keep subject":     {opts: []service.TODOOption{service.KeepSubject()}, subject: "subject", description: "new description"},
+		"Keep description": {opts: []service.TODOOption{service.KeepDescription()}, subject: "new subject", description: "description"},
+		"Keep both":        {opts: []service.TODOOption{service.KeepSubject(), service.KeepDescription(), service.WithCompleted(true)}, subject: "subject", description: "description"},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			todo, err := svc.CreateTODO(ctx, "subject", "description")
+			if err != nil {
+				t.Fatal("failed to create todo, err =", err)
+			}
+			updated, err := svc.UpdateTODO(ctx, todo.ID, "new subject", "new description", c.opts...)
+			if err != nil {
+				t.Fatal("failed to update todo, err =", err)
+			}
+			if updated.Subject != c.subject || updated.Description != c.description {
+				t.Errorf("unexpected todo, given = %+v, expected subject = %q, description = %q", updated, c.subject, c.description)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// watcherBuffer は受け取られていないイベントを watcher ごとに溜めておける数
+const watcherBuffer = 64
+
+// A TODOEvents delivers the changes of TODOs to their watchers.
+// Share one among the TODOServices of the process by WithEvents, so that the
+// changes made by any of them are delivered.
+type TODOEvents struct {
+	mu       sync.Mutex
+	watchers map[chan *model.TODOEvent]struct{}
+	closed   bool
+}
+
+// NewTODOEvents returns new TODOEvents.
+func NewTODOEvents() *TODOEvents {
+	return &TODOEvents{
+		watchers: map[chan *model.TODOEvent]struct{}{},
+	}
+}
+
+// WithEvents makes TODOService deliver the changes of TODOs through events.
+func WithEvents(events *TODOEvents) TODOServiceOption {
+	return func(s *TODOService) {
+		s.events = events
+	}
+}
+
+// Close closes the channels of the watchers, and of those watching after the call, so
+// that their streams end before the server stops gracefully.
+func (e *TODOEvents) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.closed = true
+	for ch := range e.watchers {
+		delete(e.watchers, ch)
+		close(ch)
+	}
+}
+
+// WatchTODO returns a channel receiving the changes of TODOs committed after the call.
+// The channel is closed when ctx is done, when the events are closed by TODOEvents.Close,
+// or when the watcher falls behind by more than the buffered events so that it can watch
+// again and reload the TODOs.
+func (s *TODOService) WatchTODO(ctx context.Context) <-chan *model.TODOEvent {
+	e := s.events
+	ch := make(chan *model.TODOEvent, watcherBuffer)
+
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		close(ch)
+		return ch
+	}
+	e.watchers[ch] = struct{}{}
+	e.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		e.remove(ch)
+	}()
+	return ch
+}
+
+// WatchClosed reports whether the channels of WatchTODO are closed by TODOEvents.Close,
+// rather than because the watcher fell behind.
+func (s *TODOService) WatchClosed() bool {
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+	return s.events.closed
+}
+
+// publish はイベントをすべての watcher に送る。DB の変更をコミットしてから呼ぶ
+func (e *TODOEvents) publish(event *model.TODOEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for ch := range e.watchers {
+		select {
+		case ch <- event:
+		default:
+			// 受け取りが遅れている watcher のために他を待たせず、取りこぼしたことを閉じて伝える
+			delete(e.watchers, ch)
+			close(ch)
+		}
+	}
+}
+
+// watched は watcher がいるかを返す
+func (e *TODOEvents) watched() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.watchers) > 0
+}
+
+func (e *TODOEvents) remove(ch chan *model.TODOEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.watchers[ch]; ok {
+		delete(e.watchers, ch)
+		close(ch)
+	}
+}
+
+// todoChanges はトランザクションの中で作成・更新したTODOを、コミット後に watcher へ伝えるために控える
+type todoChanges struct {
+	changes []todoChange
+	seen    map[int64]bool
+}
+
+type todoChange struct {
+	id  int64
+	typ string
+}
+
+// create は作成したTODOを控える
+func (c *todoChanges) create(id int64) {
+	c.add(id, model.TODOEventCreated)
+}
+
+// update は更新したTODOを控える。すでに控えたTODOは、作成したものも含めて控え直さない
+func (c *todoChanges) update(ids ...int64) {
+	for _, id := range ids {
+		c.add(id, model.TODOEventUpdated)
+	}
+}
+
+func (c *todoChanges) add(id int64, typ string) {
+	if c.seen == nil {
+		c.seen = map[int64]bool{}
+	}
+	if c.seen[id] {
+		return
+	}
+	c.seen[id] = true
+	c.changes = append(c.changes, todoChange{id: id, typ: typ})
+}
+
+// publishChanges はコミットした changes のTODOを読み取り、控えた順にイベントとして送る。
+// コミット後に削除されたTODOは送らない
+func (s *TODOService) publishChanges(ctx context.Context, changes *todoChanges) {
+	if len(changes.changes) == 0 || !s.events.watched() {
+		return
+	}
+
+	ids := make([]int64, len(changes.changes))
+	for i, change := range changes.changes {
+		ids[i] = change.id
+	}
+	todos, err := s.ReadTODOsByIDs(ctx, ids)
+	if err != nil {
+		// 変更はコミット済みなので、呼び出し元には失敗を返さない
+		s.logger.WarnContext(ctx, "failed to read changed todos to publish", "err", err)
+		return
+	}
+	byID := make(map[int64]*model.TODO, len(todos))
+	for _, todo := range todos {
+		byID[todo.ID] = todo
+	}
+
+	for _, change := range changes.changes {
+		if todo, ok := byID[change.id]; ok {
+			s.events.publish(&model.TODOEvent{Type: change.typ, TODO: todo})
+		}
+	}
+}
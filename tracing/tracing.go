@@ -0,0 +1,49 @@
+// Package tracing builds the OpenTelemetry tracer provider exporting the spans of the server.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+// ServiceName is the service.name resource attribute of the spans.
+const ServiceName = "go-stations"
+
+// Exporters of NewExporter.
+const (
+	// ExporterOTLP sends spans over gRPC to the OTLP endpoint configured by the
+	// OTEL_EXPORTER_OTLP_* environment variables, localhost:4317 by default.
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes spans as JSON, mainly for debugging.
+	ExporterStdout = "stdout"
+)
+
+// NewExporter returns the span exporter of name, ExporterOTLP or ExporterStdout writing
+// to w. Tests may use the in-memory exporter of go.opentelemetry.io/otel/sdk/trace/tracetest
+// with NewTracerProvider instead.
+func NewExporter(ctx context.Context, name string, w io.Writer) (sdktrace.SpanExporter, error) {
+	switch name {
+	case ExporterOTLP:
+		return otlptracegrpc.New(ctx)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q", name)
+	}
+}
+
+// NewTracerProvider returns the tracer provider exporting the spans of ServiceName to
+// exporter in batches. It must be shut down to export the remaining spans.
+func NewTracerProvider(exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(ServiceName))),
+	)
+}
@@ -0,0 +1,37 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/tracing"
+)
+
+func TestNewExporter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	exporter, err := tracing.NewExporter(context.Background(), tracing.ExporterStdout, &buf)
+	if err != nil {
+		t.Fatal("failed to create exporter, err =", err)
+	}
+	tp := tracing.NewTracerProvider(exporter)
+	_, span := tp.Tracer("test").Start(context.Background(), "span")
+	span.End()
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatal("failed to shut down, err =", err)
+	}
+
+	// 終了時に残りのスパンを書き出す
+	for _, want := range []string{`"Name":"span"`, `"Value":"` + tracing.ServiceName + `"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("exported span must have %s, given = %s", want, buf.String())
+		}
+	}
+
+	if _, err := tracing.NewExporter(context.Background(), "zipkin", &buf); err == nil {
+		t.Error("unknown exporter must be rejected")
+	}
+}